@@ -0,0 +1,555 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dunamismax/go-modern-scaffold/db/migrations"
+	"github.com/dunamismax/go-modern-scaffold/internal/db"
+	"github.com/dunamismax/go-modern-scaffold/internal/form"
+	"github.com/dunamismax/go-modern-scaffold/internal/markdown"
+	"github.com/dunamismax/go-modern-scaffold/internal/transfer"
+	"github.com/dustin/go-humanize"
+	"github.com/pressly/goose/v3"
+	"github.com/spf13/cobra"
+)
+
+// seedMessages are the fixture messages admin seed imports.
+//
+//go:embed seed.ndjson
+var seedMessages []byte
+
+// seedChannels are the channels the fixture messages are posted to, besides
+// general.
+var seedChannels = []db.CreateChannelParams{
+	{Slug: "ops", Name: "Ops", Description: "Deploys, alerts and on-call", CreatedBy: "alice"},
+	{Slug: "random", Name: "Random", Description: "Everything else", CreatedBy: "bob"},
+}
+
+// newAdminCmd creates the admin command and its subcommands.
+func newAdminCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "admin",
+		Short: "Maintain the database directly",
+		Long: `Maintain the database directly.
+
+These commands open the database the server is configured to use, from
+DB_URL and DB_DRIVER in the environment or .env, rather than going through
+the server. Migrations are built into the binary.`,
+		Args: usageArgs(cobra.NoArgs),
+	}
+	cmd.AddCommand(
+		newMigrateCmd(),
+		newSeedCmd(),
+		newBackupCmd(),
+		newMaintenanceCmd("vacuum", "Rebuild the database file to reclaim free space", "VACUUM"),
+		newMaintenanceCmd("analyze", "Update the statistics the query planner uses", "ANALYZE"),
+		newCheckCmd(),
+		newStatsCmd(),
+	)
+	return cmd
+}
+
+// newMigrateCmd creates the admin migrate command and its subcommands.
+func newMigrateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply, roll back or list the schema migrations",
+		Args:  usageArgs(cobra.NoArgs),
+	}
+
+	var upTo int64
+	up := &cobra.Command{
+		Use:   "up",
+		Short: "Apply the pending migrations",
+		Args:  usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withMigrations(func(p *goose.Provider) error {
+				var results []*goose.MigrationResult
+				var err error
+				if upTo != 0 {
+					results, err = p.UpTo(cmd.Context(), upTo)
+				} else {
+					results, err = p.Up(cmd.Context())
+				}
+				return reportMigrations(cmd.ErrOrStderr(), results, err, "Applied")
+			})
+		},
+	}
+	up.Flags().Int64Var(&upTo, "to", 0, "apply the migrations up to and including this version")
+
+	var downTo int64
+	var all bool
+	down := &cobra.Command{
+		Use:   "down",
+		Short: "Roll back the latest migration",
+		Args:  usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if all && cmd.Flags().Changed("to") {
+				return usageError{errors.New("--all and --to cannot be used together")}
+			}
+			return withMigrations(func(p *goose.Provider) error {
+				var results []*goose.MigrationResult
+				var err error
+				switch {
+				case all:
+					results, err = p.DownTo(cmd.Context(), 0)
+				case cmd.Flags().Changed("to"):
+					results, err = p.DownTo(cmd.Context(), downTo)
+				default:
+					var result *goose.MigrationResult
+					if result, err = p.Down(cmd.Context()); result != nil {
+						results = append(results, result)
+					}
+				}
+				return reportMigrations(cmd.ErrOrStderr(), results, err, "Rolled back")
+			})
+		},
+	}
+	down.Flags().Int64Var(&downTo, "to", 0, "roll back the migrations after this version")
+	down.Flags().BoolVar(&all, "all", false, "roll back every migration")
+
+	var output string
+	status := &cobra.Command{
+		Use:   "status",
+		Short: "List the migrations and whether they are applied",
+		Args:  usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withMigrations(func(p *goose.Provider) error {
+				statuses, err := p.Status(cmd.Context())
+				if err != nil {
+					return err
+				}
+				type migrationStatus struct {
+					Version   int64      `json:"version"`
+					Name      string     `json:"name"`
+					State     string     `json:"state"`
+					AppliedAt *time.Time `json:"applied_at"`
+				}
+				list := make([]migrationStatus, len(statuses))
+				for i, s := range statuses {
+					list[i] = migrationStatus{Version: s.Source.Version, Name: filepath.Base(s.Source.Path), State: string(s.State)}
+					if s.State == goose.StateApplied {
+						list[i].AppliedAt = &s.AppliedAt
+					}
+				}
+				return printOutput(cmd.OutOrStdout(), output, list, func(w io.Writer) {
+					fmt.Fprintln(w, "VERSION\tNAME\tSTATE\tAPPLIED")
+					for _, s := range list {
+						applied := ""
+						if s.AppliedAt != nil {
+							applied = s.AppliedAt.Local().Format(timeFormat)
+						}
+						fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", s.Version, s.Name, s.State, applied)
+					}
+				})
+			})
+		},
+	}
+	addOutputFlag(status, &output)
+
+	cmd.AddCommand(up, down, status)
+	return cmd
+}
+
+// withMigrations calls fn with a migration provider for the configured
+// database.
+func withMigrations(fn func(*goose.Provider) error) error {
+	conn, err := openDB()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := db.CheckFTS5(context.Background(), conn); err != nil {
+		return err
+	}
+	p, err := goose.NewProvider(goose.DialectSQLite3, conn, migrations.FS)
+	if err != nil {
+		return err
+	}
+	return fn(p)
+}
+
+// reportMigrations reports the migrations applied or rolled back by a run
+// that returned results and err, and returns err unless it only says there
+// was nothing to do.
+func reportMigrations(w io.Writer, results []*goose.MigrationResult, err error, done string) error {
+	var partial *goose.PartialError
+	if errors.As(err, &partial) {
+		results = partial.Applied
+		err = fmt.Errorf("migration %s failed: %w", filepath.Base(partial.Failed.Source.Path), partial.Err)
+	}
+	if errors.Is(err, goose.ErrNoNextVersion) {
+		err = nil
+	}
+	for _, r := range results {
+		fmt.Fprintf(w, "%s %s (%s)\n", done, filepath.Base(r.Source.Path), r.Duration.Round(time.Millisecond))
+	}
+	if len(results) == 0 && err == nil {
+		fmt.Fprintln(w, "Nothing to do")
+	}
+	return err
+}
+
+// newSeedCmd creates the admin seed command.
+func newSeedCmd() *cobra.Command {
+	var force bool
+	cmd := &cobra.Command{
+		Use:   "seed",
+		Short: "Fill the database with sample channels and messages",
+		Long: `Fill the database with sample channels and messages, for development
+and demos. A database that already has messages is left alone unless
+--force is given.`,
+		Args: usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			conn, err := openDB()
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			var exists bool
+			if err := conn.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM messages)`).Scan(&exists); err != nil {
+				return err
+			}
+			if exists && !force {
+				return errors.New("the database already has messages; use --force to add the samples anyway")
+			}
+
+			store := db.NewStore(conn)
+			for _, arg := range seedChannels {
+				_, err := store.GetChannel(ctx, arg.Slug)
+				if err == nil {
+					continue
+				}
+				if !errors.Is(err, sql.ErrNoRows) {
+					return err
+				}
+				if _, err := store.CreateChannel(ctx, arg); err != nil {
+					return fmt.Errorf("create channel %s: %w", arg.Slug, err)
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "Created channel %s\n", arg.Slug)
+			}
+
+			validator, err := form.NewValidator()
+			if err != nil {
+				return err
+			}
+			importer := &transfer.Importer{
+				Store:     store,
+				Validator: validator,
+				Tags:      markdown.New(func(string) string { return "" }).Tags,
+			}
+			report, err := importer.Import(ctx, bytes.NewReader(seedMessages), transfer.NDJSON)
+			if err != nil {
+				return err
+			}
+			if len(report.Errors) > 0 {
+				return fmt.Errorf("seed messages: %w", report.Errors[0])
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Created %d messages\n", report.Imported)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&force, "force", false, "seed a database that already has messages")
+	return cmd
+}
+
+// newBackupCmd creates the admin backup command.
+func newBackupCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "backup <file>",
+		Short: "Copy the database to a file while the server keeps running",
+		Long: `Copy the database to a new file while the server keeps running.
+
+The copy is a consistent snapshot, written with VACUUM INTO, so it is also
+compacted. The file must not exist yet.`,
+		Example: `  cli admin backup backups/app-$(date +%F).db`,
+		Args:    usageArgs(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := args[0]
+			if _, err := os.Stat(path); err == nil {
+				return usageError{fmt.Errorf("%s already exists", path)}
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+			conn, err := openDB()
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			start := time.Now()
+			if _, err := conn.ExecContext(cmd.Context(), `VACUUM INTO ?`, path); err != nil {
+				os.Remove(path)
+				return fmt.Errorf("back up: %w", err)
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Backed up to %s (%s in %s)\n",
+				path, humanize.IBytes(uint64(info.Size())), time.Since(start).Round(time.Millisecond))
+			return nil
+		},
+	}
+}
+
+// newMaintenanceCmd creates an admin command that runs a maintenance
+// statement.
+func newMaintenanceCmd(name, short, stmt string) *cobra.Command {
+	return &cobra.Command{
+		Use:   name,
+		Short: short,
+		Args:  usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conn, err := openDB()
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			start := time.Now()
+			if _, err := conn.ExecContext(cmd.Context(), stmt); err != nil {
+				return fmt.Errorf("%s: %w", stmt, err)
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "%s done in %s\n", stmt, time.Since(start).Round(time.Millisecond))
+			return nil
+		},
+	}
+}
+
+// newCheckCmd creates the admin check command.
+func newCheckCmd() *cobra.Command {
+	var quick bool
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Check the database file for corruption",
+		Long: `Check the database file for corruption with PRAGMA integrity_check, and
+the foreign keys with PRAGMA foreign_key_check. The problems found are
+printed and the command fails if there are any.`,
+		Args: usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conn, err := openDB()
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			pragma := "integrity_check"
+			if quick {
+				pragma = "quick_check"
+			}
+			problems, err := integrityProblems(cmd.Context(), conn, pragma)
+			if err != nil {
+				return err
+			}
+			fkProblems, err := foreignKeyProblems(cmd.Context(), conn)
+			if err != nil {
+				return err
+			}
+			problems = append(problems, fkProblems...)
+			for _, p := range problems {
+				fmt.Fprintln(cmd.OutOrStdout(), p)
+			}
+			if len(problems) > 0 {
+				return fmt.Errorf("found %d problems", len(problems))
+			}
+			fmt.Fprintln(cmd.ErrOrStderr(), "No problems found")
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&quick, "quick", false, "skip the slower index checks (PRAGMA quick_check)")
+	return cmd
+}
+
+// integrityProblems runs an integrity check pragma and returns what it
+// reports, or nothing if the database is fine.
+func integrityProblems(ctx context.Context, conn *sql.DB, pragma string) ([]string, error) {
+	rows, err := conn.QueryContext(ctx, "PRAGMA "+pragma)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var problems []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, err
+		}
+		if line != "ok" {
+			problems = append(problems, line)
+		}
+	}
+	return problems, rows.Err()
+}
+
+// foreignKeyProblems returns the rows that refer to rows that do not exist.
+func foreignKeyProblems(ctx context.Context, conn *sql.DB) ([]string, error) {
+	rows, err := conn.QueryContext(ctx, "PRAGMA foreign_key_check")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var problems []string
+	for rows.Next() {
+		var table, parent string
+		var rowid sql.NullInt64
+		var fkid int64
+		if err := rows.Scan(&table, &rowid, &parent, &fkid); err != nil {
+			return nil, err
+		}
+		problems = append(problems, fmt.Sprintf("row %d of %s refers to a missing row of %s", rowid.Int64, table, parent))
+	}
+	return problems, rows.Err()
+}
+
+// tableStats describes a table for admin stats.
+type tableStats struct {
+	Name string `json:"name"`
+	Rows int64  `json:"rows"`
+	// Size is the space the table and its indexes take, in bytes, if SQLite
+	// was built with the dbstat table.
+	Size *int64 `json:"size,omitempty"`
+}
+
+// dbStats is the output of admin stats.
+type dbStats struct {
+	Path        string       `json:"path"`
+	JournalMode string       `json:"journal_mode"`
+	PageSize    int64        `json:"page_size"`
+	Pages       int64        `json:"pages"`
+	FreePages   int64        `json:"free_pages"`
+	Tables      []tableStats `json:"tables"`
+}
+
+// newStatsCmd creates the admin stats command.
+func newStatsCmd() *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show the size of the database and its tables",
+		Args:  usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conn, err := openDB()
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			stats, err := collectStats(cmd.Context(), conn)
+			if err != nil {
+				return err
+			}
+			return printOutput(cmd.OutOrStdout(), output, stats, func(w io.Writer) {
+				fmt.Fprintf(w, "Database:\t%s\n", stats.Path)
+				fmt.Fprintf(w, "Journal mode:\t%s\n", stats.JournalMode)
+				fmt.Fprintf(w, "Size:\t%s (%d pages of %s, %d free)\n",
+					humanize.IBytes(uint64(stats.Pages*stats.PageSize)), stats.Pages, humanize.IBytes(uint64(stats.PageSize)), stats.FreePages)
+				fmt.Fprintln(w)
+				fmt.Fprintln(w, "TABLE\tROWS\tSIZE")
+				for _, t := range stats.Tables {
+					size := "-"
+					if t.Size != nil {
+						size = humanize.IBytes(uint64(*t.Size))
+					}
+					fmt.Fprintf(w, "%s\t%d\t%s\n", t.Name, t.Rows, size)
+				}
+			})
+		},
+	}
+	addOutputFlag(cmd, &output)
+	return cmd
+}
+
+// collectStats gathers the statistics of the database.
+func collectStats(ctx context.Context, conn *sql.DB) (dbStats, error) {
+	var stats dbStats
+	var seq int
+	var name string
+	if err := conn.QueryRowContext(ctx, `PRAGMA database_list`).Scan(&seq, &name, &stats.Path); err != nil {
+		return stats, err
+	}
+	for pragma, dest := range map[string]any{
+		"journal_mode":   &stats.JournalMode,
+		"page_size":      &stats.PageSize,
+		"page_count":     &stats.Pages,
+		"freelist_count": &stats.FreePages,
+	} {
+		if err := conn.QueryRowContext(ctx, "PRAGMA "+pragma).Scan(dest); err != nil {
+			return stats, fmt.Errorf("PRAGMA %s: %w", pragma, err)
+		}
+	}
+
+	// The tables behind full-text indexes are listed too.
+	rows, err := conn.QueryContext(ctx, `
+		SELECT name FROM sqlite_schema
+		WHERE type = 'table' AND name NOT LIKE 'sqlite\_%' ESCAPE '\'
+		ORDER BY name`)
+	if err != nil {
+		return stats, err
+	}
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return stats, err
+		}
+		tables = append(tables, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return stats, err
+	}
+
+	sizes := tableSizes(ctx, conn)
+	for _, name := range tables {
+		t := tableStats{Name: name}
+		if err := conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM "`+strings.ReplaceAll(name, `"`, `""`)+`"`).Scan(&t.Rows); err != nil {
+			return stats, fmt.Errorf("count rows of %s: %w", name, err)
+		}
+		if size, ok := sizes[name]; ok {
+			t.Size = &size
+		}
+		stats.Tables = append(stats.Tables, t)
+	}
+	return stats, nil
+}
+
+// tableSizes returns the bytes each table takes with its indexes, or nil if
+// SQLite was built without the dbstat table.
+func tableSizes(ctx context.Context, conn *sql.DB) map[string]int64 {
+	rows, err := conn.QueryContext(ctx, `
+		SELECT COALESCE(i.tbl_name, s.name), SUM(s.pgsize)
+		FROM dbstat s LEFT JOIN sqlite_schema i ON i.name = s.name AND i.type = 'index'
+		GROUP BY 1`)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+	sizes := make(map[string]int64)
+	for rows.Next() {
+		var name string
+		var size int64
+		if err := rows.Scan(&name, &size); err != nil {
+			return nil
+		}
+		sizes[name] = size
+	}
+	if rows.Err() != nil {
+		return nil
+	}
+	return sizes
+}
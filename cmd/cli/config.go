@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// appName names the directory of the CLI's files in the user config
+// directory.
+const appName = "go-modern-scaffold"
+
+// Files in the config directory. Tokens are kept apart from the rest of the
+// configuration, so that config.yaml can be shared or shown without them.
+const (
+	configFile      = "config.yaml"
+	credentialsFile = "credentials.yaml"
+)
+
+// defaultProfile is the name login saves to unless told otherwise.
+const defaultProfile = "default"
+
+// Themes of the interactive views.
+const (
+	themeAuto  = "auto"  // follow the terminal's background
+	themeDark  = "dark"  // colors for a dark background
+	themeLight = "light" // colors for a light background
+	themeMono  = "mono"  // no colors
+)
+
+var themes = []string{themeAuto, themeDark, themeLight, themeMono}
+
+// profile holds the settings for one server.
+type profile struct {
+	Server string `yaml:"server,omitempty" json:"server,omitempty"`
+	User   string `yaml:"user,omitempty" json:"user,omitempty"`
+	Output string `yaml:"output,omitempty" json:"output,omitempty"`
+	Theme  string `yaml:"theme,omitempty" json:"theme,omitempty"`
+}
+
+// validate checks the values of the settings that are set.
+func (p profile) validate() error {
+	if p.Output != "" && !slices.Contains([]string{outputTable, outputJSON, outputYAML}, p.Output) {
+		return fmt.Errorf("unknown output format %q (want table, json or yaml)", p.Output)
+	}
+	if p.Theme != "" && !slices.Contains(themes, p.Theme) {
+		return fmt.Errorf("unknown theme %q (want auto, dark, light or mono)", p.Theme)
+	}
+	return nil
+}
+
+// cliConfig is the contents of config.yaml.
+type cliConfig struct {
+	// Current is the profile used unless another is selected.
+	Current  string             `yaml:"current,omitempty"`
+	Profiles map[string]profile `yaml:"profiles,omitempty"`
+}
+
+// credentials is the contents of credentials.yaml.
+type credentials struct {
+	// Tokens holds the API token of each profile that has one.
+	Tokens map[string]string `yaml:"tokens,omitempty"`
+}
+
+// configDir returns the directory of the CLI's files: $CLI_CONFIG_DIR, or
+// the app's directory in the user config directory, such as
+// ~/.config/go-modern-scaffold on Linux.
+func configDir() (string, error) {
+	if dir := os.Getenv("CLI_CONFIG_DIR"); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, appName), nil
+}
+
+// loadConfig reads config.yaml from dir. A missing file is an empty
+// configuration.
+func loadConfig(dir string) (*cliConfig, error) {
+	cfg := &cliConfig{}
+	if err := readYAMLFile(filepath.Join(dir, configFile), cfg); err != nil {
+		return nil, err
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = make(map[string]profile)
+	}
+	return cfg, nil
+}
+
+// save writes cfg to config.yaml in dir.
+func (cfg *cliConfig) save(dir string) error {
+	return writeYAMLFile(filepath.Join(dir, configFile), cfg)
+}
+
+// loadCredentials reads credentials.yaml from dir. It refuses to read a file
+// other users could read too, as the tokens in it may have leaked.
+func loadCredentials(dir string) (*credentials, error) {
+	path := filepath.Join(dir, credentialsFile)
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &credentials{Tokens: make(map[string]string)}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if perm := info.Mode().Perm(); perm&0o077 != 0 {
+		return nil, fmt.Errorf("%s is accessible by other users (mode %04o); restrict it with chmod 600 and consider replacing its tokens", path, perm)
+	}
+
+	creds := &credentials{}
+	if err := readYAMLFile(path, creds); err != nil {
+		return nil, err
+	}
+	if creds.Tokens == nil {
+		creds.Tokens = make(map[string]string)
+	}
+	return creds, nil
+}
+
+// save writes creds to credentials.yaml in dir.
+func (creds *credentials) save(dir string) error {
+	return writeYAMLFile(filepath.Join(dir, credentialsFile), creds)
+}
+
+// readYAMLFile decodes the YAML file at path into v, leaving v as it is if
+// the file does not exist.
+func readYAMLFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+	return nil
+}
+
+// writeYAMLFile writes v as YAML to path, readable only by the user. The file
+// is replaced at once, so a failed write does not leave it half written.
+func writeYAMLFile(path string, v any) error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	// CreateTemp creates the file with mode 0600.
+	f, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+)
+
+// newDashCmd creates the dash command.
+func newDashCmd(opts *globalOptions) *cobra.Command {
+	var interval time.Duration
+	cmd := &cobra.Command{
+		Use:   "dash",
+		Short: "Watch the health and metrics of the server",
+		Long: `Watch the health and metrics of the server in a dashboard.
+
+The dashboard polls the server's /health, /metrics and /version endpoints and
+shows the request rate and latency over time, the hit ratio of the cache, the
+state of the database connection pool and the errors the server logged last.
+Only admins may read the metrics; others see the health and version only.`,
+		Example: `  cli dash
+  cli dash --interval 5s --profile prod`,
+		Args: usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if interval < 500*time.Millisecond {
+				return usageError{errors.New("--interval must be at least 500ms")}
+			}
+			if !isatty.IsTerminal(os.Stdin.Fd()) || !isatty.IsTerminal(os.Stdout.Fd()) {
+				return usageError{errors.New("the dashboard needs a terminal")}
+			}
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return runDash(ctx, c, interval)
+		},
+	}
+	cmd.Flags().DurationVarP(&interval, "interval", "i", 2*time.Second, "how often to poll the server")
+	return cmd
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// draftsDir is the directory of the drafts in the config directory.
+const draftsDir = "drafts"
+
+// draft is a new message being written. Each is kept in a Markdown file of
+// its own, named after its ID and channel, such as 3.md or 4.ops.md, so that
+// it can be handed to an editor as it is and survives a crash of the CLI.
+type draft struct {
+	ID int `json:"id"`
+	// Channel is the channel the message is for; empty means the default.
+	Channel   string    `json:"channel"`
+	Body      string    `json:"body"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	path string
+}
+
+// drafts returns the drafts in the config directory.
+func (o *globalOptions) drafts() draftStore {
+	return draftStore{dir: filepath.Join(o.dir, draftsDir)}
+}
+
+// draftStore is a directory of drafts.
+type draftStore struct {
+	dir string
+}
+
+// create creates an empty draft for channel.
+func (s draftStore) create(channel string) (draft, error) {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return draft{}, err
+	}
+	list, err := s.list()
+	if err != nil {
+		return draft{}, err
+	}
+	id := 1
+	for _, d := range list {
+		id = max(id, d.ID+1)
+	}
+	// Another process may take the same ID, so keep trying the next.
+	for ; ; id++ {
+		name := strconv.Itoa(id)
+		if channel != "" {
+			name += "." + channel
+		}
+		path := filepath.Join(s.dir, name+".md")
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return draft{}, err
+		}
+		f.Close()
+		return draft{ID: id, Channel: channel, UpdatedAt: time.Now(), path: path}, nil
+	}
+}
+
+// list returns the drafts, most recently changed first.
+func (s draftStore) list() ([]draft, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var drafts []draft
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".md")
+		if !ok || e.IsDir() {
+			continue
+		}
+		idPart, channel, _ := strings.Cut(name, ".")
+		id, err := strconv.Atoi(idPart)
+		if err != nil || id <= 0 {
+			continue
+		}
+		d := draft{ID: id, Channel: channel, path: filepath.Join(s.dir, e.Name())}
+		if err := d.load(); err != nil {
+			return nil, err
+		}
+		drafts = append(drafts, d)
+	}
+	slices.SortFunc(drafts, func(a, b draft) int {
+		return cmp.Or(b.UpdatedAt.Compare(a.UpdatedAt), cmp.Compare(b.ID, a.ID))
+	})
+	return drafts, nil
+}
+
+// get returns the draft with id.
+func (s draftStore) get(id int) (draft, error) {
+	list, err := s.list()
+	if err != nil {
+		return draft{}, err
+	}
+	for _, d := range list {
+		if d.ID == id {
+			return d, nil
+		}
+	}
+	return draft{}, fmt.Errorf("draft %d does not exist", id)
+}
+
+// load reads the body of d from its file.
+func (d *draft) load() error {
+	data, err := os.ReadFile(d.path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(d.path)
+	if err != nil {
+		return err
+	}
+	d.Body, d.UpdatedAt = string(data), info.ModTime()
+	return nil
+}
+
+// save replaces the body of d. The file is replaced at once, so a crash
+// leaves either the old or the new body.
+func (d *draft) save(body string) error {
+	f, err := os.CreateTemp(filepath.Dir(d.path), ".draft.*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := io.WriteString(f, body); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(f.Name(), d.path); err != nil {
+		return err
+	}
+	d.Body, d.UpdatedAt = body, time.Now()
+	return nil
+}
+
+// remove deletes d.
+func (d draft) remove() error {
+	err := os.Remove(d.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// editorCommand returns the command that opens path in the user's editor:
+// $VISUAL, $EDITOR or vi. The variables may hold arguments too, as in
+// "code --wait".
+func editorCommand(path string) *exec.Cmd {
+	args := strings.Fields(cmp.Or(os.Getenv("VISUAL"), os.Getenv("EDITOR")))
+	if len(args) == 0 {
+		args = []string{"vi"}
+	}
+	return exec.Command(args[0], append(args[1:], path)...)
+}
+
+// newDraftsCmd creates the drafts command and its subcommands.
+func newDraftsCmd(opts *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "drafts",
+		Short: "Manage drafts of new messages",
+		Long: `Manage drafts of new messages.
+
+Messages written with cli post --edit or in the TUI are saved as drafts while
+they are written, and kept if they cannot be posted. Post a draft with
+cli post --draft <id>, or pick it in the TUI.`,
+		Args: usageArgs(cobra.NoArgs),
+	}
+	cmd.AddCommand(newDraftsListCmd(opts), newDraftsDeleteCmd(opts))
+	return cmd
+}
+
+// newDraftsListCmd creates the drafts list command.
+func newDraftsListCmd(opts *globalOptions) *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the drafts, most recently changed first",
+		Args:  usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			drafts, err := opts.drafts().list()
+			if err != nil {
+				return err
+			}
+			if drafts == nil {
+				drafts = []draft{}
+			}
+			return printOutput(cmd.OutOrStdout(), output, drafts, func(w io.Writer) {
+				fmt.Fprintln(w, "ID\tCHANNEL\tCHANGED\tMESSAGE")
+				for _, d := range drafts {
+					fmt.Fprintf(w, "%d\t%s\t%s\t%s\n",
+						d.ID, cmp.Or(d.Channel, "general"), d.UpdatedAt.Local().Format(timeFormat), oneLine(d.Body, 60))
+				}
+			})
+		},
+	}
+	addOutputFlag(cmd, &output)
+	return cmd
+}
+
+// newDraftsDeleteCmd creates the drafts delete command.
+func newDraftsDeleteCmd(opts *globalOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete <id>...",
+		Short: "Delete drafts",
+		Args:  usageArgs(cobra.MinimumNArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids := make([]int, len(args))
+			for i, arg := range args {
+				id, err := draftID(arg)
+				if err != nil {
+					return err
+				}
+				ids[i] = id
+			}
+			store := opts.drafts()
+			for _, id := range ids {
+				d, err := store.get(id)
+				if err != nil {
+					return err
+				}
+				if err := d.remove(); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "Deleted draft %d\n", id)
+			}
+			return nil
+		},
+	}
+}
+
+// draftID parses a draft ID argument.
+func draftID(arg string) (int, error) {
+	id, err := strconv.Atoi(arg)
+	if err != nil || id <= 0 {
+		return 0, usageError{fmt.Errorf("invalid draft ID %q", arg)}
+	}
+	return id, nil
+}
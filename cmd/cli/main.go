@@ -1,133 +1,251 @@
 package main
 
 import (
+	"cmp"
+	"context"
+	"errors"
 	"fmt"
-	"log"
-	"strings"
-	"time"
-
-	"github.com/charmbracelet/bubbles/spinner"
-	"github.com/charmbracelet/bubbles/textinput"
-	"github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/buildinfo"
+	"github.com/dunamismax/go-modern-scaffold/internal/client"
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
 )
 
-var (
-	docStyle = lipgloss.NewStyle().Margin(1, 2)
-
-	helpStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+// Exit codes, so scripts can tell failures apart.
+const (
+	exitOK          = 0
+	exitError       = 1 // any other failure
+	exitUsage       = 2 // invalid command, flag or argument
+	exitNotFound    = 3 // the message or channel does not exist
+	exitDenied      = 4 // the server refused the request for this user
+	exitUnavailable = 5 // the server could not be reached or failed
+	exitQueued      = 6 // the message was queued in the outbox to post later
 )
 
-type model struct {
-	textInput textinput.Model
-	spinner   spinner.Model
-	loading   bool
-	sent      bool
-	err       error
-}
+// defaultServer is the server used when none is configured.
+const defaultServer = "http://localhost:3000"
 
-func initialModel() model {
-	ti := textinput.New()
-	ti.Placeholder = "Enter a message..."
-	ti.Focus()
-	ti.CharLimit = 256
-	ti.Width = 50
-
-	sp := spinner.New()
-	sp.Spinner = spinner.Dot
-	sp.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
-
-	return model{
-		textInput: ti,
-		spinner:   sp,
-		loading:   false,
-		sent:      false,
-		err:       nil,
-	}
+// globalOptions are the flags shared by every command and the configuration
+// they select from.
+type globalOptions struct {
+	profile string
+	server  string
+	user    string
+
+	// dir and config are the config directory and its config.yaml, loaded
+	// before any command runs.
+	dir    string
+	config *cliConfig
 }
 
-func (m model) Init() tea.Cmd {
-	return tea.Batch(textinput.Blink, m.spinner.Tick)
+func main() {
+	err := newRootCmd().Execute()
+	// A queued message is reported as it is queued, not as a failure.
+	if err != nil && !errors.As(err, new(*queuedError)) {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+	}
+	os.Exit(exitCode(err))
 }
 
-func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	var cmd tea.Cmd
-
-	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		switch msg.Type {
-		case tea.KeyCtrlC, tea.KeyEsc:
-			return m, tea.Quit
-		case tea.KeyEnter:
-			if m.textInput.Value() != "" {
-				m.loading = true
-				m.sent = false
-				// Here you would typically send the message to the server.
-				// For this example, we'll just simulate a network request.
-				return m, tea.Tick(1*time.Second, func(t time.Time) tea.Msg {
-					return "message sent"
-				})
+// newRootCmd creates the cli command. Run without a subcommand in a terminal,
+// it starts the interactive message browser.
+func newRootCmd() *cobra.Command {
+	opts := &globalOptions{}
+	var channel string
+	root := &cobra.Command{
+		Use:     "cli",
+		Short:   "Go Modern Scaffold command-line client",
+		Version: buildinfo.Get().String(),
+		Long: `Go Modern Scaffold command-line client.
+
+Run without a command in a terminal to browse, post, edit and delete the
+messages of a channel interactively.
+
+Settings are taken from the flags, then the CLI_SERVER, CLI_USER, CLI_TOKEN,
+CLI_OUTPUT and CLI_THEME environment variables, then the selected profile in
+~/.config/go-modern-scaffold/config.yaml. Profiles are created with cli login;
+--profile or CLI_PROFILE selects one other than the current.
+
+Exit codes:
+  0  success
+  1  failure
+  2  invalid command, flag or argument
+  3  message or channel not found
+  4  permission denied
+  5  server unreachable or failing
+  6  message queued in the outbox, to be posted later`,
+		Args:          usageArgs(cobra.NoArgs),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.load(); err != nil {
+				return err
 			}
-		}
-
-	case string:
-		m.loading = false
-		m.sent = true
-		m.textInput.Reset()
-		return m, nil
-
-	case error:
-		m.err = msg
-		return m, nil
+			settings := opts.settings()
+			if err := settings.validate(); err != nil {
+				return usageError{err}
+			}
+			// Commands printing data default to the configured format.
+			if f := cmd.Flags().Lookup("output"); f != nil && !f.Changed && f.Annotations[outputFormatAnnotation] != nil {
+				if err := f.Value.Set(settings.Output); err != nil {
+					return err
+				}
+			}
+			applyTheme(settings.Theme)
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !isatty.IsTerminal(os.Stdin.Fd()) || !isatty.IsTerminal(os.Stdout.Fd()) {
+				return usageError{errors.New("no command given and not running in a terminal; see cli --help")}
+			}
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+			ob, err := opts.outbox()
+			if err != nil {
+				return err
+			}
+			defer ob.Close()
+			return runTUI(c, ob, opts.drafts(), channel)
+		},
 	}
+	root.Flags().StringVarP(&channel, "channel", "c", "", "channel to browse (default general)")
+	root.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return usageError{err}
+	})
+
+	flags := root.PersistentFlags()
+	flags.StringVarP(&opts.profile, "profile", "p", os.Getenv("CLI_PROFILE"), "profile to use instead of the current one (env CLI_PROFILE)")
+	flags.StringVar(&opts.server, "server", "", "URL of the server (env CLI_SERVER, default "+defaultServer+")")
+	flags.StringVar(&opts.user, "user", "", "username to act as (env CLI_USER)")
+	_ = root.RegisterFlagCompletionFunc("profile", opts.completeProfiles)
+
+	root.AddCommand(
+		newPostCmd(opts),
+		newListCmd(opts),
+		newGetCmd(opts),
+		newDeleteCmd(opts),
+		newTailCmd(opts),
+		newOutboxCmd(opts),
+		newDraftsCmd(opts),
+		newDashCmd(opts),
+		newVersionCmd(),
+		newLoginCmd(opts),
+		newLogoutCmd(opts),
+		newProfileCmd(opts),
+		newExportCmd(),
+		newImportCmd(),
+		newAdminCmd(),
+	)
+	return root
+}
 
-	var cmds []tea.Cmd
-	if m.loading {
-		m.spinner, cmd = m.spinner.Update(msg)
-		cmds = append(cmds, cmd)
-	} else {
-		m.textInput, cmd = m.textInput.Update(msg)
-		cmds = append(cmds, cmd)
+// load reads the configuration.
+func (o *globalOptions) load() error {
+	dir, err := configDir()
+	if err != nil {
+		return err
 	}
-
-	return m, tea.Batch(cmds...)
+	cfg, err := loadConfig(dir)
+	if err != nil {
+		return err
+	}
+	o.dir, o.config = dir, cfg
+	return nil
 }
 
-func (m model) View() string {
-	if m.err != nil {
-		return fmt.Sprintf("\nError: %v\n\n", m.err)
+// selectedProfile returns the name of the profile selected by --profile,
+// $CLI_PROFILE or the config file, or "" if none is, and its settings. ok is
+// false if the profile does not exist.
+func (o *globalOptions) selectedProfile() (name string, p profile, ok bool) {
+	name = o.profile
+	if name == "" {
+		name = o.config.Current
 	}
+	p, ok = o.config.Profiles[name]
+	return name, p, ok || name == ""
+}
 
-	var b strings.Builder
+// settings returns the settings commands run with: those of the flags,
+// environment variables and selected profile, in that order of precedence.
+func (o *globalOptions) settings() profile {
+	_, p, _ := o.selectedProfile()
+	return profile{
+		Server: cmp.Or(o.server, os.Getenv("CLI_SERVER"), p.Server, defaultServer),
+		User:   cmp.Or(o.user, os.Getenv("CLI_USER"), p.User),
+		Output: cmp.Or(os.Getenv("CLI_OUTPUT"), p.Output, outputTable),
+		Theme:  cmp.Or(os.Getenv("CLI_THEME"), p.Theme, themeAuto),
+	}
+}
 
-	b.WriteString(docStyle.Render(m.titleView()))
-	b.WriteString("\n")
+// client returns an API client configured by the settings.
+func (o *globalOptions) client() (*client.Client, error) {
+	name, _, ok := o.selectedProfile()
+	if !ok {
+		return nil, usageError{fmt.Errorf("profile %q does not exist; create it with cli login --profile %s", name, name)}
+	}
+	settings := o.settings()
+	c, err := client.New(settings.Server)
+	if err != nil {
+		return nil, usageError{err}
+	}
+	c.User = settings.User
 
-	if m.loading {
-		b.WriteString(fmt.Sprintf("%s Sending message...", m.spinner.View()))
-	} else {
-		b.WriteString(m.textInput.View())
-		if m.sent {
-			b.WriteString(helpStyle.Render("\nMessage sent!"))
+	c.Token = os.Getenv("CLI_TOKEN")
+	if c.Token == "" && name != "" {
+		creds, err := loadCredentials(o.dir)
+		if err != nil {
+			return nil, err
 		}
+		c.Token = creds.Tokens[name]
 	}
+	return c, nil
+}
 
-	b.WriteString(helpStyle.Render("\n\nPress Enter to send, Esc to quit."))
-
-	return b.String()
+// usageError is an error in how the command was invoked.
+type usageError struct {
+	err error
 }
 
-func (m model) titleView() string {
-	return lipgloss.NewStyle().
-		Background(lipgloss.Color("62")).
-		Foreground(lipgloss.Color("230")).
-		Padding(0, 1).
-		Render("Go Modern Scaffold CLI")
+func (e usageError) Error() string { return e.err.Error() }
+
+func (e usageError) Unwrap() error { return e.err }
+
+// usageArgs wraps a cobra argument validator so that its errors are usage
+// errors.
+func usageArgs(validate cobra.PositionalArgs) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if err := validate(cmd, args); err != nil {
+			return usageError{err}
+		}
+		return nil
+	}
 }
 
-func main() {
-	p := tea.NewProgram(initialModel())
-	if _, err := p.Run(); err != nil {
-		log.Fatalf("Alas, there's been an error: %v", err)
+// exitCode returns the exit code for the error a command returned.
+func exitCode(err error) int {
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, new(*queuedError)):
+		return exitQueued
+	case errors.As(err, new(usageError)):
+		return exitUsage
+	case client.IsStatus(err, http.StatusNotFound):
+		return exitNotFound
+	case client.IsStatus(err, http.StatusUnauthorized, http.StatusForbidden):
+		return exitDenied
+	case client.IsStatus(err, http.StatusBadRequest, http.StatusUnprocessableEntity):
+		return exitUsage
+	case client.IsStatus(err, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout),
+		errors.As(err, &netErr), errors.Is(err, context.DeadlineExceeded):
+		return exitUnavailable
 	}
+	return exitError
 }
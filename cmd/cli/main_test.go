@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/client"
+	"github.com/dunamismax/go-modern-scaffold/internal/outbox"
+)
+
+func TestExitCode(t *testing.T) {
+	unreachable := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"success", nil, exitOK},
+		{"failure", errors.New("boom"), exitError},
+		{"usage", usageError{errors.New("bad flag")}, exitUsage},
+		{"not found", &client.APIError{StatusCode: http.StatusNotFound}, exitNotFound},
+		{"denied", &client.APIError{StatusCode: http.StatusForbidden}, exitDenied},
+		{"invalid", &client.APIError{StatusCode: http.StatusUnprocessableEntity}, exitUsage},
+		{"server failing", &client.APIError{StatusCode: http.StatusBadGateway}, exitUnavailable},
+		{"unreachable", unreachable, exitUnavailable},
+		{"timeout", fmt.Errorf("list: %w", context.DeadlineExceeded), exitUnavailable},
+		{"queued", &queuedError{outbox.Entry{ID: 1}, unreachable}, exitQueued},
+	}
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("%s: exitCode(%v) = %d, want %d", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/client"
+	"github.com/dunamismax/go-modern-scaffold/internal/db"
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+)
+
+// timeFormat is how times are shown in tables.
+const timeFormat = "2006-01-02 15:04"
+
+// newPostCmd creates the post command.
+func newPostCmd(opts *globalOptions) *cobra.Command {
+	var channel, output string
+	var noQueue, edit bool
+	var draftNum int
+	cmd := &cobra.Command{
+		Use:   "post [message]",
+		Short: "Post a message",
+		Long: `Post a message given as arguments, or read from stdin when there are none or the only one is "-".
+
+With --edit, write the message in $VISUAL or $EDITOR instead, starting from
+the arguments if any. The message is kept as a draft until it is posted; see
+cli drafts.
+
+Messages queued in the outbox are posted first. If the server cannot be
+reached, the message is queued too and posted by the next post or
+cli outbox flush, and post exits with status 6.`,
+		Example: `  cli post "Deploy finished"
+  echo "deployed" | cli post --channel ops
+  cli post --edit --channel ops
+  cli post --draft 3 --edit`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var d *draft
+			var body string
+			if edit || draftNum != 0 {
+				var err error
+				if d, err = composeDraft(cmd, opts.drafts(), args, channel, draftNum, edit); err != nil {
+					return err
+				}
+				body = strings.TrimSpace(d.Body)
+				if !cmd.Flags().Changed("channel") {
+					channel = d.Channel
+				}
+			} else {
+				var err error
+				if body, err = messageBody(cmd, args); err != nil {
+					return err
+				}
+			}
+			msg, err := post(cmd, opts, channel, body, noQueue)
+			var queued *queuedError
+			if err != nil && !errors.As(err, &queued) {
+				if d != nil {
+					fmt.Fprintf(cmd.ErrOrStderr(), "The message is kept as draft %d; post it with cli post --draft %d\n", d.ID, d.ID)
+				}
+				return err
+			}
+			if d != nil {
+				if err := d.remove(); err != nil {
+					return err
+				}
+			}
+			if queued != nil {
+				return queued
+			}
+			return printMessage(cmd.OutOrStdout(), output, msg)
+		},
+	}
+	cmd.Flags().StringVarP(&channel, "channel", "c", "", "channel to post to (default general, or the draft's)")
+	cmd.Flags().BoolVar(&noQueue, "no-queue", false, "fail instead of queueing the message if the server cannot be reached")
+	cmd.Flags().BoolVarP(&edit, "edit", "e", false, "write the message in your editor")
+	cmd.Flags().IntVar(&draftNum, "draft", 0, "post the draft with this ID")
+	addOutputFlag(cmd, &output)
+	return cmd
+}
+
+// post posts a message through the outbox, or directly with noQueue. If the
+// message was queued, it says so and returns the *queuedError.
+func post(cmd *cobra.Command, opts *globalOptions, channel, body string, noQueue bool) (db.Message, error) {
+	c, err := opts.client()
+	if err != nil {
+		return db.Message{}, err
+	}
+
+	if noQueue {
+		return c.CreateMessage(cmd.Context(), channel, body, "")
+	}
+	ob, err := opts.outbox()
+	if err != nil {
+		return db.Message{}, err
+	}
+	defer ob.Close()
+
+	msg, err := postQueued(cmd.Context(), c, ob, channel, body)
+	if queued := (*queuedError)(nil); errors.As(err, &queued) {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Could not reach the server: %v\nQueued the message as outbox entry #%d; post it with cli outbox flush.\n",
+			queued.err, queued.entry.ID)
+		return db.Message{}, queued
+	}
+	return msg, err
+}
+
+// composeDraft returns the draft the post command posts: the draft with
+// draftNum or, when that is 0, a new one for channel holding args. With
+// edit, the draft is opened in the user's editor first. An empty draft is
+// deleted and refused.
+func composeDraft(cmd *cobra.Command, store draftStore, args []string, channel string, draftNum int, edit bool) (*draft, error) {
+	if edit && !isatty.IsTerminal(os.Stdin.Fd()) {
+		return nil, usageError{errors.New("--edit needs a terminal")}
+	}
+	var d draft
+	var err error
+	if draftNum != 0 {
+		if len(args) > 0 {
+			return nil, usageError{errors.New("a message cannot be given with --draft")}
+		}
+		if d, err = store.get(draftNum); err != nil {
+			return nil, usageError{err}
+		}
+	} else {
+		if d, err = store.create(channel); err != nil {
+			return nil, err
+		}
+		body := strings.Join(args, " ")
+		if body != "" {
+			body += "\n"
+		}
+		if err := d.save(body); err != nil {
+			return nil, err
+		}
+	}
+
+	if edit {
+		editor := editorCommand(d.path)
+		editor.Stdin, editor.Stdout, editor.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := editor.Run(); err != nil {
+			return nil, fmt.Errorf("run editor: %w (the message is kept as draft %d)", err, d.ID)
+		}
+		if err := d.load(); err != nil {
+			return nil, err
+		}
+	}
+	if strings.TrimSpace(d.Body) == "" {
+		if err := d.remove(); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "Deleted draft %d\n", d.ID)
+		return nil, usageError{errors.New("the message is empty; nothing was posted")}
+	}
+	return &d, nil
+}
+
+// newListCmd creates the list command.
+func newListCmd(opts *globalOptions) *cobra.Command {
+	var channel, query, output string
+	var limit int
+	var before int64
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the messages of a channel, newest first",
+		Long: `List the messages of a channel, newest first.
+
+The messages are listed a page at a time. To list the next page, pass the
+ID of the last message listed to --before.`,
+		Example: `  cli list --limit 100
+  cli list --before 1234`,
+		Args: usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if limit < 0 || limit > client.MaxListLimit {
+				return usageError{fmt.Errorf("--limit must be between 1 and %d", client.MaxListLimit)}
+			}
+			if before < 0 {
+				return usageError{errors.New("--before must not be negative")}
+			}
+			if query != "" && (limit != 0 || before != 0) {
+				return usageError{errors.New("--limit and --before cannot be used with --query")}
+			}
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+			messages, err := c.ListMessages(cmd.Context(), client.ListOptions{
+				Channel: channel,
+				Query:   query,
+				Limit:   limit,
+				Before:  before,
+			})
+			if err != nil {
+				return err
+			}
+			return printMessages(cmd.OutOrStdout(), output, messages)
+		},
+	}
+	cmd.Flags().StringVarP(&channel, "channel", "c", "", "channel to list (default general)")
+	cmd.Flags().StringVarP(&query, "query", "q", "", "only list messages matching this search")
+	cmd.Flags().IntVar(&limit, "limit", 0, "number of messages to list (default 50)")
+	cmd.Flags().Int64Var(&before, "before", 0, "only list messages with IDs below this one")
+	addOutputFlag(cmd, &output)
+	return cmd
+}
+
+// newGetCmd creates the get command.
+func newGetCmd(opts *globalOptions) *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "get <id>",
+		Short: "Show a message",
+		Args:  usageArgs(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := messageID(args[0])
+			if err != nil {
+				return err
+			}
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+
+			msg, err := c.GetMessage(cmd.Context(), id)
+			if err != nil {
+				return err
+			}
+			return printMessage(cmd.OutOrStdout(), output, msg)
+		},
+	}
+	addOutputFlag(cmd, &output)
+	return cmd
+}
+
+// newDeleteCmd creates the delete command.
+func newDeleteCmd(opts *globalOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete <id>...",
+		Short: "Delete messages",
+		Args:  usageArgs(cobra.MinimumNArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids := make([]int64, len(args))
+			for i, arg := range args {
+				var err error
+				if ids[i], err = messageID(arg); err != nil {
+					return err
+				}
+			}
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+
+			for _, id := range ids {
+				if err := c.DeleteMessage(cmd.Context(), id); err != nil {
+					return fmt.Errorf("delete message %d: %w", id, err)
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "Deleted message %d\n", id)
+			}
+			return nil
+		},
+	}
+}
+
+// messageBody returns the body of a new message from args or stdin.
+func messageBody(cmd *cobra.Command, args []string) (string, error) {
+	if len(args) > 0 && !(len(args) == 1 && args[0] == "-") {
+		return strings.Join(args, " "), nil
+	}
+	if f, ok := cmd.InOrStdin().(*os.File); ok && isatty.IsTerminal(f.Fd()) {
+		return "", usageError{errors.New("no message given; pass it as an argument or on stdin")}
+	}
+
+	data, err := io.ReadAll(cmd.InOrStdin())
+	if err != nil {
+		return "", err
+	}
+	body := strings.TrimSpace(string(data))
+	if body == "" {
+		return "", usageError{errors.New("the message read from stdin is empty")}
+	}
+	return body, nil
+}
+
+// messageID parses a message ID argument.
+func messageID(arg string) (int64, error) {
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, usageError{fmt.Errorf("invalid message ID %q", arg)}
+	}
+	return id, nil
+}
+
+// printMessage prints a single message, with its full body in table format.
+func printMessage(w io.Writer, format string, msg db.Message) error {
+	return printOutput(w, format, msg, func(w io.Writer) {
+		fmt.Fprintf(w, "ID:\t%d\n", msg.ID)
+		fmt.Fprintf(w, "Author:\t%s\n", msg.Author)
+		fmt.Fprintf(w, "Channel:\t%d\n", msg.ChannelID)
+		if msg.ParentID != nil {
+			fmt.Fprintf(w, "Reply to:\t%d\n", *msg.ParentID)
+		}
+		fmt.Fprintf(w, "Created:\t%s\n", msg.CreatedAt.Local().Format(timeFormat))
+		if !msg.UpdatedAt.Equal(msg.CreatedAt) {
+			fmt.Fprintf(w, "Edited:\t%s by %s\n", msg.UpdatedAt.Local().Format(timeFormat), msg.Editor)
+		}
+		fmt.Fprintf(w, "\n%s\n", msg.Body)
+	})
+}
+
+// printMessages prints a message list, one line per message in table format.
+func printMessages(w io.Writer, format string, messages []client.Message) error {
+	return printOutput(w, format, messages, func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tAUTHOR\tCREATED\tREPLIES\tMESSAGE")
+		for _, msg := range messages {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%s\n",
+				msg.ID, msg.Author, msg.CreatedAt.Local().Format(timeFormat), msg.ReplyCount, oneLine(msg.Body, 60))
+		}
+	})
+}
+
+// writeLogLine writes a message as a line of a chat log.
+func writeLogLine(w io.Writer, msg db.Message) {
+	fmt.Fprintf(w, "%s\t%s\t%s\n", msg.CreatedAt.Local().Format(timeFormat), msg.Author, oneLine(msg.Body, 100))
+}
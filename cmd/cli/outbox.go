@@ -0,0 +1,259 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/client"
+	"github.com/dunamismax/go-modern-scaffold/internal/db"
+	"github.com/dunamismax/go-modern-scaffold/internal/outbox"
+	"github.com/spf13/cobra"
+)
+
+// outboxFile is the outbox database in the config directory.
+const outboxFile = "outbox.db"
+
+// outbox opens the outbox in the config directory, readable only by the
+// user. The caller must close it.
+func (o *globalOptions) outbox() (*outbox.Outbox, error) {
+	if err := os.MkdirAll(o.dir, 0o700); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(o.dir, outboxFile)
+	f, err := os.OpenFile(path, os.O_RDONLY|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	return outbox.Open(path)
+}
+
+// queuedError reports that a message could not be posted yet and was left
+// in the outbox.
+type queuedError struct {
+	entry outbox.Entry
+	err   error // the error that stopped the flush
+}
+
+func (e *queuedError) Error() string {
+	return fmt.Sprintf("queued as outbox entry #%d: %v", e.entry.ID, e.err)
+}
+
+func (e *queuedError) Unwrap() error { return e.err }
+
+// postQueued posts a message through the outbox: it queues the message, then
+// flushes the queue of the client's user, so that messages queued earlier are
+// posted first. If the server cannot be reached, the message stays queued and
+// a *queuedError is returned. A message the server refuses is dropped again
+// and its error returned.
+func postQueued(ctx context.Context, c *client.Client, ob *outbox.Outbox, channel, body string) (db.Message, error) {
+	entry, err := ob.Add(ctx, c.BaseURL(), c.User, channel, body)
+	if err != nil {
+		return db.Message{}, err
+	}
+	var msg db.Message
+	var sendErr error
+	res, err := ob.Flush(ctx, outbox.FlushOptions{
+		Server:    c.BaseURL(),
+		User:      c.User,
+		Retryable: client.IsTemporary,
+	}, func(ctx context.Context, e outbox.Entry) error {
+		m, err := c.CreateMessage(ctx, e.Channel, e.Body, e.Key)
+		if e.ID == entry.ID {
+			msg, sendErr = m, err
+		}
+		return err
+	})
+	switch {
+	case err != nil:
+		return db.Message{}, err
+	case msg.ID != 0:
+		return msg, nil
+	case res.Err != nil:
+		return db.Message{}, &queuedError{entry, res.Err}
+	}
+	// The server refused the message, so it is failed.
+	if _, err := ob.Drop(ctx, entry.ID); err != nil {
+		return db.Message{}, err
+	}
+	return db.Message{}, sendErr
+}
+
+// flushOutbox posts the queued messages of the client's user. With due, only
+// those whose retry delay has passed are posted; with failed, failed ones are
+// retried too.
+func flushOutbox(ctx context.Context, c *client.Client, ob *outbox.Outbox, due, failed bool) (outbox.FlushResult, error) {
+	return ob.Flush(ctx, outbox.FlushOptions{
+		Server:    c.BaseURL(),
+		User:      c.User,
+		Due:       due,
+		Failed:    failed,
+		Retryable: client.IsTemporary,
+	}, func(ctx context.Context, e outbox.Entry) error {
+		_, err := c.CreateMessage(ctx, e.Channel, e.Body, e.Key)
+		return err
+	})
+}
+
+// newOutboxCmd creates the outbox command and its subcommands.
+func newOutboxCmd(opts *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "outbox",
+		Short: "Manage messages waiting to be posted",
+		Long: `Manage messages waiting to be posted.
+
+Messages that cannot be posted because the server is unreachable are kept in
+the outbox and posted, in order, by the next post or outbox flush. Each is
+sent with an idempotency key, so retries never post a message twice. Messages
+the server refuses are marked failed and kept until they are retried with
+outbox flush --failed or dropped.`,
+		Args: usageArgs(cobra.NoArgs),
+	}
+	cmd.AddCommand(
+		newOutboxListCmd(opts),
+		newOutboxFlushCmd(opts),
+		newOutboxDropCmd(opts),
+	)
+	return cmd
+}
+
+// newOutboxListCmd creates the outbox list command.
+func newOutboxListCmd(opts *globalOptions) *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the messages in the outbox, oldest first",
+		Args:  usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ob, err := opts.outbox()
+			if err != nil {
+				return err
+			}
+			defer ob.Close()
+
+			entries, err := ob.List(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if entries == nil {
+				entries = []outbox.Entry{}
+			}
+			return printOutput(cmd.OutOrStdout(), output, entries, func(w io.Writer) {
+				fmt.Fprintln(w, "ID\tSTATUS\tSERVER\tUSER\tCHANNEL\tATTEMPTS\tNEXT TRY / ERROR\tMESSAGE")
+				for _, e := range entries {
+					note := e.NextAttemptAt.Local().Format(timeFormat)
+					if e.Status == outbox.Failed {
+						note = oneLine(e.LastError, 40)
+					}
+					fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n", e.ID, e.Status, e.Server, e.User,
+						cmp.Or(e.Channel, "general"), e.Attempts, note, oneLine(e.Body, 50))
+				}
+			})
+		},
+	}
+	addOutputFlag(cmd, &output)
+	return cmd
+}
+
+// newOutboxFlushCmd creates the outbox flush command.
+func newOutboxFlushCmd(opts *globalOptions) *cobra.Command {
+	var failed bool
+	cmd := &cobra.Command{
+		Use:   "flush",
+		Short: "Post the queued messages now",
+		Long: `Post the queued messages of the current user on the current server now,
+oldest first, whether or not their retry delay has passed.`,
+		Args: usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+			ob, err := opts.outbox()
+			if err != nil {
+				return err
+			}
+			defer ob.Close()
+
+			res, err := flushOutbox(cmd.Context(), c, ob, false, failed)
+			if err != nil {
+				return err
+			}
+			errw := cmd.ErrOrStderr()
+			fmt.Fprintf(errw, "Posted %d, failed %d, %d still queued\n", res.Sent, res.Failed, res.Remaining)
+			if res.Err != nil {
+				return fmt.Errorf("stopped flushing: %w", res.Err)
+			}
+			if res.Failed > 0 {
+				return errors.New("some messages were refused; see cli outbox list")
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&failed, "failed", false, "retry failed messages too")
+	return cmd
+}
+
+// newOutboxDropCmd creates the outbox drop command.
+func newOutboxDropCmd(opts *globalOptions) *cobra.Command {
+	var all, failed bool
+	cmd := &cobra.Command{
+		Use:   "drop [id...]",
+		Short: "Remove messages from the outbox without posting them",
+		Example: `  cli outbox drop 3 4
+  cli outbox drop --failed`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if all && failed {
+				return usageError{errors.New("--all and --failed cannot be used together")}
+			}
+			if (all || failed) == (len(args) > 0) {
+				return usageError{errors.New("give either message IDs, --all or --failed")}
+			}
+			ids := make([]int64, len(args))
+			for i, arg := range args {
+				id, err := messageID(arg)
+				if err != nil {
+					return err
+				}
+				ids[i] = id
+			}
+			ob, err := opts.outbox()
+			if err != nil {
+				return err
+			}
+			defer ob.Close()
+
+			var n int
+			switch {
+			case all:
+				n, err = ob.DropStatus(cmd.Context(), "")
+			case failed:
+				n, err = ob.DropStatus(cmd.Context(), outbox.Failed)
+			default:
+				n, err = ob.Drop(cmd.Context(), ids...)
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Dropped %d messages\n", n)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&all, "all", false, "drop every message")
+	cmd.Flags().BoolVar(&failed, "failed", false, "drop the failed messages")
+	return cmd
+}
+
+// outboxStatus describes the outbox counts for a status line, or returns ""
+// if the outbox is empty.
+func outboxStatus(queued, failed int) string {
+	if queued == 0 && failed == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Outbox: %d queued, %d failed", queued, failed)
+}
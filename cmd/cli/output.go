@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats of the commands that print data.
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+// outputFormatAnnotation marks the --output flags that select an output
+// format, which default to that of the profile, from others of that name.
+const outputFormatAnnotation = "output-format"
+
+// addOutputFlag adds the --output flag to cmd, storing its value in format.
+func addOutputFlag(cmd *cobra.Command, format *string) {
+	cmd.Flags().StringVarP(format, "output", "o", outputTable, "output format: table, json or yaml")
+	_ = cmd.Flags().SetAnnotation("output", outputFormatAnnotation, []string{"true"})
+}
+
+// printOutput writes v to w in format. The table format is written by table,
+// which gets a tabwriter that is flushed afterwards.
+func printOutput(w io.Writer, format string, v any, table func(w io.Writer)) error {
+	switch format {
+	case outputTable:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		table(tw)
+		return tw.Flush()
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case outputYAML:
+		return writeYAML(w, v)
+	}
+	return usageError{fmt.Errorf("unknown output format %q (want table, json or yaml)", format)}
+}
+
+// writeYAML writes v as YAML with the keys and key order of its JSON form,
+// so both formats describe the same fields.
+func writeYAML(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	// JSON is YAML, so decoding it into a node keeps the key order.
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	blockStyle(&node)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// blockStyle clears the flow style JSON input gives collections, so they are
+// written as indented blocks.
+func blockStyle(n *yaml.Node) {
+	if n.Kind == yaml.MappingNode || n.Kind == yaml.SequenceNode {
+		n.Style = 0
+	}
+	if n.Kind == yaml.ScalarNode && n.Style == yaml.DoubleQuotedStyle {
+		n.Style = 0
+	}
+	for _, child := range n.Content {
+		blockStyle(child)
+	}
+}
+
+// oneLine shortens text to a single line of at most n characters for tables.
+func oneLine(text string, n int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,420 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/dunamismax/go-modern-scaffold/internal/client"
+	"github.com/spf13/cobra"
+)
+
+// profileInfo is a profile as shown by the profile commands. Tokens are
+// never shown, only whether there is one.
+type profileInfo struct {
+	Name    string `json:"name"`
+	Current bool   `json:"current"`
+	profile
+	Token bool `json:"token"`
+}
+
+// newLoginCmd creates the login command.
+func newLoginCmd(opts *globalOptions) *cobra.Command {
+	var withToken bool
+	cmd := &cobra.Command{
+		Use:   "login",
+		Short: "Save the server and credentials of a profile and switch to it",
+		Long: `Save the server, username and API token of a profile and make it the
+current one. The profile is the one selected by --profile, or else the current
+one, or else "default"; it is created if it does not exist.
+
+In a terminal, login asks for each setting not given as a flag, offering the
+saved value. Otherwise the token, if any, is read from stdin with --with-token.
+The token is sent as bearer token for the authenticating proxy in front of the
+server and saved in credentials.yaml, which only you can read.
+
+Before saving, login checks that the server accepts the credentials.`,
+		Example: `  cli login --profile staging --server https://staging.example.com
+  echo "$TOKEN" | cli login --profile prod --server https://chat.example.com --with-token`,
+		Args: usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name, _, _ := opts.selectedProfile()
+			name = cmp.Or(name, defaultProfile)
+			p := opts.config.Profiles[name]
+			creds, err := loadCredentials(opts.dir)
+			if err != nil {
+				return err
+			}
+			token := creds.Tokens[name]
+
+			in := bufio.NewReader(cmd.InOrStdin())
+			stdin, ok := cmd.InOrStdin().(*os.File)
+			interactive := ok && term.IsTerminal(stdin.Fd()) && !withToken
+			prompt := func(label, value string) (string, error) {
+				if value != "" {
+					fmt.Fprintf(cmd.ErrOrStderr(), "%s [%s]: ", label, value)
+				} else {
+					fmt.Fprintf(cmd.ErrOrStderr(), "%s: ", label)
+				}
+				line, err := in.ReadString('\n')
+				if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+					return "", err
+				}
+				return cmp.Or(strings.TrimSpace(line), value), nil
+			}
+
+			p.Server = cmp.Or(opts.server, p.Server)
+			p.User = cmp.Or(opts.user, p.User)
+			switch {
+			case withToken:
+				data, err := io.ReadAll(in)
+				if err != nil {
+					return err
+				}
+				if token = strings.TrimSpace(string(data)); token == "" {
+					return usageError{errors.New("no token on stdin")}
+				}
+			case interactive:
+				if opts.server == "" {
+					if p.Server, err = prompt("Server URL", cmp.Or(p.Server, defaultServer)); err != nil {
+						return err
+					}
+				}
+				if opts.user == "" {
+					if p.User, err = prompt("Username (empty if the proxy sets it)", p.User); err != nil {
+						return err
+					}
+				}
+				label := "API token (empty for none)"
+				if token != "" {
+					label = "API token (empty to keep the saved one)"
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "%s: ", label)
+				secret, err := term.ReadPassword(stdin.Fd())
+				fmt.Fprintln(cmd.ErrOrStderr())
+				if err != nil {
+					return err
+				}
+				token = cmp.Or(strings.TrimSpace(string(secret)), token)
+			}
+			p.Server = cmp.Or(p.Server, defaultServer)
+
+			c, err := client.New(p.Server)
+			if err != nil {
+				return usageError{err}
+			}
+			c.User, c.Token = p.User, token
+			id, err := c.Identity(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("log in to %s: %w", p.Server, err)
+			}
+
+			opts.config.Profiles[name] = p
+			opts.config.Current = name
+			if err := opts.config.save(opts.dir); err != nil {
+				return err
+			}
+			if token != "" {
+				creds.Tokens[name] = token
+			} else {
+				delete(creds.Tokens, name)
+			}
+			if err := creds.save(opts.dir); err != nil {
+				return err
+			}
+
+			user := id.User
+			if id.Admin {
+				user += " (admin)"
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Logged in to %s as %s with profile %q\n", p.Server, user, name)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&withToken, "with-token", false, "read the API token from stdin")
+	return cmd
+}
+
+// newLogoutCmd creates the logout command.
+func newLogoutCmd(opts *globalOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "logout",
+		Short: "Remove the API token of a profile",
+		Long:  `Remove the saved API token of the profile selected by --profile, or else the current one. Its other settings are kept.`,
+		Args:  usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name, _, ok := opts.selectedProfile()
+			if name == "" {
+				return usageError{errors.New("no profile selected")}
+			}
+			if !ok {
+				return usageError{fmt.Errorf("profile %q does not exist", name)}
+			}
+			creds, err := loadCredentials(opts.dir)
+			if err != nil {
+				return err
+			}
+			if _, ok := creds.Tokens[name]; !ok {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Profile %q has no token\n", name)
+				return nil
+			}
+			delete(creds.Tokens, name)
+			if err := creds.save(opts.dir); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Removed the token of profile %q\n", name)
+			return nil
+		},
+	}
+}
+
+// newProfileCmd creates the profile command and its subcommands.
+func newProfileCmd(opts *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Manage the profiles of the servers you use",
+		Long: `Manage the profiles in ~/.config/go-modern-scaffold/config.yaml. Each holds
+the server URL, username, default output format and theme to use with one
+server; its API token is kept in credentials.yaml next to it.`,
+		Args: usageArgs(cobra.NoArgs),
+	}
+	cmd.AddCommand(
+		newProfileListCmd(opts),
+		newProfileUseCmd(opts),
+		newProfileShowCmd(opts),
+		newProfileSetCmd(opts),
+		newProfileDeleteCmd(opts),
+	)
+	return cmd
+}
+
+// newProfileListCmd creates the profile list command.
+func newProfileListCmd(opts *globalOptions) *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the profiles",
+		Args:  usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			creds, err := loadCredentials(opts.dir)
+			if err != nil {
+				return err
+			}
+			infos := make([]profileInfo, 0, len(opts.config.Profiles))
+			for _, name := range slices.Sorted(maps.Keys(opts.config.Profiles)) {
+				infos = append(infos, opts.profileInfo(name, creds))
+			}
+			return printOutput(cmd.OutOrStdout(), output, infos, func(w io.Writer) {
+				fmt.Fprintln(w, "CURRENT\tNAME\tSERVER\tUSER\tTOKEN")
+				for _, info := range infos {
+					current := ""
+					if info.Current {
+						current = "*"
+					}
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", current, info.Name, info.Server, info.User, yesNo(info.Token))
+				}
+			})
+		},
+	}
+	addOutputFlag(cmd, &output)
+	return cmd
+}
+
+// newProfileUseCmd creates the profile use command.
+func newProfileUseCmd(opts *globalOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:               "use <name>",
+		Short:             "Make a profile the current one",
+		Args:              usageArgs(cobra.ExactArgs(1)),
+		ValidArgsFunction: opts.completeProfiles,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			p, ok := opts.config.Profiles[name]
+			if !ok {
+				return usageError{fmt.Errorf("profile %q does not exist", name)}
+			}
+			opts.config.Current = name
+			if err := opts.config.save(opts.dir); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Switched to profile %q (%s)\n", name, cmp.Or(p.Server, defaultServer))
+			return nil
+		},
+	}
+}
+
+// newProfileShowCmd creates the profile show command.
+func newProfileShowCmd(opts *globalOptions) *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:               "show [name]",
+		Short:             "Show a profile, by default the selected one",
+		Args:              usageArgs(cobra.MaximumNArgs(1)),
+		ValidArgsFunction: opts.completeProfiles,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name, _, _ := opts.selectedProfile()
+			if len(args) > 0 {
+				name = args[0]
+			}
+			if name == "" {
+				return usageError{errors.New("no profile selected")}
+			}
+			if _, ok := opts.config.Profiles[name]; !ok {
+				return usageError{fmt.Errorf("profile %q does not exist", name)}
+			}
+			creds, err := loadCredentials(opts.dir)
+			if err != nil {
+				return err
+			}
+			info := opts.profileInfo(name, creds)
+			return printOutput(cmd.OutOrStdout(), output, info, func(w io.Writer) {
+				fmt.Fprintf(w, "Name:\t%s\n", info.Name)
+				fmt.Fprintf(w, "Current:\t%s\n", yesNo(info.Current))
+				fmt.Fprintf(w, "Server:\t%s\n", info.Server)
+				fmt.Fprintf(w, "User:\t%s\n", info.User)
+				fmt.Fprintf(w, "Token:\t%s\n", yesNo(info.Token))
+				fmt.Fprintf(w, "Output:\t%s\n", info.Output)
+				fmt.Fprintf(w, "Theme:\t%s\n", info.Theme)
+			})
+		},
+	}
+	addOutputFlag(cmd, &output)
+	return cmd
+}
+
+// profileKeys are the settings profile set changes.
+var profileKeys = []string{"server", "user", "output", "theme"}
+
+// newProfileSetCmd creates the profile set command.
+func newProfileSetCmd(opts *globalOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Change a setting of a profile",
+		Long: `Change a setting of the profile selected by --profile, or else the current
+one, creating the profile if it does not exist. The keys are server, user,
+output (table, json or yaml) and theme (auto, dark, light or mono). An empty
+value removes the setting. Use login to change the token.`,
+		Example: `  cli profile set --profile staging server https://staging.example.com
+  cli profile set output json`,
+		Args: usageArgs(cobra.ExactArgs(2)),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 0 {
+				return profileKeys, cobra.ShellCompDirectiveNoFileComp
+			}
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name, _, _ := opts.selectedProfile()
+			name = cmp.Or(name, defaultProfile)
+			p := opts.config.Profiles[name]
+
+			key, value := args[0], strings.TrimSpace(args[1])
+			switch key {
+			case "server":
+				if value != "" {
+					if _, err := client.New(value); err != nil {
+						return usageError{err}
+					}
+				}
+				p.Server = value
+			case "user":
+				p.User = value
+			case "output":
+				p.Output = value
+			case "theme":
+				p.Theme = value
+			default:
+				return usageError{fmt.Errorf("unknown key %q (want %s)", key, strings.Join(profileKeys, ", "))}
+			}
+			if err := p.validate(); err != nil {
+				return usageError{err}
+			}
+
+			opts.config.Profiles[name] = p
+			if opts.config.Current == "" {
+				opts.config.Current = name
+			}
+			if err := opts.config.save(opts.dir); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Set %s of profile %q\n", key, name)
+			return nil
+		},
+	}
+}
+
+// newProfileDeleteCmd creates the profile delete command.
+func newProfileDeleteCmd(opts *globalOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:               "delete <name>...",
+		Short:             "Delete profiles and their tokens",
+		Args:              usageArgs(cobra.MinimumNArgs(1)),
+		ValidArgsFunction: opts.completeProfiles,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, name := range args {
+				if _, ok := opts.config.Profiles[name]; !ok {
+					return usageError{fmt.Errorf("profile %q does not exist", name)}
+				}
+			}
+			creds, err := loadCredentials(opts.dir)
+			if err != nil {
+				return err
+			}
+
+			for _, name := range args {
+				delete(opts.config.Profiles, name)
+				delete(creds.Tokens, name)
+				if opts.config.Current == name {
+					opts.config.Current = ""
+				}
+			}
+			// The tokens go first, so a failure cannot leave one behind.
+			if err := creds.save(opts.dir); err != nil {
+				return err
+			}
+			if err := opts.config.save(opts.dir); err != nil {
+				return err
+			}
+			for _, name := range args {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Deleted profile %q\n", name)
+			}
+			return nil
+		},
+	}
+}
+
+// profileInfo returns the profile called name as shown by the profile commands.
+func (o *globalOptions) profileInfo(name string, creds *credentials) profileInfo {
+	_, hasToken := creds.Tokens[name]
+	return profileInfo{
+		Name:    name,
+		Current: name == o.config.Current,
+		profile: o.config.Profiles[name],
+		Token:   hasToken,
+	}
+}
+
+// completeProfiles completes profile names for the shell.
+func (o *globalOptions) completeProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if o.config == nil {
+		if err := o.load(); err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+	}
+	return slices.Sorted(maps.Keys(o.config.Profiles)), cobra.ShellCompDirectiveNoFileComp
+}
+
+// yesNo formats a flag for tables.
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
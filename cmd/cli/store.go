@@ -0,0 +1,28 @@
+package main
+
+import (
+	"database/sql"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/config"
+	"github.com/dunamismax/go-modern-scaffold/internal/db"
+)
+
+// openDB opens the database the server is configured to use, for the
+// commands that work on it directly.
+func openDB() (*sql.DB, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	return db.Open(cfg.DBDriver, cfg.DBURL)
+}
+
+// openStore is openDB for the commands that only need its queries. The
+// caller must call the returned close function when done.
+func openStore() (db.Store, func() error, error) {
+	conn, err := openDB()
+	if err != nil {
+		return nil, nil, err
+	}
+	return db.NewStore(conn), conn.Close, nil
+}
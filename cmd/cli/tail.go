@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/client"
+	"github.com/dunamismax/go-modern-scaffold/internal/db"
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+)
+
+// newTailCmd creates the tail command.
+func newTailCmd(opts *globalOptions) *cobra.Command {
+	var channel, output string
+	var lines int
+	var follow bool
+	cmd := &cobra.Command{
+		Use:   "tail",
+		Short: "Show the latest messages of a channel, oldest first",
+		Long: `Show the latest messages of a channel, oldest first.
+
+With --follow, keep watching the channel for new messages and replies,
+reconnecting whenever the connection to the server is lost. In a terminal
+the messages are shown in a scrolling view; otherwise one line per message
+is printed: tab-separated in table format, a JSON object in json format and
+a YAML document in yaml format.`,
+		Example: `  cli tail -n 20 --channel ops
+  cli tail --follow | grep deploy`,
+		Args: usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if lines < 0 || lines > client.MaxListLimit {
+				return usageError{fmt.Errorf("--lines must be between 0 and %d", client.MaxListLimit)}
+			}
+			if !slices.Contains([]string{outputTable, outputJSON, outputYAML}, output) {
+				return usageError{fmt.Errorf("unknown output format %q (want table, json or yaml)", output)}
+			}
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+
+			list, err := c.ListMessages(cmd.Context(), client.ListOptions{Channel: channel, Limit: max(lines, 1)})
+			if err != nil {
+				return err
+			}
+			list = list[:min(lines, len(list))]
+			slices.Reverse(list)
+			if !follow {
+				return printOutput(cmd.OutOrStdout(), output, list, func(w io.Writer) {
+					for _, msg := range list {
+						writeLogLine(w, msg.Message)
+					}
+				})
+			}
+
+			messages := make([]db.Message, len(list))
+			for i, msg := range list {
+				messages[i] = msg.Message
+			}
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			if output == outputTable && isatty.IsTerminal(os.Stdin.Fd()) && isatty.IsTerminal(os.Stdout.Fd()) {
+				return runTail(ctx, c, channel, messages)
+			}
+			return followMessages(ctx, c, channel, messages, cmd.OutOrStdout(), cmd.ErrOrStderr(), output)
+		},
+	}
+	cmd.Flags().StringVarP(&channel, "channel", "c", "", "channel to show (default general)")
+	cmd.Flags().IntVarP(&lines, "lines", "n", 10, "number of messages to show")
+	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "keep showing new messages as they are posted")
+	addOutputFlag(cmd, &output)
+	return cmd
+}
+
+// followMessages prints messages and then every new message of channel, one
+// line each, until ctx is done. Connection changes are reported on errw.
+func followMessages(ctx context.Context, c *client.Client, channel string, messages []db.Message, w, errw io.Writer, format string) error {
+	write := func(msg db.Message) error {
+		switch format {
+		case outputJSON:
+			return json.NewEncoder(w).Encode(msg)
+		case outputYAML:
+			if _, err := io.WriteString(w, "---\n"); err != nil {
+				return err
+			}
+			return writeYAML(w, msg)
+		}
+		writeLogLine(w, msg)
+		return nil
+	}
+	for _, msg := range messages {
+		if err := write(msg); err != nil {
+			return err
+		}
+	}
+
+	var after int64
+	if len(messages) > 0 {
+		after = messages[len(messages)-1].ID
+	}
+	lost := false
+	err := c.FollowMessages(ctx, client.StreamOptions{
+		Channel: channel,
+		After:   after,
+		OnState: func(state client.StreamState, err error) {
+			switch {
+			case state == client.StreamReconnecting && !lost:
+				lost = true
+				fmt.Fprintf(errw, "Connection lost, reconnecting: %v\n", err)
+			case state == client.StreamConnected && lost:
+				lost = false
+				fmt.Fprintln(errw, "Reconnected")
+			}
+		},
+	}, write)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/form"
+	"github.com/dunamismax/go-modern-scaffold/internal/markdown"
+	"github.com/dunamismax/go-modern-scaffold/internal/transfer"
+	"github.com/spf13/cobra"
+)
+
+// newExportCmd creates the export command, which writes messages from the
+// database to a file or stdout.
+func newExportCmd() *cobra.Command {
+	var formatName, from, to, output string
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export messages to NDJSON, CSV or JSON",
+		Example: `  cli export > messages.ndjson
+  cli export --format csv --from 2026-01-01 --to 2026-02-01 -o january.csv`,
+		Args: usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := transfer.ParseFormat(formatName)
+			if err != nil {
+				return usageError{err}
+			}
+			var filter transfer.Filter
+			if from != "" {
+				if filter.Since, err = transfer.ParseTime(from); err != nil {
+					return usageError{err}
+				}
+			}
+			if to != "" {
+				if filter.Until, err = transfer.ParseTime(to); err != nil {
+					return usageError{err}
+				}
+			}
+
+			store, closeStore, err := openStore()
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			w := cmd.OutOrStdout()
+			if output != "" && output != "-" {
+				f, err := os.Create(output)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				w = f
+			}
+
+			n, err := transfer.Export(cmd.Context(), store, w, format, filter)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Exported %d messages\n", n)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&formatName, "format", "f", "ndjson", "file format: ndjson, csv or json")
+	cmd.Flags().StringVar(&from, "from", "", "only messages created at or after this date or RFC 3339 time")
+	cmd.Flags().StringVar(&to, "to", "", "only messages created before this date or RFC 3339 time")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "file to write instead of stdout")
+	return cmd
+}
+
+// newImportCmd creates the import command, which loads messages from a file
+// or stdin into the database.
+func newImportCmd() *cobra.Command {
+	var formatName string
+	var batchSize int
+	cmd := &cobra.Command{
+		Use:   "import [file]",
+		Short: "Import messages from NDJSON, CSV or JSON",
+		Long: `Import messages from a file written by export, or from stdin when no file
+or "-" is given. Records that cannot be imported are reported by line and
+skipped; the rest are committed in batches.`,
+		Example: `  cli import messages.ndjson
+  cli import --format csv < messages.csv`,
+		Args: usageArgs(cobra.MaximumNArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := transfer.ParseFormat(formatName)
+			if err != nil {
+				return usageError{err}
+			}
+			validator, err := form.NewValidator()
+			if err != nil {
+				return err
+			}
+
+			var r io.Reader = cmd.InOrStdin()
+			if len(args) == 1 && args[0] != "-" {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				r = f
+			}
+
+			store, closeStore, err := openStore()
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			// Only hashtags are extracted, so the links they would render to
+			// do not matter.
+			md := markdown.New(func(string) string { return "" })
+			importer := &transfer.Importer{
+				Store:     store,
+				Validator: validator,
+				Tags:      md.Tags,
+				BatchSize: batchSize,
+			}
+			report, err := importer.Import(cmd.Context(), r, format)
+
+			stderr := cmd.ErrOrStderr()
+			for _, lineErr := range report.Errors {
+				fmt.Fprintln(stderr, lineErr)
+			}
+			fmt.Fprintf(stderr, "Imported %d messages, skipped %d\n", report.Imported, len(report.Errors))
+			return err
+		},
+	}
+	cmd.Flags().StringVarP(&formatName, "format", "f", "ndjson", "file format: ndjson, csv or json")
+	cmd.Flags().IntVar(&batchSize, "batch-size", transfer.DefaultBatchSize, "records committed per transaction")
+	return cmd
+}
@@ -0,0 +1,753 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/viewport"
+	"github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/dunamismax/go-modern-scaffold/internal/client"
+	"github.com/dunamismax/go-modern-scaffold/internal/outbox"
+	"github.com/muesli/termenv"
+)
+
+// splitWidth is the narrowest terminal, in columns, the list and the detail
+// or compose pane are shown side by side in. Narrower terminals show only the
+// focused pane.
+const splitWidth = 90
+
+// messagePageSize is the number of messages the browser loads at a time.
+const messagePageSize = 50
+
+// loadMoreMargin is how close to the end of the list, in messages, the
+// selection gets before the browser loads the next page.
+const loadMoreMargin = 10
+
+// outboxInterval is how often the browser posts the messages in the outbox
+// that are due.
+const outboxInterval = 10 * time.Second
+
+var (
+	titleStyle = lipgloss.NewStyle().
+			Background(lipgloss.Color("62")).
+			Foreground(lipgloss.Color("230")).
+			Padding(0, 1)
+
+	helpStyle = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "245", Dark: "241"})
+
+	errorStyle = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "160", Dark: "196"})
+
+	metaStyle = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "242", Dark: "245"})
+
+	paneStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.AdaptiveColor{Light: "250", Dark: "238"}).
+			Padding(0, 1)
+
+	focusedPaneStyle = paneStyle.BorderForeground(lipgloss.Color("62"))
+)
+
+// applyTheme sets the colors of the interactive views for a theme, which
+// must be valid. Colors adapt to the terminal's background unless the theme
+// names one.
+func applyTheme(theme string) {
+	switch theme {
+	case themeDark:
+		lipgloss.SetHasDarkBackground(true)
+	case themeLight:
+		lipgloss.SetHasDarkBackground(false)
+	case themeMono:
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+}
+
+// pane identifies the part of the TUI that receives keys.
+type pane int
+
+const (
+	paneList pane = iota
+	paneDetail
+	paneCompose
+	paneConfirm
+	paneDrafts
+)
+
+// messageItem is a message in the list pane.
+type messageItem struct {
+	client.Message
+}
+
+// Title implements list.DefaultItem.
+func (i messageItem) Title() string { return oneLine(i.Body, 200) }
+
+// Description implements list.DefaultItem.
+func (i messageItem) Description() string {
+	desc := fmt.Sprintf("#%d · %s · %s", i.ID, i.Author, i.CreatedAt.Local().Format(timeFormat))
+	if i.ReplyCount > 0 {
+		desc += fmt.Sprintf(" · %d replies", i.ReplyCount)
+	}
+	return desc
+}
+
+// FilterValue implements list.Item.
+func (i messageItem) FilterValue() string { return i.Author + " " + i.Body }
+
+// Messages the commands of the browser report back with.
+type (
+	// messagesLoadedMsg carries the messages listed with opts.
+	messagesLoadedMsg struct {
+		messages []client.Message
+		opts     client.ListOptions
+	}
+	actionDoneMsg struct{ status string }
+	errMsg        struct{ err error }
+	// outboxMsg reports the outbox counts, after sent messages were posted
+	// from it. A non-empty status replaces the status line.
+	outboxMsg struct {
+		sent, queued, failed int
+		status               string
+	}
+	outboxTickMsg struct{}
+)
+
+// browser is the bubbletea model of the TUI: a list of the messages of a
+// channel beside a pane showing the selected message or composing one.
+type browser struct {
+	client  *client.Client
+	outbox  *outbox.Outbox
+	drafts  draftStore
+	channel string
+
+	list      list.Model
+	detail    viewport.Model
+	compose   textarea.Model
+	draftList list.Model
+	help      help.Model
+	spinner   spinner.Model
+	focus     pane
+	previous  pane // the pane to return to from compose and confirm
+
+	// editing is the ID of the message being edited, or 0 for a new one.
+	editing int64
+	// draft is where a new message is autosaved once it has been, and
+	// composeChannel the channel it is for.
+	draft          *draft
+	composeChannel string
+	// autosaves counts changes to the message being composed, so that only
+	// the autosave scheduled by the last one saves.
+	autosaves int
+	loading   bool
+	status    string
+	err       error
+	// more reports whether older messages may be left to load, and
+	// loadingMore whether the next page is being loaded.
+	more, loadingMore bool
+	// queued and failed count the user's messages in the outbox.
+	queued, failed int
+
+	width, height int
+}
+
+// newBrowser creates the TUI model for the messages of channel.
+func newBrowser(c *client.Client, ob *outbox.Outbox, drafts draftStore, channel string) browser {
+	delegate := list.NewDefaultDelegate()
+	l := list.New(nil, delegate, 0, 0)
+	l.Title = "Messages"
+	if channel != "" {
+		l.Title += " in " + channel
+	}
+	l.SetShowHelp(false)
+	l.DisableQuitKeybindings()
+
+	dl := list.New(nil, list.NewDefaultDelegate(), 0, 0)
+	dl.Title = "Drafts"
+	dl.SetShowHelp(false)
+	dl.DisableQuitKeybindings()
+
+	ta := textarea.New()
+	ta.Placeholder = "Write a message... (Markdown is supported)"
+	ta.CharLimit = 2000
+	ta.ShowLineNumbers = false
+
+	sp := spinner.New()
+	sp.Spinner = spinner.Dot
+	sp.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+
+	return browser{
+		client:    c,
+		outbox:    ob,
+		drafts:    drafts,
+		channel:   channel,
+		list:      l,
+		detail:    viewport.New(0, 0),
+		compose:   ta,
+		draftList: dl,
+		help:      help.New(),
+		spinner:   sp,
+		loading:   true,
+	}
+}
+
+// runTUI runs the interactive message browser. New messages go through ob,
+// which it keeps posting from while the server is unreachable, and are
+// autosaved to drafts while they are written.
+func runTUI(c *client.Client, ob *outbox.Outbox, drafts draftStore, channel string) error {
+	_, err := tea.NewProgram(newBrowser(c, ob, drafts, channel), tea.WithAltScreen()).Run()
+	return err
+}
+
+func (m browser) Init() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, m.load(), m.flushOutbox(), outboxTick())
+}
+
+func (m browser) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.resize()
+		return m, nil
+
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case messagesLoadedMsg:
+		items := make([]list.Item, len(msg.messages))
+		for i, message := range msg.messages {
+			items[i] = messageItem{message}
+		}
+		if msg.opts.Before == 0 {
+			m.loading = false
+		} else {
+			m.loadingMore = false
+			// A page requested before the list was reloaded may not follow
+			// it any more; scrolling requests it again.
+			if last, ok := m.lastItem(); !ok || last.ID != msg.opts.Before {
+				return m, nil
+			}
+			items = append(m.list.Items(), items...)
+		}
+		m.more = len(msg.messages) == msg.opts.Limit
+		cmd := m.list.SetItems(items)
+		m.showSelected()
+		return m, tea.Batch(cmd, m.loadMoreIfNeeded())
+
+	case actionDoneMsg:
+		m.status, m.err = msg.status, nil
+		return m, tea.Batch(m.load(), m.countOutbox())
+
+	case outboxMsg:
+		m.queued, m.failed = msg.queued, msg.failed
+		if msg.status != "" {
+			m.loading, m.status, m.err = false, msg.status, nil
+		}
+		if msg.sent > 0 {
+			m.loading = true
+			return m, m.load()
+		}
+		return m, nil
+
+	case outboxTickMsg:
+		return m, tea.Batch(m.flushOutbox(), outboxTick())
+
+	case autosaveMsg:
+		if msg.n == m.autosaves && m.focus == paneCompose && m.editing == 0 {
+			if err := m.saveDraft(); err != nil {
+				m.err = err
+			}
+		}
+		return m, nil
+
+	case editorDoneMsg:
+		return m.editorDone(msg)
+
+	case errMsg:
+		m.loading, m.loadingMore = false, false
+		m.err = msg.err
+		return m, nil
+
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			if m.focus == paneCompose && m.editing == 0 {
+				_ = m.saveDraft()
+			}
+			return m, tea.Quit
+		}
+		switch m.focus {
+		case paneConfirm:
+			return m.updateConfirm(msg)
+		case paneCompose:
+			return m.updateCompose(msg)
+		case paneDrafts:
+			return m.updateDrafts(msg)
+		case paneDetail:
+			return m.updateDetail(msg)
+		}
+		return m.updateList(msg)
+	}
+
+	// Other messages, such as the cursor blink, go to the focused component.
+	var cmd tea.Cmd
+	switch m.focus {
+	case paneCompose:
+		m.compose, cmd = m.compose.Update(msg)
+	case paneDetail:
+		m.detail, cmd = m.detail.Update(msg)
+	case paneDrafts:
+		m.draftList, cmd = m.draftList.Update(msg)
+	default:
+		m.list, cmd = m.list.Update(msg)
+	}
+	return m, cmd
+}
+
+// updateList handles a key press in the list pane.
+func (m browser) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// While filtering, keys are typed into the filter.
+	if m.list.FilterState() != list.Filtering {
+		switch {
+		case key.Matches(msg, keys.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, keys.Help):
+			m.help.ShowAll = !m.help.ShowAll
+			m.resize()
+			return m, nil
+		case key.Matches(msg, keys.Open):
+			if _, ok := m.selected(); ok {
+				m.focus = paneDetail
+				m.resize()
+			}
+			return m, nil
+		case key.Matches(msg, keys.New):
+			return m.startCompose(0, "")
+		case key.Matches(msg, keys.Drafts):
+			return m.openDrafts()
+		case key.Matches(msg, keys.Edit):
+			if item, ok := m.selected(); ok {
+				return m.startCompose(item.ID, item.Body)
+			}
+			return m, nil
+		case key.Matches(msg, keys.Delete):
+			return m.startConfirm()
+		case key.Matches(msg, keys.Refresh):
+			m.loading, m.status, m.err = true, "", nil
+			return m, m.load()
+		}
+	}
+
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	m.showSelected()
+	return m, tea.Batch(cmd, m.loadMoreIfNeeded())
+}
+
+// updateDetail handles a key press in the detail pane.
+func (m browser) updateDetail(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, keys.Quit):
+		return m, tea.Quit
+	case key.Matches(msg, keys.Back):
+		m.focus = paneList
+		m.resize()
+		return m, nil
+	case key.Matches(msg, keys.Help):
+		m.help.ShowAll = !m.help.ShowAll
+		m.resize()
+		return m, nil
+	case key.Matches(msg, keys.Edit):
+		if item, ok := m.selected(); ok {
+			return m.startCompose(item.ID, item.Body)
+		}
+		return m, nil
+	case key.Matches(msg, keys.Delete):
+		return m.startConfirm()
+	}
+
+	var cmd tea.Cmd
+	m.detail, cmd = m.detail.Update(msg)
+	return m, cmd
+}
+
+// updateCompose handles a key press in the compose pane.
+func (m browser) updateCompose(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.Type == tea.KeyEsc:
+		m.compose.Blur()
+		m.focus = m.previous
+		m.status, m.err = "", nil
+		if m.editing == 0 {
+			if err := m.saveDraft(); err != nil {
+				m.err = err
+			} else if m.draft != nil {
+				m.status = fmt.Sprintf("Saved draft %d", m.draft.ID)
+			}
+		}
+		m.resize()
+		return m, nil
+	case key.Matches(msg, keys.Editor):
+		return m.openEditor()
+	case key.Matches(msg, keys.Send):
+		body := strings.TrimSpace(m.compose.Value())
+		if body == "" {
+			m.err = fmt.Errorf("the message is empty")
+			return m, nil
+		}
+		// Keep the latest text should posting fail.
+		if m.editing == 0 {
+			if err := m.saveDraft(); err != nil {
+				m.err = err
+				return m, nil
+			}
+		}
+		m.compose.Blur()
+		m.focus = m.previous
+		m.loading, m.err = true, nil
+		m.resize()
+		return m, m.save(m.editing, body)
+	}
+
+	before := m.compose.Value()
+	var cmd tea.Cmd
+	m.compose, cmd = m.compose.Update(msg)
+	if m.editing == 0 && m.compose.Value() != before {
+		m.autosaves++
+		cmd = tea.Batch(cmd, autosave(m.autosaves))
+	}
+	return m, cmd
+}
+
+// updateConfirm handles a key press while asking to confirm a deletion.
+func (m browser) updateConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, keys.Confirm):
+		m.focus = paneList
+		item, ok := m.selected()
+		if !ok {
+			return m, nil
+		}
+		m.loading, m.err = true, nil
+		m.resize()
+		return m, m.delete(item.ID)
+	case key.Matches(msg, keys.Cancel):
+		m.focus = m.previous
+		m.resize()
+	}
+	return m, nil
+}
+
+// startCompose opens the compose pane, to edit the message with id or, when
+// id is 0, to write a new one.
+func (m browser) startCompose(id int64, body string) (tea.Model, tea.Cmd) {
+	m.previous = m.focus
+	m.focus = paneCompose
+	m.editing = id
+	m.draft, m.composeChannel = nil, m.channel
+	m.compose.SetValue(body)
+	m.status, m.err = "", nil
+	m.resize()
+	return m, m.compose.Focus()
+}
+
+// startConfirm asks to confirm deleting the selected message.
+func (m browser) startConfirm() (tea.Model, tea.Cmd) {
+	if _, ok := m.selected(); !ok {
+		return m, nil
+	}
+	m.previous = m.focus
+	m.focus = paneConfirm
+	m.status, m.err = "", nil
+	return m, nil
+}
+
+// selected returns the selected message.
+func (m browser) selected() (messageItem, bool) {
+	item, ok := m.list.SelectedItem().(messageItem)
+	return item, ok
+}
+
+// lastItem returns the last message in the list.
+func (m browser) lastItem() (messageItem, bool) {
+	items := m.list.Items()
+	if len(items) == 0 {
+		return messageItem{}, false
+	}
+	item, ok := items[len(items)-1].(messageItem)
+	return item, ok
+}
+
+// loadMoreIfNeeded loads the next page of messages once the selection nears
+// the end of the list. A filtered list only shows the messages loaded.
+func (m *browser) loadMoreIfNeeded() tea.Cmd {
+	if !m.more || m.loadingMore || m.list.FilterState() != list.Unfiltered {
+		return nil
+	}
+	if m.list.Index() < len(m.list.Items())-loadMoreMargin {
+		return nil
+	}
+	last, ok := m.lastItem()
+	if !ok {
+		return nil
+	}
+	m.loadingMore = true
+	return m.fetch(client.ListOptions{Channel: m.channel, Limit: messagePageSize, Before: last.ID})
+}
+
+// showSelected shows the selected message in the detail pane.
+func (m *browser) showSelected() {
+	item, ok := m.selected()
+	if !ok {
+		m.detail.SetContent(helpStyle.Render("No message selected."))
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString(metaStyle.Render(fmt.Sprintf("#%d by %s on %s", item.ID, item.Author, item.CreatedAt.Local().Format(timeFormat))))
+	if !item.UpdatedAt.Equal(item.CreatedAt) {
+		b.WriteString("\n" + metaStyle.Render(fmt.Sprintf("edited by %s on %s", item.Editor, item.UpdatedAt.Local().Format(timeFormat))))
+	}
+	if item.ReplyCount > 0 {
+		b.WriteString("\n" + metaStyle.Render(fmt.Sprintf("%d replies", item.ReplyCount)))
+	}
+	b.WriteString("\n\n")
+	b.WriteString(lipgloss.NewStyle().Width(m.detail.Width).Render(item.Body))
+	m.detail.SetContent(b.String())
+	m.detail.GotoTop()
+}
+
+// resize lays out the panes for the window size and the focused pane.
+func (m *browser) resize() {
+	if m.width == 0 {
+		return
+	}
+	m.help.Width = m.width
+	// The title, status and help lines take the rest.
+	height := m.height - 3 - lipgloss.Height(m.help.View(m.helpKeys()))
+	frameW, frameH := paneStyle.GetFrameSize()
+
+	listWidth, rightWidth := m.width, m.width
+	if m.width >= splitWidth {
+		listWidth = m.width * 2 / 5
+		rightWidth = m.width - listWidth
+	}
+	m.list.SetSize(listWidth-frameW, height-frameH)
+	m.detail.Width = rightWidth - frameW
+	m.detail.Height = height - frameH
+	m.compose.SetWidth(rightWidth - frameW)
+	m.compose.SetHeight(height - frameH - 2) // leave room for the heading
+	m.draftList.SetSize(rightWidth-frameW, height-frameH)
+	m.showSelected()
+}
+
+func (m browser) View() string {
+	if m.width == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	title := "Go Modern Scaffold"
+	if m.client.User != "" {
+		title += " · " + m.client.User
+	}
+	b.WriteString(titleStyle.Render(title) + "\n")
+
+	left := m.paneView(paneList, m.list.View(), m.list.Width())
+	var right string
+	switch m.focus {
+	case paneCompose:
+		heading := "New message"
+		if m.composeChannel != m.channel {
+			heading += " in " + cmp.Or(m.composeChannel, "general")
+		}
+		if m.draft != nil {
+			heading += fmt.Sprintf(" (draft %d)", m.draft.ID)
+		}
+		if m.editing != 0 {
+			heading = fmt.Sprintf("Edit message #%d", m.editing)
+		}
+		right = m.paneView(paneCompose, heading+"\n\n"+m.compose.View(), m.detail.Width)
+	case paneDrafts:
+		right = m.paneView(paneDrafts, m.draftList.View(), m.detail.Width)
+	default:
+		right = m.paneView(paneDetail, m.detail.View(), m.detail.Width)
+	}
+
+	switch {
+	case m.width >= splitWidth:
+		b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, left, right))
+	case m.focus == paneList || m.focus == paneConfirm && m.previous == paneList:
+		b.WriteString(left)
+	default:
+		b.WriteString(right)
+	}
+	b.WriteString("\n" + m.statusView() + "\n")
+	b.WriteString(m.help.View(m.helpKeys()))
+	return b.String()
+}
+
+// paneView renders content in a bordered pane of the given inner width,
+// highlighted when focused.
+func (m browser) paneView(p pane, content string, width int) string {
+	style := paneStyle
+	if m.focus == p || m.focus == paneConfirm && m.previous == p {
+		style = focusedPaneStyle
+	}
+	return style.Width(width + style.GetHorizontalPadding()).Render(content)
+}
+
+// statusView renders the line below the panes, led by the outbox counts
+// while the outbox is not empty.
+func (m browser) statusView() string {
+	if m.focus == paneConfirm {
+		item, _ := m.selected()
+		return errorStyle.Render(fmt.Sprintf("Delete message #%d? (y/n)", item.ID))
+	}
+	var status string
+	switch {
+	case m.loading || m.loadingMore:
+		status = m.spinner.View() + " Loading..."
+	case m.err != nil:
+		status = errorStyle.Render("Error: " + m.err.Error())
+	default:
+		status = helpStyle.Render(m.status)
+	}
+	if counts := outboxStatus(m.queued, m.failed); counts != "" {
+		style := helpStyle
+		if m.failed > 0 {
+			style = errorStyle
+		}
+		status = style.Render(counts) + "  " + status
+	}
+	return status
+}
+
+// helpKeys returns the key bindings of the focused pane.
+func (m browser) helpKeys() paneKeys {
+	switch m.focus {
+	case paneConfirm:
+		return paneKeys{short: []key.Binding{keys.Confirm, keys.Cancel}}
+	case paneCompose:
+		return paneKeys{short: []key.Binding{keys.Send, keys.Editor, keys.Back}}
+	case paneDrafts:
+		return paneKeys{short: []key.Binding{keys.Resume, keys.Delete, keys.Filter, keys.Back}}
+	case paneDetail:
+		return paneKeys{
+			short: []key.Binding{keys.Back, keys.Edit, keys.Delete, keys.Help, keys.Quit},
+			full: [][]key.Binding{
+				{keys.Up, keys.Down, keys.Back},
+				{keys.Edit, keys.Delete},
+				{keys.Help, keys.Quit},
+			},
+		}
+	}
+	return paneKeys{
+		short: []key.Binding{keys.Open, keys.New, keys.Delete, keys.Help, keys.Quit},
+		full: [][]key.Binding{
+			{keys.Up, keys.Down, keys.Open, keys.Filter},
+			{keys.New, keys.Drafts, keys.Edit, keys.Delete, keys.Refresh},
+			{keys.Help, keys.Quit},
+		},
+	}
+}
+
+// load fetches the newest messages of the channel to replace the list with:
+// as many as it holds, so that reloading keeps the pages loaded so far, but
+// at least a page.
+func (m browser) load() tea.Cmd {
+	limit := min(max(len(m.list.Items()), messagePageSize), client.MaxListLimit)
+	return m.fetch(client.ListOptions{Channel: m.channel, Limit: limit})
+}
+
+// fetch lists the messages selected by opts.
+func (m browser) fetch(opts client.ListOptions) tea.Cmd {
+	return func() tea.Msg {
+		messages, err := m.client.ListMessages(context.Background(), opts)
+		if err != nil {
+			return errMsg{err}
+		}
+		return messagesLoadedMsg{messages, opts}
+	}
+}
+
+// save creates a message, or updates the message with id if it is not 0.
+func (m browser) save(id int64, body string) tea.Cmd {
+	return func() tea.Msg {
+		if id == 0 {
+			// Once in the outbox, the message no longer needs its draft.
+			msg, err := postQueued(context.Background(), m.client, m.outbox, m.composeChannel, body)
+			if queued := (*queuedError)(nil); errors.As(err, &queued) {
+				if err := m.removeDraft(); err != nil {
+					return errMsg{err}
+				}
+				return m.outboxCounts(0, "Could not reach the server; message queued in the outbox")
+			}
+			if err != nil {
+				return errMsg{err}
+			}
+			if err := m.removeDraft(); err != nil {
+				return errMsg{err}
+			}
+			return actionDoneMsg{fmt.Sprintf("Posted message #%d", msg.ID)}
+		}
+		if _, err := m.client.UpdateMessage(context.Background(), id, body); err != nil {
+			return errMsg{err}
+		}
+		return actionDoneMsg{fmt.Sprintf("Saved message #%d", id)}
+	}
+}
+
+// delete deletes the message with id.
+func (m browser) delete(id int64) tea.Cmd {
+	return func() tea.Msg {
+		if err := m.client.DeleteMessage(context.Background(), id); err != nil {
+			return errMsg{err}
+		}
+		return actionDoneMsg{fmt.Sprintf("Deleted message #%d", id)}
+	}
+}
+
+// flushOutbox posts the messages in the outbox that are due.
+func (m browser) flushOutbox() tea.Cmd {
+	return func() tea.Msg {
+		res, err := flushOutbox(context.Background(), m.client, m.outbox, true, false)
+		if err != nil {
+			return errMsg{err}
+		}
+		var status string
+		if res.Sent > 0 {
+			status = fmt.Sprintf("Posted %d queued messages", res.Sent)
+		}
+		return m.outboxCounts(res.Sent, status)
+	}
+}
+
+// countOutbox refreshes the outbox counts.
+func (m browser) countOutbox() tea.Cmd {
+	return func() tea.Msg {
+		return m.outboxCounts(0, "")
+	}
+}
+
+// outboxCounts returns an outboxMsg with the current outbox counts.
+func (m browser) outboxCounts(sent int, status string) tea.Msg {
+	queued, failed, err := m.outbox.Counts(context.Background(), m.client.BaseURL(), m.client.User)
+	if err != nil {
+		return errMsg{err}
+	}
+	return outboxMsg{sent: sent, queued: queued, failed: failed, status: status}
+}
+
+// outboxTick schedules the next outbox flush.
+func outboxTick() tea.Cmd {
+	return tea.Tick(outboxInterval, func(time.Time) tea.Msg { return outboxTickMsg{} })
+}
@@ -0,0 +1,491 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/dunamismax/go-modern-scaffold/internal/buildinfo"
+	"github.com/dunamismax/go-modern-scaffold/internal/client"
+	"github.com/dunamismax/go-modern-scaffold/internal/metrics"
+)
+
+const (
+	// dashHistory is the number of samples the dashboard keeps for its
+	// sparklines, enough for the widest terminals.
+	dashHistory = 300
+	// dashTimeout bounds each poll of the server.
+	dashTimeout = 5 * time.Second
+	// dashPanelLines is the number of lines of each panel but that of the
+	// errors, heading included.
+	dashPanelLines = 5
+)
+
+var (
+	headingStyle = lipgloss.NewStyle().Bold(true)
+
+	sparkStyle = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "62", Dark: "99"})
+)
+
+// sparkBlocks are the bars of a sparkline, lowest first.
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// Messages of the dashboard.
+type (
+	// dashTickMsg asks for the nth scheduled poll.
+	dashTickMsg struct{ n int }
+	// dashPollMsg carries what a poll found. version is nil if it was not
+	// asked for or could not be read.
+	dashPollMsg struct {
+		healthErr  error
+		report     metrics.Report
+		metricsErr error
+		version    *buildinfo.Info
+	}
+)
+
+// dashboard is the bubbletea model of cli dash: panels of the metrics of
+// the server, polled every interval.
+type dashboard struct {
+	ctx      context.Context
+	client   *client.Client
+	interval time.Duration
+	help     help.Model
+
+	polling bool
+	// ticks counts the polls scheduled; a tick for an earlier one is stale.
+	ticks  int
+	polled time.Time
+
+	healthErr  error
+	metricsErr error
+	version    *buildinfo.Info
+	report     *metrics.Report
+	// requests and cache are the use of the server between its last two
+	// reports, elapsed seconds apart. elapsed is 0 until there are two.
+	requests metrics.Requests
+	cache    metrics.Cache
+	elapsed  float64
+	// rates and latencies are the request rate and mean latency between
+	// each pair of consecutive reports, oldest first.
+	rates, latencies []float64
+
+	width, height int
+}
+
+// runDash runs the dashboard until the user quits or ctx is done.
+func runDash(ctx context.Context, c *client.Client, interval time.Duration) error {
+	_, err := tea.NewProgram(dashboard{
+		ctx:      ctx,
+		client:   c,
+		interval: interval,
+		help:     help.New(),
+		polling:  true,
+	}, tea.WithAltScreen(), tea.WithContext(ctx)).Run()
+	if errors.Is(err, tea.ErrProgramKilled) && ctx.Err() != nil {
+		return nil // interrupted
+	}
+	return err
+}
+
+func (m dashboard) Init() tea.Cmd {
+	return m.poll()
+}
+
+func (m dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.help.Width = msg.Width
+		return m, nil
+
+	case dashTickMsg:
+		if msg.n != m.ticks || m.polling {
+			return m, nil
+		}
+		m.polling = true
+		return m, m.poll()
+
+	case dashPollMsg:
+		m.polling = false
+		m.polled = time.Now()
+		m.healthErr = msg.healthErr
+		if msg.healthErr == nil {
+			m.metricsErr = msg.metricsErr
+			if msg.metricsErr == nil {
+				m.record(msg.report)
+			}
+			if msg.version != nil {
+				m.version = msg.version
+			}
+		}
+		m.ticks++
+		return m, m.tick()
+
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, keys.Refresh):
+			if m.polling {
+				return m, nil
+			}
+			// The poll schedules the next tick; the pending one is stale.
+			m.polling = true
+			m.ticks++
+			return m, m.poll()
+		}
+	}
+	return m, nil
+}
+
+// tick schedules the next poll.
+func (m dashboard) tick() tea.Cmd {
+	n := m.ticks
+	return tea.Tick(m.interval, func(time.Time) tea.Msg { return dashTickMsg{n} })
+}
+
+// poll checks the health of the server and reads its metrics. The version
+// is read too if the server restarted since the last poll, as it can only
+// change then, or if the metrics cannot tell.
+func (m dashboard) poll() tea.Cmd {
+	var started time.Time
+	if m.report != nil {
+		started = m.report.Started
+	}
+	known := m.version != nil
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(m.ctx, dashTimeout)
+		defer cancel()
+
+		var msg dashPollMsg
+		if _, msg.healthErr = m.client.Health(ctx); msg.healthErr != nil {
+			return msg
+		}
+		msg.report, msg.metricsErr = m.client.Metrics(ctx)
+		if !known || msg.metricsErr != nil || !msg.report.Started.Equal(started) {
+			if info, err := m.client.Version(ctx); err == nil {
+				msg.version = &info
+			}
+		}
+		return msg
+	}
+}
+
+// record takes in a new report, adding the use of the server since the
+// previous one to the history.
+func (m *dashboard) record(report metrics.Report) {
+	switch {
+	case m.report == nil:
+	case !report.Started.Equal(m.report.Started):
+		// The server restarted, so its counters started over.
+		m.rates, m.latencies, m.elapsed = nil, nil, 0
+	default:
+		elapsed := report.Time.Sub(m.report.Time).Seconds()
+		if elapsed <= 0 {
+			break
+		}
+		m.requests = report.Requests.Sub(m.report.Requests)
+		m.cache = report.Cache.Sub(m.report.Cache)
+		m.elapsed = elapsed
+		m.rates = appendSample(m.rates, float64(m.requests.Total)/elapsed)
+		m.latencies = appendSample(m.latencies, m.requests.MeanLatency())
+	}
+	m.report = &report
+}
+
+// appendSample appends v to samples, dropping the oldest beyond
+// dashHistory.
+func appendSample(samples []float64, v float64) []float64 {
+	samples = append(samples, v)
+	return samples[max(len(samples)-dashHistory, 0):]
+}
+
+func (m dashboard) View() string {
+	if m.width == 0 {
+		return ""
+	}
+	title := titleStyle.Render("Go Modern Scaffold · " + m.client.BaseURL())
+	help := m.help.View(paneKeys{short: []key.Binding{keys.Refresh, keys.Quit}})
+	// The title, status bar and help lines take the rest.
+	return title + "\n" + m.statusView() + "\n" + m.panelsView(max(m.height-3, 0)) + "\n" + help
+}
+
+// statusView renders the status bar: the health and version of the server
+// on the left and when it was last polled on the right.
+func (m dashboard) statusView() string {
+	dot, state := connectingStyle, "Connecting..."
+	switch {
+	case m.healthErr != nil:
+		dot, state = disconnectedStyle, "Unreachable: "+m.healthErr.Error()
+	case !m.polled.IsZero():
+		dot, state = connectedStyle, "Healthy"
+		if m.version != nil {
+			state += " · " + m.version.Version
+			if m.version.Revision != "" {
+				state += " (" + m.version.ShortRevision() + ")"
+			}
+		}
+		if m.report != nil {
+			state += " · up " + m.report.Time.Sub(m.report.Started).Round(time.Second).String()
+			if m.metricsErr != nil {
+				// The panels show the metrics last read.
+				state += " · cannot read metrics: " + m.metricsErr.Error()
+			}
+		}
+	}
+	polled := "every " + m.interval.String()
+	if !m.polled.IsZero() {
+		polled = "polled " + m.polled.Format("15:04:05") + ", " + polled
+	}
+
+	// The dot and the spaces around the state take three columns.
+	width := m.width - statusBarStyle.GetHorizontalFrameSize() - lipgloss.Width(polled) - 3
+	state = oneLine(state, max(width, 1))
+	gap := strings.Repeat(" ", max(width-lipgloss.Width(state), 0)+1)
+	text := statusBarStyle.UnsetPadding()
+	return statusBarStyle.Width(m.width).Render(dot.Inherit(text).Render("●") + text.Render(" "+state+gap+polled))
+}
+
+// panelsView lays out the panels in height lines: side by side in pairs in
+// terminals at least splitWidth wide, one under the other otherwise, and the
+// errors below taking the lines left.
+func (m dashboard) panelsView(height int) string {
+	if m.report == nil {
+		text := "Waiting for metrics..."
+		switch {
+		case client.IsStatus(m.metricsErr, http.StatusUnauthorized, http.StatusForbidden):
+			text = "Only admins can read the metrics of the server; run cli dash as one."
+		case m.metricsErr != nil:
+			text = "Cannot read the metrics: " + m.metricsErr.Error()
+		}
+		return dashPanel("Metrics", helpStyle.Render(oneLine(text, max(m.width-paneStyle.GetHorizontalFrameSize(), 1))), m.width, 1)
+	}
+
+	var rows []string
+	if m.width >= splitWidth {
+		left := m.width / 2
+		right := m.width - left
+		rows = []string{
+			lipgloss.JoinHorizontal(lipgloss.Top, m.requestsPanel(left), m.latencyPanel(right)),
+			lipgloss.JoinHorizontal(lipgloss.Top, m.cachePanel(left), m.dbPanel(right)),
+		}
+	} else {
+		rows = []string{m.requestsPanel(m.width), m.latencyPanel(m.width), m.cachePanel(m.width), m.dbPanel(m.width)}
+	}
+	used := 0
+	for _, row := range rows {
+		used += lipgloss.Height(row)
+	}
+	// The errors panel has a border and heading besides its lines.
+	rows = append(rows, m.errorsPanel(m.width, max(height-used-3, 1)))
+
+	lines := strings.Split(lipgloss.JoinVertical(lipgloss.Left, rows...), "\n")
+	return strings.Join(lines[:min(len(lines), height)], "\n")
+}
+
+// requestsPanel renders the request rate.
+func (m dashboard) requestsPanel(width int) string {
+	w := panelWidth(width)
+	req := m.report.Requests
+	lines := []string{helpStyle.Render("measuring...")}
+	if m.elapsed > 0 {
+		lines = []string{oneLine(fmt.Sprintf("%s req/s", formatRate(float64(m.requests.Total)/m.elapsed)), w)}
+	}
+	lines = append(lines,
+		sparkStyle.Render(sparkline(m.rates, w)),
+		oneLine(fmt.Sprintf("4xx %s/s · 5xx %s/s",
+			formatRate(float64(m.requests.ClientErrors)/max(m.elapsed, 1)),
+			formatRate(float64(m.requests.ServerErrors)/max(m.elapsed, 1))), w),
+		metaStyle.Render(oneLine(fmt.Sprintf("%d since start, %d 4xx, %d 5xx · peak %s req/s",
+			req.Total, req.ClientErrors, req.ServerErrors, formatRate(peak(m.rates))), w)),
+	)
+	return dashPanel("Requests", strings.Join(lines, "\n"), width, dashPanelLines)
+}
+
+// latencyPanel renders the latency of the requests.
+func (m dashboard) latencyPanel(width int) string {
+	w := panelWidth(width)
+	lines := []string{helpStyle.Render("no requests")}
+	if m.requests.Timed > 0 {
+		lines = []string{oneLine(fmt.Sprintf("mean %s · p95 %s",
+			formatLatency(m.requests.MeanLatency()), formatLatency(m.requests.Quantile(0.95))), w)}
+	}
+	lines = append(lines, sparkStyle.Render(sparkline(m.latencies, w)))
+	if m.requests.Timed > 0 {
+		lines = append(lines, oneLine(fmt.Sprintf("p50 %s · p99 %s",
+			formatLatency(m.requests.Quantile(0.5)), formatLatency(m.requests.Quantile(0.99))), w))
+	} else {
+		lines = append(lines, "")
+	}
+	req := m.report.Requests
+	lines = append(lines, metaStyle.Render(oneLine(fmt.Sprintf("since start mean %s, p95 %s · peak %s",
+		formatLatency(req.MeanLatency()), formatLatency(req.Quantile(0.95)), formatLatency(peak(m.latencies))), w)))
+	return dashPanel("Latency", strings.Join(lines, "\n"), width, dashPanelLines)
+}
+
+// cachePanel renders the use of the cache.
+func (m dashboard) cachePanel(width int) string {
+	w := panelWidth(width)
+	c := m.report.Cache
+	ratio := fmt.Sprintf("hit ratio %s", formatPercent(c.Ratio()))
+	if m.cache.Hits+m.cache.Misses > 0 {
+		ratio += fmt.Sprintf(" · last %s %s", time.Duration(m.elapsed*float64(time.Second)).Round(time.Second), formatPercent(m.cache.Ratio()))
+	}
+	lines := []string{
+		oneLine(ratio, w),
+		sparkStyle.Render(gauge(c.Ratio(), w)),
+		oneLine(fmt.Sprintf("%d hits · %d misses", c.Hits, c.Misses), w),
+		metaStyle.Render(oneLine(fmt.Sprintf("%d keys added, %d evicted · cost %d added, %d evicted",
+			c.KeysAdded, c.KeysEvicted, c.CostAdded, c.CostEvicted), w)),
+	}
+	return dashPanel("Cache", strings.Join(lines, "\n"), width, dashPanelLines)
+}
+
+// dbPanel renders the state of the database connection pool and the Go
+// runtime.
+func (m dashboard) dbPanel(width int) string {
+	w := panelWidth(width)
+	db, rt := m.report.DB, m.report.Runtime
+	maxOpen := "unlimited"
+	if db.MaxOpen > 0 {
+		maxOpen = strconv.Itoa(db.MaxOpen)
+	}
+	lines := []string{
+		oneLine(fmt.Sprintf("%d open · %d in use · %d idle · max %s", db.Open, db.InUse, db.Idle, maxOpen), w),
+		oneLine(fmt.Sprintf("waited %d times, %s in all", db.WaitCount, formatLatency(db.WaitSeconds)), w),
+		metaStyle.Render(oneLine(fmt.Sprintf("closed %d idle, %d idle too long, %d too old",
+			db.MaxIdleClosed, db.MaxIdleTimeClosed, db.MaxLifetimeClosed), w)),
+		metaStyle.Render(oneLine(fmt.Sprintf("%d goroutines · %s heap · %d GCs",
+			rt.Goroutines, formatBytes(rt.HeapBytes), rt.GCs), w)),
+	}
+	return dashPanel("Database pool", strings.Join(lines, "\n"), width, dashPanelLines)
+}
+
+// errorsPanel renders up to n of the latest errors the server logged.
+func (m dashboard) errorsPanel(width, n int) string {
+	w := panelWidth(width)
+	records := m.report.Errors
+	var lines []string
+	for _, r := range records[:min(len(records), n)] {
+		at := r.Time.Local().Format("15:04:05")
+		text := oneLine(r.Message+formatAttrs(r.Attrs), max(w-len(at)-len(r.Level)-2, 1))
+		lines = append(lines, metaStyle.Render(at)+" "+errorStyle.Render(r.Level)+" "+text)
+	}
+	if len(records) == 0 {
+		lines = []string{helpStyle.Render("No errors logged since the server started.")}
+	}
+	heading := "Recent errors"
+	if len(records) > 0 {
+		heading += fmt.Sprintf(" (%d)", len(records))
+	}
+	return dashPanel(heading, strings.Join(lines, "\n"), width, n+1)
+}
+
+// dashPanel renders a panel width columns wide, border included, with a
+// heading and body, padded to height lines.
+func dashPanel(heading, body string, width, height int) string {
+	style := paneStyle.Width(max(width-paneStyle.GetHorizontalBorderSize(), 0)).Height(height)
+	return style.Render(headingStyle.Render(heading) + "\n" + body)
+}
+
+// panelWidth returns the width of the text in a panel width columns wide.
+func panelWidth(width int) int {
+	return max(width-paneStyle.GetHorizontalFrameSize(), 1)
+}
+
+// sparkline draws the last width values as bars scaled to the highest,
+// aligned to the right.
+func sparkline(values []float64, width int) string {
+	values = values[max(len(values)-width, 0):]
+	top := peak(values)
+	var b strings.Builder
+	b.WriteString(strings.Repeat(" ", width-len(values)))
+	for _, v := range values {
+		i := 0
+		if top > 0 {
+			i = int(v/top*float64(len(sparkBlocks)-1) + 0.5)
+		}
+		b.WriteRune(sparkBlocks[i])
+	}
+	return b.String()
+}
+
+// gauge draws a bar width columns wide, filled by ratio.
+func gauge(ratio float64, width int) string {
+	filled := min(max(int(ratio*float64(width)+0.5), 0), width)
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
+
+// peak returns the highest of values, or 0 if there are none.
+func peak(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	return slices.Max(values)
+}
+
+// formatAttrs formats log attributes as " key=value ...", the error first.
+func formatAttrs(attrs map[string]string) string {
+	keys := slices.Sorted(maps.Keys(attrs))
+	if i := slices.Index(keys, "error"); i > 0 {
+		keys = slices.Insert(slices.Delete(keys, i, i+1), 0, "error")
+	}
+	var b strings.Builder
+	for _, k := range keys {
+		v := attrs[k]
+		if v == "" || strings.ContainsAny(v, " =\"") {
+			v = strconv.Quote(v)
+		}
+		b.WriteString(" " + k + "=" + v)
+	}
+	return b.String()
+}
+
+// formatRate formats a rate per second.
+func formatRate(r float64) string {
+	return strconv.FormatFloat(r, 'f', 1, 64)
+}
+
+// formatPercent formats a ratio as a percentage.
+func formatPercent(r float64) string {
+	return strconv.FormatFloat(r*100, 'f', 1, 64) + "%"
+}
+
+// formatLatency formats a duration in seconds to a precision that suits
+// its size, such as 420µs, 4.2ms or 1.23s.
+func formatLatency(seconds float64) string {
+	d := time.Duration(seconds * float64(time.Second))
+	switch {
+	case d < time.Millisecond:
+		return d.Round(time.Microsecond).String()
+	case d < time.Second:
+		return d.Round(100 * time.Microsecond).String()
+	default:
+		return d.Round(10 * time.Millisecond).String()
+	}
+}
+
+// formatBytes formats a size in bytes with a binary unit, such as 5.2 MiB.
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
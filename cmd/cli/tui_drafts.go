@@ -0,0 +1,220 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbletea"
+)
+
+// autosaveDelay is how long after the last change to a new message it is
+// saved to its draft.
+const autosaveDelay = time.Second
+
+// Messages of the draft and editor commands.
+type (
+	// autosaveMsg asks to save the message being composed if it has not
+	// changed since the nth change.
+	autosaveMsg struct{ n int }
+	// editorDoneMsg reports that the editor editing path exited. A temporary
+	// file is removed once read.
+	editorDoneMsg struct {
+		path string
+		temp bool
+		err  error
+	}
+)
+
+// draftItem is a draft in the drafts picker.
+type draftItem struct {
+	draft
+}
+
+// Title implements list.DefaultItem.
+func (i draftItem) Title() string { return oneLine(i.Body, 80) }
+
+// Description implements list.DefaultItem.
+func (i draftItem) Description() string {
+	return fmt.Sprintf("draft %d in %s · %s", i.ID, cmp.Or(i.Channel, "general"), i.UpdatedAt.Local().Format(timeFormat))
+}
+
+// FilterValue implements list.Item.
+func (i draftItem) FilterValue() string { return i.Body }
+
+// autosave schedules the autosave after the nth change.
+func autosave(n int) tea.Cmd {
+	return tea.Tick(autosaveDelay, func(time.Time) tea.Msg { return autosaveMsg{n} })
+}
+
+// saveDraft saves the new message being composed to its draft, creating the
+// draft if needed. A message left empty deletes its draft instead.
+func (m *browser) saveDraft() error {
+	body := m.compose.Value()
+	if body == "" {
+		err := m.removeDraft()
+		m.draft = nil
+		return err
+	}
+	if m.draft == nil {
+		d, err := m.drafts.create(m.composeChannel)
+		if err != nil {
+			return err
+		}
+		m.draft = &d
+	}
+	return m.draft.save(body)
+}
+
+// removeDraft deletes the draft of the message being composed, if any.
+func (m browser) removeDraft() error {
+	if m.draft == nil {
+		return nil
+	}
+	return m.draft.remove()
+}
+
+// openEditor suspends the TUI to edit the message being composed in the
+// user's editor. A new message is edited in its draft, creating it if need
+// be; an existing one in a temporary file.
+func (m browser) openEditor() (tea.Model, tea.Cmd) {
+	// A pending autosave would overwrite what the editor saves.
+	m.autosaves++
+	var path string
+	temp := m.editing != 0
+	if temp {
+		f, err := os.CreateTemp("", "message-*.md")
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		path = f.Name()
+		_, err = f.WriteString(m.compose.Value())
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(path)
+			m.err = err
+			return m, nil
+		}
+	} else {
+		if err := m.saveDraft(); err != nil {
+			m.err = err
+			return m, nil
+		}
+		if m.draft == nil {
+			d, err := m.drafts.create(m.composeChannel)
+			if err != nil {
+				m.err = err
+				return m, nil
+			}
+			m.draft = &d
+		}
+		path = m.draft.path
+	}
+	return m, tea.ExecProcess(editorCommand(path), func(err error) tea.Msg {
+		return editorDoneMsg{path: path, temp: temp, err: err}
+	})
+}
+
+// editorDone takes the message back from the editor.
+func (m browser) editorDone(msg editorDoneMsg) (tea.Model, tea.Cmd) {
+	if msg.temp {
+		defer os.Remove(msg.path)
+	}
+	if msg.err != nil {
+		m.err = fmt.Errorf("run editor: %w", msg.err)
+		return m, nil
+	}
+	data, err := os.ReadFile(msg.path)
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	m.compose.SetValue(string(data))
+	m.status, m.err = "", nil
+	if !msg.temp {
+		if err := m.saveDraft(); err != nil {
+			m.err = err
+		}
+	}
+	return m, nil
+}
+
+// openDrafts opens the drafts picker.
+func (m browser) openDrafts() (tea.Model, tea.Cmd) {
+	drafts, err := m.drafts.list()
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	if len(drafts) == 0 {
+		m.status, m.err = "No drafts", nil
+		return m, nil
+	}
+	m.previous = m.focus
+	m.focus = paneDrafts
+	m.status, m.err = "", nil
+	m.resize()
+	return m, m.setDrafts(drafts)
+}
+
+// setDrafts shows drafts in the picker.
+func (m *browser) setDrafts(drafts []draft) tea.Cmd {
+	items := make([]list.Item, len(drafts))
+	for i, d := range drafts {
+		items[i] = draftItem{d}
+	}
+	return m.draftList.SetItems(items)
+}
+
+// updateDrafts handles a key press in the drafts picker.
+func (m browser) updateDrafts(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// While filtering, keys are typed into the filter.
+	if m.draftList.FilterState() != list.Filtering {
+		item, ok := m.draftList.SelectedItem().(draftItem)
+		switch {
+		case key.Matches(msg, keys.Back) && m.draftList.FilterState() == list.Unfiltered:
+			m.focus = m.previous
+			m.resize()
+			return m, nil
+		case key.Matches(msg, keys.Resume):
+			if !ok {
+				return m, nil
+			}
+			m.focus = m.previous
+			model, cmd := m.startCompose(0, item.Body)
+			m = model.(browser)
+			m.draft, m.composeChannel = &item.draft, item.Channel
+			return m, cmd
+		case key.Matches(msg, keys.Delete):
+			if !ok {
+				return m, nil
+			}
+			if err := item.remove(); err != nil {
+				m.err = err
+				return m, nil
+			}
+			m.status, m.err = fmt.Sprintf("Deleted draft %d", item.ID), nil
+			drafts, err := m.drafts.list()
+			if err != nil {
+				m.err = err
+				return m, nil
+			}
+			if len(drafts) == 0 {
+				m.focus = m.previous
+				m.resize()
+				return m, nil
+			}
+			return m, m.setDrafts(drafts)
+		}
+	}
+
+	var cmd tea.Cmd
+	m.draftList, cmd = m.draftList.Update(msg)
+	return m, cmd
+}
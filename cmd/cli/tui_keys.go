@@ -0,0 +1,61 @@
+package main
+
+import "github.com/charmbracelet/bubbles/key"
+
+// keyMap holds the key bindings of the TUI. Which of them apply depends on
+// the focused pane; see browser.helpKeys. The tail view uses some of them.
+type keyMap struct {
+	Open    key.Binding
+	Back    key.Binding
+	New     key.Binding
+	Drafts  key.Binding
+	Resume  key.Binding
+	Edit    key.Binding
+	Delete  key.Binding
+	Refresh key.Binding
+	Send    key.Binding
+	Editor  key.Binding
+	Confirm key.Binding
+	Cancel  key.Binding
+	Up      key.Binding
+	Down    key.Binding
+	Top     key.Binding
+	Bottom  key.Binding
+	Filter  key.Binding
+	Help    key.Binding
+	Quit    key.Binding
+}
+
+var keys = keyMap{
+	Open:    key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "open")),
+	Back:    key.NewBinding(key.WithKeys("esc", "left", "h"), key.WithHelp("esc", "back")),
+	New:     key.NewBinding(key.WithKeys("n"), key.WithHelp("n", "new message")),
+	Drafts:  key.NewBinding(key.WithKeys("D"), key.WithHelp("D", "drafts")),
+	Resume:  key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "resume")),
+	Edit:    key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "edit")),
+	Delete:  key.NewBinding(key.WithKeys("d"), key.WithHelp("d", "delete")),
+	Refresh: key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "refresh")),
+	Send:    key.NewBinding(key.WithKeys("ctrl+s"), key.WithHelp("ctrl+s", "send")),
+	Editor:  key.NewBinding(key.WithKeys("ctrl+e"), key.WithHelp("ctrl+e", "open $EDITOR")),
+	Confirm: key.NewBinding(key.WithKeys("y"), key.WithHelp("y", "confirm")),
+	Cancel:  key.NewBinding(key.WithKeys("n", "esc"), key.WithHelp("n/esc", "cancel")),
+	Up:      key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "up")),
+	Down:    key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "down")),
+	Top:     key.NewBinding(key.WithKeys("g", "home"), key.WithHelp("g", "top")),
+	Bottom:  key.NewBinding(key.WithKeys("G", "end"), key.WithHelp("G", "latest")),
+	Filter:  key.NewBinding(key.WithKeys("/"), key.WithHelp("/", "filter")),
+	Help:    key.NewBinding(key.WithKeys("?"), key.WithHelp("?", "more keys")),
+	Quit:    key.NewBinding(key.WithKeys("q", "ctrl+c"), key.WithHelp("q", "quit")),
+}
+
+// paneKeys adapts a set of bindings to help.KeyMap.
+type paneKeys struct {
+	short []key.Binding
+	full  [][]key.Binding
+}
+
+// ShortHelp implements help.KeyMap.
+func (k paneKeys) ShortHelp() []key.Binding { return k.short }
+
+// FullHelp implements help.KeyMap.
+func (k paneKeys) FullHelp() [][]key.Binding { return k.full }
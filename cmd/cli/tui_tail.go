@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	"github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/dunamismax/go-modern-scaffold/internal/client"
+	"github.com/dunamismax/go-modern-scaffold/internal/db"
+)
+
+// tailLimit is the number of messages the tail view keeps; older ones
+// scroll out of it.
+const tailLimit = 1000
+
+var (
+	authorStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.AdaptiveColor{Light: "25", Dark: "75"})
+
+	statusBarStyle = lipgloss.NewStyle().
+			Background(lipgloss.AdaptiveColor{Light: "254", Dark: "236"}).
+			Foreground(lipgloss.AdaptiveColor{Light: "236", Dark: "252"}).
+			Padding(0, 1)
+
+	connectedStyle    = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "28", Dark: "42"})
+	connectingStyle   = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "166", Dark: "214"})
+	disconnectedStyle = errorStyle
+)
+
+// Messages the stream sends to the tail view.
+type (
+	tailMessageMsg struct{ msg db.Message }
+	tailStateMsg   struct {
+		state client.StreamState
+		err   error
+	}
+	tailDoneMsg struct{ err error }
+)
+
+// tailView is the bubbletea model of tail --follow: the messages of a
+// channel in a viewport that keeps to the latest as new ones arrive.
+type tailView struct {
+	channel  string
+	messages []db.Message
+	viewport viewport.Model
+	help     help.Model
+	state    client.StreamState
+	// err is why the connection was lost.
+	err error
+	// fatal is why following stopped, if it did on its own.
+	fatal error
+
+	width, height int
+}
+
+// runTail runs the tail view, showing messages and then those posted to
+// channel until the user quits or ctx is done.
+func runTail(ctx context.Context, c *client.Client, channel string, messages []db.Message) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var after int64
+	if len(messages) > 0 {
+		after = messages[len(messages)-1].ID
+	}
+	p := tea.NewProgram(tailView{
+		channel:  channel,
+		messages: messages,
+		viewport: viewport.New(0, 0),
+		help:     help.New(),
+	}, tea.WithAltScreen(), tea.WithContext(ctx))
+	go func() {
+		err := c.FollowMessages(ctx, client.StreamOptions{
+			Channel: channel,
+			After:   after,
+			OnState: func(state client.StreamState, err error) {
+				p.Send(tailStateMsg{state, err})
+			},
+		}, func(msg db.Message) error {
+			p.Send(tailMessageMsg{msg})
+			return nil
+		})
+		p.Send(tailDoneMsg{err})
+	}()
+
+	model, err := p.Run()
+	if errors.Is(err, tea.ErrProgramKilled) && ctx.Err() != nil {
+		return nil // interrupted
+	}
+	if err != nil {
+		return err
+	}
+	if fatal := model.(tailView).fatal; !errors.Is(fatal, context.Canceled) {
+		return fatal
+	}
+	return nil
+}
+
+func (m tailView) Init() tea.Cmd {
+	return nil
+}
+
+func (m tailView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.resize()
+		return m, nil
+
+	case tailMessageMsg:
+		m.messages = append(m.messages, msg.msg)
+		if len(m.messages) > tailLimit {
+			m.messages = m.messages[len(m.messages)-tailLimit:]
+		}
+		m.render()
+		return m, nil
+
+	case tailStateMsg:
+		m.state, m.err = msg.state, msg.err
+		return m, nil
+
+	case tailDoneMsg:
+		// Following only stops on its own for errors retrying cannot fix.
+		m.fatal = msg.err
+		return m, tea.Quit
+
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, keys.Top):
+			m.viewport.GotoTop()
+			return m, nil
+		case key.Matches(msg, keys.Bottom):
+			m.viewport.GotoBottom()
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+// resize lays out the view for the window size.
+func (m *tailView) resize() {
+	m.help.Width = m.width
+	m.viewport.Width = m.width
+	// The title, status bar and help lines take the rest.
+	m.viewport.Height = max(m.height-3, 0)
+	m.render()
+}
+
+// render fills the viewport with the messages, keeping it scrolled to the
+// latest unless the user scrolled up.
+func (m *tailView) render() {
+	if m.width == 0 {
+		return
+	}
+	atBottom := m.viewport.AtBottom()
+
+	var b strings.Builder
+	indent := lipgloss.Width(timeFormat) + 1
+	body := lipgloss.NewStyle().Width(max(m.width-indent, 10))
+	for i, msg := range m.messages {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(metaStyle.Render(msg.CreatedAt.Local().Format(timeFormat)) + " " + authorStyle.Render(msg.Author))
+		if msg.ParentID != nil {
+			b.WriteString(metaStyle.Render(fmt.Sprintf(" replying to #%d", *msg.ParentID)))
+		}
+		b.WriteString("\n")
+		b.WriteString(lipgloss.NewStyle().MarginLeft(indent).Render(body.Render(msg.Body)))
+	}
+	if len(m.messages) == 0 {
+		b.WriteString(helpStyle.Render("Waiting for messages..."))
+	}
+
+	m.viewport.SetContent(b.String())
+	if atBottom {
+		m.viewport.GotoBottom()
+	}
+}
+
+func (m tailView) View() string {
+	if m.width == 0 {
+		return ""
+	}
+	channel := m.channel
+	if channel == "" {
+		channel = "general"
+	}
+	title := titleStyle.Render("Go Modern Scaffold · " + channel)
+	return title + "\n" + m.viewport.View() + "\n" + m.statusView() + "\n" + m.help.View(paneKeys{
+		short: []key.Binding{keys.Up, keys.Down, keys.Top, keys.Bottom, keys.Quit},
+	})
+}
+
+// statusView renders the status bar: the connection state on the left and
+// the number of messages on the right.
+func (m tailView) statusView() string {
+	dot, state := connectingStyle, "Connecting..."
+	switch m.state {
+	case client.StreamConnected:
+		dot, state = connectedStyle, "Connected"
+	case client.StreamReconnecting:
+		dot, state = disconnectedStyle, "Reconnecting"
+		if m.err != nil {
+			state += ": " + m.err.Error()
+		}
+	}
+	count := fmt.Sprintf("%d messages", len(m.messages))
+	if !m.viewport.AtBottom() {
+		count = fmt.Sprintf("%d%% · %s", int(m.viewport.ScrollPercent()*100), count)
+	}
+
+	// The dot and the spaces around the state take three columns.
+	width := m.width - statusBarStyle.GetHorizontalFrameSize() - lipgloss.Width(count) - 3
+	state = oneLine(state, max(width, 1))
+	gap := strings.Repeat(" ", max(width-lipgloss.Width(state), 0)+1)
+	// Each part is styled on its own, as the dot's reset would end the
+	// background of the bar for the text after it.
+	text := statusBarStyle.UnsetPadding()
+	return statusBarStyle.Width(m.width).Render(dot.Inherit(text).Render("●") + text.Render(" "+state+gap+count))
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/dunamismax/go-modern-scaffold/internal/client"
+	"github.com/dunamismax/go-modern-scaffold/internal/db"
+)
+
+// pagingServer serves the messages with IDs n down to 1 a page at a time, as
+// the message list API does.
+func pagingServer(t *testing.T, n int64) *client.Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit, _ := strconv.ParseInt(r.URL.Query().Get("limit"), 10, 64)
+		if limit == 0 {
+			limit = 50
+		}
+		id := n
+		if before := r.URL.Query().Get("before"); before != "" {
+			b, _ := strconv.ParseInt(before, 10, 64)
+			id = min(id, b-1)
+		}
+		messages := []client.Message{}
+		for ; id > 0 && int64(len(messages)) < limit; id-- {
+			messages = append(messages, client.Message{Message: db.Message{ID: id, Body: "message " + strconv.FormatInt(id, 10)}})
+		}
+		json.NewEncoder(w).Encode(messages)
+	}))
+	t.Cleanup(srv.Close)
+	c, err := client.New(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// run runs cmd and the commands it batches, and passes the messages loaded
+// to the model.
+func run(t *testing.T, m browser, cmd tea.Cmd) browser {
+	t.Helper()
+	if cmd == nil {
+		return m
+	}
+	switch msg := cmd().(type) {
+	case tea.BatchMsg:
+		for _, cmd := range msg {
+			m = run(t, m, cmd)
+		}
+	case messagesLoadedMsg:
+		model, next := m.Update(msg)
+		m = run(t, model.(browser), next)
+	case errMsg:
+		t.Fatal(msg.err)
+	}
+	return m
+}
+
+func TestBrowserLoadsMoreOnScroll(t *testing.T) {
+	const total = 120
+	m := newBrowser(pagingServer(t, total), nil, draftStore{t.TempDir()}, "")
+	model, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = run(t, model.(browser), model.(browser).load())
+	if got := len(m.list.Items()); got != messagePageSize {
+		t.Fatalf("loaded %d messages, want %d", got, messagePageSize)
+	}
+
+	// Going to the end of the list loads the next page, until the last.
+	for range 3 {
+		model, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnd})
+		m = run(t, model.(browser), cmd)
+	}
+	items := m.list.Items()
+	if len(items) != total || m.more {
+		t.Fatalf("loaded %d messages, more %t; want %d, false", len(items), m.more, total)
+	}
+	for i, item := range items {
+		if id := item.(messageItem).ID; id != total-int64(i) {
+			t.Fatalf("message %d has ID %d, want %d", i, id, total-i)
+		}
+	}
+
+	// Reloading keeps the pages loaded.
+	m = run(t, m, m.load())
+	if got := len(m.list.Items()); got != total {
+		t.Errorf("reloaded %d messages, want %d", got, total)
+	}
+}
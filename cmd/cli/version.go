@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/buildinfo"
+	"github.com/spf13/cobra"
+)
+
+// newVersionCmd creates the version command.
+func newVersionCmd() *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "version",
+		Short: "Show the version of the client",
+		Args:  usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			info := buildinfo.Get()
+			return printOutput(cmd.OutOrStdout(), output, info, func(w io.Writer) {
+				writeBuildInfo(w, info)
+			})
+		},
+	}
+	addOutputFlag(cmd, &output)
+	return cmd
+}
+
+// writeBuildInfo writes the rows of a build information table.
+func writeBuildInfo(w io.Writer, info buildinfo.Info) {
+	fmt.Fprintf(w, "Version:\t%s\n", info.Version)
+	if info.Revision != "" {
+		revision := info.Revision
+		if info.Dirty {
+			revision += " (dirty)"
+		}
+		fmt.Fprintf(w, "Revision:\t%s\n", revision)
+	}
+	if info.BuildTime != nil {
+		fmt.Fprintf(w, "Built:\t%s\n", info.BuildTime.Local().Format(timeFormat))
+	}
+	fmt.Fprintf(w, "Go:\t%s\n", info.GoVersion)
+	fmt.Fprintf(w, "Platform:\t%s\n", info.Platform)
+}
@@ -1,54 +1,82 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
+	"github.com/dunamismax/go-modern-scaffold/internal/blob"
+	"github.com/dunamismax/go-modern-scaffold/internal/buildinfo"
 	"github.com/dunamismax/go-modern-scaffold/internal/cache"
 	"github.com/dunamismax/go-modern-scaffold/internal/config"
 	"github.com/dunamismax/go-modern-scaffold/internal/db"
+	"github.com/dunamismax/go-modern-scaffold/internal/events"
+	"github.com/dunamismax/go-modern-scaffold/internal/form"
+	"github.com/dunamismax/go-modern-scaffold/internal/jobs"
+	"github.com/dunamismax/go-modern-scaffold/internal/logging"
+	"github.com/dunamismax/go-modern-scaffold/internal/metrics"
 	"github.com/dunamismax/go-modern-scaffold/internal/web"
-	"github.com/go-playground/validator/v10"
-	"database/sql"
-	_ "github.com/mattn/go-sqlite3"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 )
 
-// CustomValidator holds the validator instance.
-type CustomValidator struct {
-	validator *validator.Validate
-}
-
-// Validate implements the echo.Validator interface.
-func (v *CustomValidator) Validate(i interface{}) error {
-	return v.validator.Struct(i)
-}
-
 func main() {
-	// Setup structured logging
-	log := slog.New(slog.NewJSONHandler(os.Stdout, nil))
-	slog.SetDefault(log)
+	showVersion := flag.Bool("version", false, "print the version and exit")
+	flag.Parse()
+	build := buildinfo.Get()
+	if *showVersion {
+		fmt.Println("server", build)
+		return
+	}
 
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
-		log.Error("failed to load configuration", "error", err)
+		slog.Error("failed to load configuration", "error", err)
+		os.Exit(1)
+	}
+
+	// Setup structured logging
+	log, logCloser, err := logging.New(&cfg.Log)
+	if err != nil {
+		slog.Error("failed to configure logging", "error", err)
 		os.Exit(1)
 	}
+	defer logCloser.Close()
+	// The latest errors are kept for /metrics.
+	recorder := metrics.NewRecorder()
+	log = slog.New(recorder.Handler(log.Handler()))
+	slog.SetDefault(log)
 
 	// Setup database connection
-	dbConn, err := sql.Open("sqlite3", cfg.DBURL)
+	dbConn, err := db.Open(cfg.DBDriver, cfg.DBURL)
 	if err != nil {
 		log.Error("failed to connect to database", "error", err)
 		os.Exit(1)
 	}
 	defer dbConn.Close()
+	if err := db.CheckFTS5(context.Background(), dbConn); err != nil {
+		log.Error("database driver cannot serve message search", "error", err)
+		os.Exit(1)
+	}
 
 	// Create a new sqlc querier
-	queries := db.New(dbConn)
+	queries := db.NewStore(dbConn)
+
+	// Create the blob store for uploaded files
+	blobs, err := blob.NewLocalStore(cfg.Storage.BlobDir)
+	if err != nil {
+		log.Error("failed to create blob store", "error", err)
+		os.Exit(1)
+	}
 
 	// Create a new cache
 	appCache, err := cache.New(&cfg.Cache)
@@ -59,32 +87,203 @@ func main() {
 
 	// Create Echo app
 	e := echo.New()
-	e.Validator = &CustomValidator{validator: validator.New()}
+	e.HideBanner = true
+	e.HidePort = true // the "starting server" log line carries the address
+	e.HTTPErrorHandler = web.ErrorHandler(log)
+	validator, err := form.NewValidator()
+	if err != nil {
+		log.Error("failed to create validator", "error", err)
+		os.Exit(1)
+	}
+	e.Validator = validator
 
 	// Add middleware
-	e.Use(middleware.Recover())
-	e.Use(middleware.Logger())
+	e.Use(middleware.RequestID())
+	e.Use(web.Metrics(recorder))
+	e.Use(web.AccessLog(log, cfg.Log.AccessSampleRate))
+	e.Use(middleware.RecoverWithConfig(web.RecoverConfig()))
+	identify, err := web.Identify(&cfg.Auth)
+	if err != nil {
+		log.Error("invalid auth configuration", "error", err)
+		os.Exit(1)
+	}
+	if len(cfg.Auth.TrustedProxies) == 0 && cfg.Auth.ProxySecret == "" {
+		log.Warn("neither AUTH_TRUSTED_PROXIES nor AUTH_PROXY_SECRET is set; all requests are anonymous")
+	}
+	e.Use(identify)
 
 	// Static files
 	e.Static("/css", "./public/css")
+	e.GET("/css/highlight.css", web.HighlightCSS)
 	e.Static("/js", "./public/js")
 	e.Static("/assets", "./public/assets")
 
 	// Create web handlers
-	webHandlers := web.NewHandlers(queries, appCache)
+	broker := events.NewBroker()
+	webHandlers := web.NewHandlers(queries, appCache, broker, blobs, &cfg.Storage)
+	go cleanupBlobs(webHandlers, log)
+
+	// Start the background job workers
+	registry := jobs.NewRegistry()
+	webHandlers.RegisterJobs(registry)
+	pool := jobs.NewPool(queries, registry, &cfg.Jobs, log)
+	pool.Start()
 
 	// Register routes
 	e.GET("/", webHandlers.RenderIndex)
-	e.POST("/messages", webHandlers.CreateMessage)
+	e.POST("/messages", webHandlers.CreateMessage, web.UploadLimit(&cfg.Storage))
+	e.GET("/messages/search", webHandlers.SearchMessages)
+	e.GET("/messages/:id", webHandlers.RenderMessage)
+	e.GET("/messages/:id/edit", webHandlers.EditMessage)
+	e.PUT("/messages/:id", webHandlers.UpdateMessage)
+	e.DELETE("/messages/:id", webHandlers.DeleteMessage)
+	e.GET("/messages/:id/history", webHandlers.RenderMessageHistory)
+	e.GET("/messages/:id/thread", webHandlers.RenderThread)
+	e.POST("/messages/:id/replies", webHandlers.CreateReply)
+	e.GET("/messages/:id/reactions", webHandlers.RenderReactions)
+	e.POST("/messages/:id/reactions", webHandlers.ToggleReaction)
+	e.GET("/events", webHandlers.Events)
+	e.GET("/tags/:tag", webHandlers.RenderTag)
+	e.GET("/attachments/:id", webHandlers.ServeAttachment)
+	e.GET("/attachments/:id/thumbnail", webHandlers.ServeThumbnail)
+	e.GET("/notifications", webHandlers.RenderNotifications, web.RequireUser)
+	e.GET("/notifications/badge", webHandlers.RenderNotificationBadge)
+	e.POST("/notifications/read", webHandlers.MarkAllNotificationsRead, web.RequireUser)
+	e.POST("/notifications/:id/read", webHandlers.MarkNotificationRead, web.RequireUser)
+	e.PUT("/notifications/preferences", webHandlers.UpdateNotificationPreferences, web.RequireUser)
+	e.GET("/channels/new", webHandlers.RenderNewChannel)
+	e.POST("/channels", webHandlers.CreateChannel)
+	e.GET("/c/:slug", webHandlers.RenderIndex)
+	e.GET("/c/:slug/edit", webHandlers.EditChannel)
+	e.PUT("/c/:slug", webHandlers.UpdateChannel)
+	e.DELETE("/c/:slug", webHandlers.DeleteChannel)
+	e.GET("/c/:slug/messages", webHandlers.RenderMessages)
+	e.POST("/c/:slug/messages", webHandlers.CreateMessage, web.UploadLimit(&cfg.Storage))
+	e.GET("/c/:slug/search", webHandlers.SearchMessages)
+
+	admin := e.Group("/admin", web.RequireAdmin)
+	admin.GET("/messages/deleted", webHandlers.RenderDeletedMessages)
+	admin.POST("/messages/:id/restore", webHandlers.RestoreMessage)
+	admin.GET("/jobs", webHandlers.RenderJobs)
+	admin.POST("/jobs/:id/retry", webHandlers.RetryJob)
+	admin.GET("/webhooks", webHandlers.RenderWebhooks)
+	admin.POST("/webhooks", webHandlers.CreateWebhook)
+	admin.GET("/webhooks/:id", webHandlers.RenderWebhook)
+	admin.PUT("/webhooks/:id", webHandlers.UpdateWebhook)
+	admin.DELETE("/webhooks/:id", webHandlers.DeleteWebhook)
+	admin.POST("/webhooks/:id/deliveries/:delivery/redeliver", webHandlers.Redeliver)
+
+	api := e.Group("/api")
+	api.GET("/me", web.APIIdentity)
+	api.GET("/messages", webHandlers.APIListMessages)
+	api.GET("/messages/export", webHandlers.APIExportMessages)
+	api.GET("/messages/stream", webHandlers.APIStreamMessages)
+	api.POST("/messages/import", webHandlers.APIImportMessages, web.RequireAdmin)
+	api.POST("/messages", webHandlers.APICreateMessage, web.UploadLimit(&cfg.Storage))
+	api.GET("/messages/:id", webHandlers.APIGetMessage)
+	api.PUT("/messages/:id", webHandlers.APIUpdateMessage)
+	api.DELETE("/messages/:id", webHandlers.APIDeleteMessage)
+	api.GET("/messages/:id/revisions", webHandlers.APIMessageRevisions)
+	api.GET("/messages/:id/thread", webHandlers.APIThread)
+	api.POST("/messages/:id/replies", webHandlers.APICreateReply)
+	api.GET("/messages/:id/reactions", webHandlers.APIReactions)
+	api.POST("/messages/:id/reactions", webHandlers.APIToggleReaction)
+	api.GET("/tags", webHandlers.APITags)
+	api.GET("/notifications", webHandlers.APINotifications, web.RequireUser)
+	api.GET("/notifications/unread", webHandlers.APIUnreadNotifications, web.RequireUser)
+	api.POST("/notifications/read", webHandlers.APIMarkAllNotificationsRead, web.RequireUser)
+	api.POST("/notifications/:id/read", webHandlers.APIMarkNotificationRead, web.RequireUser)
+	api.GET("/notifications/preferences", webHandlers.APINotificationPreferences, web.RequireUser)
+	api.PUT("/notifications/preferences", webHandlers.APIUpdateNotificationPreferences, web.RequireUser)
+	api.GET("/channels", webHandlers.APIListChannels)
+	api.POST("/channels", webHandlers.APICreateChannel)
+	api.GET("/channels/:slug", webHandlers.APIGetChannel)
+	api.PUT("/channels/:slug", webHandlers.APIUpdateChannel)
+	api.DELETE("/channels/:slug", webHandlers.APIDeleteChannel)
+	api.GET("/channels/:slug/messages", webHandlers.APIListMessages)
+	api.GET("/channels/:slug/messages/stream", webHandlers.APIStreamMessages)
+	api.POST("/channels/:slug/messages", webHandlers.APICreateMessage, web.UploadLimit(&cfg.Storage))
+
+	webhooks := api.Group("/webhooks", web.RequireAdmin)
+	webhooks.GET("", webHandlers.APIListWebhooks)
+	webhooks.POST("", webHandlers.APICreateWebhook)
+	webhooks.GET("/:id", webHandlers.APIGetWebhook)
+	webhooks.PUT("/:id", webHandlers.APIUpdateWebhook)
+	webhooks.DELETE("/:id", webHandlers.APIDeleteWebhook)
+	webhooks.GET("/:id/deliveries", webHandlers.APIWebhookDeliveries)
+	webhooks.POST("/:id/deliveries/:delivery/redeliver", webHandlers.APIRedeliver)
+
 	e.GET("/health", func(c echo.Context) error {
 		return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
 	})
+	e.GET("/version", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, build)
+	})
+	e.GET("/metrics", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, recorder.Report(appCache.Memory.Metrics, dbConn.Stats()))
+	}, web.RequireAdmin)
 
 	// Start server
 	listenAddr := fmt.Sprintf(":%d", cfg.HTTPPort)
-	log.Info("starting server", "address", listenAddr)
-	if err := e.Start(listenAddr); err != nil {
+	log.Info("starting server", "address", listenAddr, "version", build.Version,
+		"revision", build.ShortRevision(), "dirty", build.Dirty, "go", build.GoVersion)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	serverErr := make(chan error, 1)
+	go func() { serverErr <- e.Start(listenAddr) }()
+	select {
+	case err := <-serverErr:
 		log.Error("failed to start server", "error", err)
 		os.Exit(1)
+	case <-ctx.Done():
+	}
+
+	// Drain in-flight requests and running jobs, giving up on both once the
+	// drain timeout is over. Event streams would last as long as their
+	// clients, so they are ended right away.
+	log.Info("shutting down", "drain_timeout", cfg.Jobs.DrainTimeout)
+	e.Server.RegisterOnShutdown(broker.Close)
+	drainCtx, cancel := context.WithTimeout(context.Background(), cfg.Jobs.DrainTimeout)
+	defer cancel()
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if err := e.Shutdown(drainCtx); err != nil {
+			log.Error("failed to drain requests", "error", err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		if err := pool.Shutdown(drainCtx); err != nil {
+			log.Error("failed to drain jobs; interrupted jobs are queued again", "error", err)
+		}
+	}()
+	wg.Wait()
+	if err := <-serverErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Error("server failed", "error", err)
+	}
+	log.Info("server stopped")
+}
+
+// blobCleanupInterval is how often orphaned blobs are looked for. Blobs
+// younger than that are left alone, as they may belong to an upload whose
+// message is still being saved.
+const blobCleanupInterval = time.Hour
+
+// cleanupBlobs periodically deletes the uploaded files no attachment refers to.
+func cleanupBlobs(h *web.Handlers, log *slog.Logger) {
+	ticker := time.NewTicker(blobCleanupInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		n, err := h.CleanupBlobs(context.Background(), blobCleanupInterval)
+		if err != nil {
+			log.Error("failed to clean up blobs", "error", err)
+			continue
+		}
+		if n > 0 {
+			log.Info("cleaned up orphaned blobs", "count", n)
+		}
 	}
 }
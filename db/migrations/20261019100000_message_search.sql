@@ -0,0 +1,32 @@
+-- +goose Up
+-- Create "messages_fts" full-text index over message bodies
+CREATE VIRTUAL TABLE "messages_fts" USING fts5(body, content='messages', content_rowid='id');
+
+-- Keep "messages_fts" in sync with "messages"
+-- +goose StatementBegin
+CREATE TRIGGER "messages_fts_insert" AFTER INSERT ON "messages" BEGIN
+  INSERT INTO "messages_fts" (rowid, body) VALUES (new.id, new.body);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER "messages_fts_delete" AFTER DELETE ON "messages" BEGIN
+  INSERT INTO "messages_fts" ("messages_fts", rowid, body) VALUES ('delete', old.id, old.body);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER "messages_fts_update" AFTER UPDATE OF "body" ON "messages" BEGIN
+  INSERT INTO "messages_fts" ("messages_fts", rowid, body) VALUES ('delete', old.id, old.body);
+  INSERT INTO "messages_fts" (rowid, body) VALUES (new.id, new.body);
+END;
+-- +goose StatementEnd
+
+-- Index existing messages
+INSERT INTO "messages_fts" ("messages_fts") VALUES ('rebuild');
+
+-- +goose Down
+DROP TRIGGER "messages_fts_update";
+DROP TRIGGER "messages_fts_delete";
+DROP TRIGGER "messages_fts_insert";
+DROP TABLE "messages_fts";
@@ -0,0 +1,34 @@
+-- +goose Up
+-- Track who wrote each message and soft-delete them instead of removing rows
+ALTER TABLE messages ADD COLUMN author TEXT NOT NULL DEFAULT 'anonymous';
+ALTER TABLE messages ADD COLUMN editor TEXT NOT NULL DEFAULT 'anonymous';
+ALTER TABLE messages ADD COLUMN deleted_at DATETIME;
+ALTER TABLE messages ADD COLUMN deleted_by TEXT;
+
+-- Create "message_revisions" table holding every prior body of a message
+CREATE TABLE "message_revisions" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "message_id" INTEGER NOT NULL REFERENCES "messages" ("id") ON DELETE CASCADE,
+  "body" TEXT NOT NULL,
+  "editor" TEXT NOT NULL,
+  "created_at" DATETIME NOT NULL
+);
+CREATE INDEX "message_revisions_message_id" ON "message_revisions" ("message_id");
+
+-- Snapshot the previous body whenever a message is edited
+-- +goose StatementBegin
+CREATE TRIGGER "messages_revision" AFTER UPDATE OF "body" ON "messages"
+WHEN old.body <> new.body
+BEGIN
+  INSERT INTO "message_revisions" ("message_id", "body", "editor", "created_at")
+  VALUES (old.id, old.body, old.editor, old.updated_at);
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER "messages_revision";
+DROP TABLE "message_revisions";
+ALTER TABLE "messages" DROP COLUMN "deleted_by";
+ALTER TABLE "messages" DROP COLUMN "deleted_at";
+ALTER TABLE "messages" DROP COLUMN "editor";
+ALTER TABLE "messages" DROP COLUMN "author";
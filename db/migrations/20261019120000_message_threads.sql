@@ -0,0 +1,8 @@
+-- +goose Up
+-- Let messages reply to a root message
+ALTER TABLE messages ADD COLUMN parent_id INTEGER REFERENCES messages (id);
+CREATE INDEX "messages_parent_id" ON "messages" ("parent_id");
+
+-- +goose Down
+DROP INDEX "messages_parent_id";
+ALTER TABLE "messages" DROP COLUMN "parent_id";
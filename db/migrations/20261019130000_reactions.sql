@@ -0,0 +1,13 @@
+-- +goose Up
+-- Create "reactions" table, one row per user and emoji on a message
+CREATE TABLE "reactions" (
+  "message_id" INTEGER NOT NULL REFERENCES "messages" ("id") ON DELETE CASCADE,
+  "username" TEXT NOT NULL,
+  "emoji" TEXT NOT NULL,
+  "created_at" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ("message_id", "username", "emoji")
+);
+
+-- +goose Down
+-- Drop "reactions" table
+DROP TABLE "reactions";
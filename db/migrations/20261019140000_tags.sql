@@ -0,0 +1,18 @@
+-- +goose Up
+-- Create "tags" table, one row per normalized hashtag
+CREATE TABLE "tags" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "name" TEXT NOT NULL UNIQUE
+);
+-- Create "message_tags" table linking messages to the hashtags in their body
+CREATE TABLE "message_tags" (
+  "message_id" INTEGER NOT NULL REFERENCES "messages" ("id") ON DELETE CASCADE,
+  "tag_id" INTEGER NOT NULL REFERENCES "tags" ("id") ON DELETE CASCADE,
+  PRIMARY KEY ("message_id", "tag_id")
+);
+CREATE INDEX "message_tags_tag_id" ON "message_tags" ("tag_id");
+
+-- +goose Down
+-- Drop "message_tags" and "tags" tables
+DROP TABLE "message_tags";
+DROP TABLE "tags";
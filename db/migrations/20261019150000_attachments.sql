@@ -0,0 +1,18 @@
+-- +goose Up
+-- Create "attachments" table, files uploaded with a message. The files are
+-- kept in blob storage under their content-addressed keys.
+CREATE TABLE "attachments" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "message_id" INTEGER NOT NULL REFERENCES "messages" ("id") ON DELETE CASCADE,
+  "blob_key" TEXT NOT NULL,
+  "filename" TEXT NOT NULL,
+  "content_type" TEXT NOT NULL,
+  "size" INTEGER NOT NULL,
+  "thumbnail_key" TEXT,
+  "created_at" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX "attachments_message_id" ON "attachments" ("message_id");
+
+-- +goose Down
+-- Drop "attachments" table
+DROP TABLE "attachments";
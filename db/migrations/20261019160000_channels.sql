@@ -0,0 +1,20 @@
+-- +goose Up
+-- Create "channels" table, with the "general" channel existing messages move to
+CREATE TABLE "channels" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "slug" TEXT NOT NULL UNIQUE,
+  "name" TEXT NOT NULL,
+  "description" TEXT NOT NULL DEFAULT '',
+  "created_by" TEXT NOT NULL DEFAULT 'anonymous',
+  "created_at" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+INSERT INTO "channels" ("id", "slug", "name", "description") VALUES (1, 'general', 'General', 'Messages for everyone');
+-- Put every message in a channel. SQLite cannot add a column with both a
+-- foreign key and a non-NULL default, so the reference is kept by the app.
+ALTER TABLE messages ADD COLUMN channel_id INTEGER NOT NULL DEFAULT 1;
+CREATE INDEX "messages_channel_id" ON "messages" ("channel_id", "created_at");
+
+-- +goose Down
+DROP INDEX "messages_channel_id";
+ALTER TABLE "messages" DROP COLUMN "channel_id";
+DROP TABLE "channels";
@@ -0,0 +1,25 @@
+-- +goose Up
+-- Create "notifications" table, one row per event a user is told about
+CREATE TABLE "notifications" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "recipient" TEXT NOT NULL,
+  "kind" TEXT NOT NULL,
+  "message_id" INTEGER NOT NULL REFERENCES "messages" ("id") ON DELETE CASCADE,
+  "actor" TEXT NOT NULL,
+  "created_at" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "read_at" DATETIME
+);
+CREATE INDEX "notifications_recipient" ON "notifications" ("recipient", "created_at");
+-- Create "notification_preferences" table; users without a row get every kind
+CREATE TABLE "notification_preferences" (
+  "username" TEXT PRIMARY KEY,
+  "mentions" BOOLEAN NOT NULL DEFAULT TRUE,
+  "replies" BOOLEAN NOT NULL DEFAULT TRUE,
+  "reactions" BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+-- +goose Down
+-- Drop "notification_preferences" and "notifications" tables
+DROP TABLE "notification_preferences";
+DROP INDEX "notifications_recipient";
+DROP TABLE "notifications";
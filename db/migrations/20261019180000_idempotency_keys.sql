@@ -0,0 +1,14 @@
+-- +goose Up
+-- Create "idempotency_keys" table, mapping the key a client sent with a new
+-- message to the message, so a retried request does not post it twice
+CREATE TABLE "idempotency_keys" (
+  "author" TEXT NOT NULL,
+  "key" TEXT NOT NULL,
+  "message_id" INTEGER NOT NULL REFERENCES "messages" ("id") ON DELETE CASCADE,
+  "created_at" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ("author", "key")
+);
+
+-- +goose Down
+-- Drop "idempotency_keys" table
+DROP TABLE "idempotency_keys";
@@ -0,0 +1,26 @@
+-- +goose Up
+-- Create "jobs" table, the queue of background work. A job is queued until
+-- its run_at, then leased by a worker, which holds it until lease_expires_at;
+-- jobs whose lease expired are taken up by another worker. Jobs that fail
+-- are queued again with a later run_at until they have been tried
+-- max_attempts times, when they are dead.
+CREATE TABLE "jobs" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "type" TEXT NOT NULL,
+  "payload" TEXT NOT NULL,
+  "status" TEXT NOT NULL DEFAULT 'queued' CHECK ("status" IN ('queued', 'running', 'succeeded', 'dead')),
+  "attempts" INTEGER NOT NULL DEFAULT 0,
+  "max_attempts" INTEGER NOT NULL,
+  "run_at" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "lease_id" TEXT,
+  "lease_expires_at" DATETIME,
+  "last_error" TEXT,
+  "created_at" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "finished_at" DATETIME
+);
+CREATE INDEX "jobs_status_run_at" ON "jobs" ("status", "run_at");
+
+-- +goose Down
+-- Drop "jobs" table
+DROP TABLE "jobs";
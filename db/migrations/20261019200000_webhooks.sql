@@ -0,0 +1,47 @@
+-- +goose Up
+-- Create "webhooks" table, the subscriptions to message events. events is a
+-- comma-separated list of the event types sent; empty means all of them.
+CREATE TABLE "webhooks" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "url" TEXT NOT NULL,
+  "secret" TEXT NOT NULL,
+  "events" TEXT NOT NULL DEFAULT '',
+  "created_by" TEXT NOT NULL,
+  "created_at" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- Create "webhook_deliveries" table, an event to be sent to a webhook. The
+-- payload is taken when the event happens and sent as is on every attempt.
+-- status is that of the latest attempt.
+CREATE TABLE "webhook_deliveries" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "webhook_id" INTEGER NOT NULL REFERENCES "webhooks" ("id") ON DELETE CASCADE,
+  "event" TEXT NOT NULL,
+  "payload" TEXT NOT NULL,
+  "status" TEXT NOT NULL DEFAULT 'pending' CHECK ("status" IN ('pending', 'succeeded', 'failed')),
+  "attempts" INTEGER NOT NULL DEFAULT 0,
+  "response_code" INTEGER,
+  "created_at" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX "webhook_deliveries_webhook_id" ON "webhook_deliveries" ("webhook_id", "id");
+-- Create "webhook_attempts" table, the log of the requests made for a
+-- delivery. response_code is null when no response came, and error says why
+-- an attempt failed.
+CREATE TABLE "webhook_attempts" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "delivery_id" INTEGER NOT NULL REFERENCES "webhook_deliveries" ("id") ON DELETE CASCADE,
+  "response_code" INTEGER,
+  "error" TEXT,
+  "duration_ms" INTEGER NOT NULL,
+  "created_at" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX "webhook_attempts_delivery_id" ON "webhook_attempts" ("delivery_id");
+
+-- +goose Down
+-- Drop "webhook_attempts" table
+DROP TABLE "webhook_attempts";
+-- Drop "webhook_deliveries" table
+DROP TABLE "webhook_deliveries";
+-- Drop "webhooks" table
+DROP TABLE "webhooks";
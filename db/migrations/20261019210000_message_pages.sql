@@ -0,0 +1,6 @@
+-- +goose Up
+-- Index the messages of a channel by id, the key the message list is paged by
+CREATE INDEX "messages_channel_id_id" ON "messages" ("channel_id", "id");
+
+-- +goose Down
+DROP INDEX "messages_channel_id_id";
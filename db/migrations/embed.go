@@ -0,0 +1,10 @@
+// Package migrations embeds the goose migrations of the database schema, so
+// that binaries can migrate a database without the source tree.
+package migrations
+
+import "embed"
+
+// FS holds the migration files.
+//
+//go:embed *.sql
+var FS embed.FS
@@ -1,5 +1,372 @@
 -- name: GetMessages :many
-SELECT * FROM messages ORDER BY created_at DESC;
+SELECT
+  sqlc.embed(messages),
+  (SELECT COUNT(*) FROM messages AS replies WHERE replies.parent_id = messages.id AND replies.deleted_at IS NULL) AS reply_count
+FROM messages
+WHERE messages.channel_id = @channel_id AND messages.parent_id IS NULL AND messages.deleted_at IS NULL
+  AND messages.id < @before_id
+ORDER BY messages.id DESC
+LIMIT @limit;
 
--- name: CreateMessage :exec
-INSERT INTO messages (body) VALUES (?);
+-- name: GetMessage :one
+SELECT * FROM messages WHERE id = ? AND deleted_at IS NULL;
+
+-- name: CreateMessage :one
+INSERT INTO messages (body, author, editor, parent_id, channel_id)
+VALUES (@body, @author, @author, @parent_id, @channel_id)
+RETURNING *;
+
+-- name: CountReplies :one
+SELECT COUNT(*) FROM messages WHERE parent_id = CAST(sqlc.arg(parent_id) AS INTEGER) AND deleted_at IS NULL;
+
+-- name: GetReplies :many
+SELECT * FROM messages WHERE parent_id = CAST(sqlc.arg(parent_id) AS INTEGER) AND deleted_at IS NULL ORDER BY created_at ASC;
+
+-- name: UpdateMessage :one
+UPDATE messages
+SET body = @body, editor = @editor, updated_at = CURRENT_TIMESTAMP
+WHERE id = @id AND deleted_at IS NULL
+RETURNING *;
+
+-- name: DeleteMessage :execrows
+UPDATE messages
+SET deleted_at = CURRENT_TIMESTAMP, deleted_by = @deleted_by
+WHERE id = @id AND deleted_at IS NULL;
+
+-- name: RestoreMessage :execrows
+UPDATE messages SET deleted_at = NULL, deleted_by = NULL WHERE id = ? AND deleted_at IS NOT NULL;
+
+-- name: GetMessagesAfter :many
+SELECT * FROM messages
+WHERE channel_id = @channel_id AND id > @after_id AND deleted_at IS NULL
+ORDER BY id
+LIMIT @limit;
+
+-- name: GetLatestMessageID :one
+SELECT CAST(COALESCE(MAX(id), 0) AS INTEGER) FROM messages WHERE channel_id = ?;
+
+-- name: GetDeletedMessages :many
+SELECT * FROM messages WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC;
+
+-- name: GetMessageRevisions :many
+SELECT * FROM message_revisions WHERE message_id = ? ORDER BY id DESC;
+
+-- name: SearchMessages :many
+SELECT
+  sqlc.embed(messages),
+  (SELECT COUNT(*) FROM messages AS replies WHERE replies.parent_id = messages.id AND replies.deleted_at IS NULL) AS reply_count,
+  CAST(snippet(messages_fts, 0, char(2), char(3), char(8230), 24) AS TEXT) AS snippet
+FROM messages_fts
+JOIN messages ON messages.id = messages_fts.rowid
+WHERE messages_fts.body MATCH sqlc.arg(query)
+  AND messages.channel_id = sqlc.arg(channel_id)
+  AND messages.deleted_at IS NULL
+ORDER BY bm25(messages_fts), messages.created_at DESC
+LIMIT sqlc.arg(limit);
+
+-- name: AddReaction :execrows
+INSERT INTO reactions (message_id, username, emoji) VALUES (?, ?, ?) ON CONFLICT DO NOTHING;
+
+-- name: RemoveReaction :execrows
+DELETE FROM reactions WHERE message_id = ? AND username = ? AND emoji = ?;
+
+-- name: GetReactionCounts :many
+SELECT
+  message_id,
+  emoji,
+  COUNT(*) AS count,
+  CAST(MAX(username = sqlc.arg(username)) AS BOOLEAN) AS reacted
+FROM reactions
+WHERE message_id IN (sqlc.slice(message_ids))
+GROUP BY message_id, emoji
+ORDER BY message_id, MIN(created_at);
+
+-- name: UpsertTag :one
+INSERT INTO tags (name) VALUES (?) ON CONFLICT (name) DO UPDATE SET name = excluded.name RETURNING id;
+
+-- name: AddMessageTag :exec
+INSERT INTO message_tags (message_id, tag_id) VALUES (?, ?) ON CONFLICT DO NOTHING;
+
+-- name: ClearMessageTags :exec
+DELETE FROM message_tags WHERE message_id = ?;
+
+-- name: GetMessagesByTag :many
+SELECT
+  sqlc.embed(messages),
+  (SELECT COUNT(*) FROM messages AS replies WHERE replies.parent_id = messages.id AND replies.deleted_at IS NULL) AS reply_count
+FROM messages
+JOIN message_tags ON message_tags.message_id = messages.id
+JOIN tags ON tags.id = message_tags.tag_id
+WHERE tags.name = sqlc.arg(tag) AND messages.deleted_at IS NULL
+ORDER BY messages.created_at DESC;
+
+-- name: GetTagCounts :many
+SELECT tags.name, COUNT(*) AS count
+FROM tags
+JOIN message_tags ON message_tags.tag_id = tags.id
+JOIN messages ON messages.id = message_tags.message_id
+WHERE messages.deleted_at IS NULL
+GROUP BY tags.id
+ORDER BY count DESC, tags.name
+LIMIT ?;
+
+-- name: CreateAttachment :one
+INSERT INTO attachments (message_id, blob_key, filename, content_type, size, thumbnail_key)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: SetAttachmentThumbnail :exec
+UPDATE attachments SET thumbnail_key = ? WHERE id = ?;
+
+-- name: GetAttachment :one
+SELECT attachments.*
+FROM attachments
+JOIN messages ON messages.id = attachments.message_id
+WHERE attachments.id = ? AND messages.deleted_at IS NULL;
+
+-- name: GetAttachments :many
+SELECT * FROM attachments WHERE message_id IN (sqlc.slice(message_ids)) ORDER BY id;
+
+-- name: GetAttachmentBlobKeys :many
+SELECT blob_key FROM attachments
+UNION
+SELECT CAST(thumbnail_key AS TEXT) FROM attachments WHERE thumbnail_key IS NOT NULL;
+
+-- name: GetChannels :many
+SELECT * FROM channels ORDER BY name;
+
+-- name: GetChannel :one
+SELECT * FROM channels WHERE slug = ?;
+
+-- name: CreateChannel :one
+INSERT INTO channels (slug, name, description, created_by) VALUES (?, ?, ?, ?) RETURNING *;
+
+-- name: UpdateChannel :one
+UPDATE channels SET name = ?, description = ? WHERE id = ? RETURNING *;
+
+-- name: MoveChannelMessages :exec
+UPDATE messages SET channel_id = sqlc.arg(to_channel_id) WHERE channel_id = sqlc.arg(from_channel_id);
+
+-- name: DeleteChannel :exec
+DELETE FROM channels WHERE id = ?;
+
+-- name: CreateNotification :exec
+INSERT INTO notifications (recipient, kind, message_id, actor) VALUES (?, ?, ?, ?);
+
+-- name: GetNotifications :many
+SELECT
+  sqlc.embed(notifications),
+  messages.body,
+  CAST(COALESCE(messages.parent_id, messages.id) AS INTEGER) AS root_id,
+  channels.slug AS channel_slug
+FROM notifications
+JOIN messages ON messages.id = notifications.message_id
+JOIN channels ON channels.id = messages.channel_id
+WHERE notifications.recipient = ? AND messages.deleted_at IS NULL
+ORDER BY notifications.created_at DESC, notifications.id DESC
+LIMIT ?;
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*)
+FROM notifications
+JOIN messages ON messages.id = notifications.message_id
+WHERE notifications.recipient = ? AND notifications.read_at IS NULL AND messages.deleted_at IS NULL;
+
+-- name: MarkNotificationRead :execrows
+UPDATE notifications SET read_at = COALESCE(read_at, CURRENT_TIMESTAMP) WHERE id = ? AND recipient = ?;
+
+-- name: MarkAllNotificationsRead :exec
+UPDATE notifications SET read_at = CURRENT_TIMESTAMP WHERE recipient = ? AND read_at IS NULL;
+
+-- name: GetNotificationPreferences :one
+SELECT * FROM notification_preferences WHERE username = ?;
+
+-- name: SetNotificationPreferences :one
+INSERT INTO notification_preferences (username, mentions, replies, reactions)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (username) DO UPDATE SET
+  mentions = excluded.mentions,
+  replies = excluded.replies,
+  reactions = excluded.reactions
+RETURNING *;
+
+-- name: ExportMessages :many
+SELECT
+  messages.id,
+  channels.slug AS channel,
+  messages.parent_id,
+  messages.author,
+  messages.body,
+  messages.created_at,
+  messages.updated_at
+FROM messages
+JOIN channels ON channels.id = messages.channel_id
+WHERE messages.deleted_at IS NULL
+  AND messages.id > sqlc.arg(after_id)
+  AND datetime(messages.created_at) >= datetime(CAST(sqlc.arg(since) AS TEXT))
+  AND datetime(messages.created_at) < datetime(CAST(sqlc.arg(until) AS TEXT))
+ORDER BY messages.id
+LIMIT sqlc.arg(limit);
+
+-- name: ImportMessage :one
+-- Timestamps are passed as text in the format of CURRENT_TIMESTAMP, so
+-- imported messages sort among the others whichever driver wrote them.
+INSERT INTO messages (body, author, editor, parent_id, channel_id, created_at, updated_at)
+VALUES (@body, @author, @author, @parent_id, @channel_id, CAST(@created_at AS TEXT), CAST(@updated_at AS TEXT))
+RETURNING *;
+
+-- name: CreateIdempotencyKey :exec
+INSERT INTO idempotency_keys (author, key, message_id) VALUES (?, ?, ?);
+
+-- name: GetIdempotentMessage :one
+-- Deleted messages are returned too: the request that posted one succeeded.
+SELECT messages.*
+FROM idempotency_keys
+JOIN messages ON messages.id = idempotency_keys.message_id
+WHERE idempotency_keys.author = ? AND idempotency_keys.key = ?;
+
+-- name: CreateJob :one
+INSERT INTO jobs (type, payload, max_attempts, run_at)
+VALUES (@type, @payload, @max_attempts, datetime('now', CAST(@delay_seconds AS INTEGER) || ' seconds'))
+RETURNING *;
+
+-- name: ClaimJob :one
+-- Leases the job that is due first: a queued one whose time has come or a
+-- running one whose lease expired, as its worker is gone.
+UPDATE jobs SET
+  status = 'running',
+  attempts = attempts + 1,
+  lease_id = @lease_id,
+  lease_expires_at = datetime('now', CAST(@lease_seconds AS INTEGER) || ' seconds'),
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = (
+  SELECT id FROM jobs
+  WHERE (status = 'queued' AND run_at <= datetime('now'))
+     OR (status = 'running' AND lease_expires_at <= datetime('now'))
+  ORDER BY run_at, id
+  LIMIT 1
+)
+RETURNING *;
+
+-- name: CompleteJob :execrows
+UPDATE jobs SET
+  status = 'succeeded',
+  lease_id = NULL,
+  lease_expires_at = NULL,
+  updated_at = CURRENT_TIMESTAMP,
+  finished_at = CURRENT_TIMESTAMP
+WHERE id = @id AND lease_id = @lease_id;
+
+-- name: RetryJob :execrows
+UPDATE jobs SET
+  status = 'queued',
+  run_at = datetime('now', CAST(@delay_seconds AS INTEGER) || ' seconds'),
+  last_error = @last_error,
+  lease_id = NULL,
+  lease_expires_at = NULL,
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = @id AND lease_id = @lease_id;
+
+-- name: ReleaseJob :execrows
+-- Gives a job back without counting the attempt, as it was interrupted.
+UPDATE jobs SET
+  status = 'queued',
+  attempts = attempts - 1,
+  lease_id = NULL,
+  lease_expires_at = NULL,
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = @id AND lease_id = @lease_id;
+
+-- name: BuryJob :execrows
+UPDATE jobs SET
+  status = 'dead',
+  last_error = @last_error,
+  lease_id = NULL,
+  lease_expires_at = NULL,
+  updated_at = CURRENT_TIMESTAMP,
+  finished_at = CURRENT_TIMESTAMP
+WHERE id = @id AND lease_id = @lease_id;
+
+-- name: RequeueDeadJob :one
+UPDATE jobs SET
+  status = 'queued',
+  attempts = 0,
+  run_at = CURRENT_TIMESTAMP,
+  updated_at = CURRENT_TIMESTAMP,
+  finished_at = NULL
+WHERE id = ? AND status = 'dead'
+RETURNING *;
+
+-- name: GetJobs :many
+-- Lists the latest jobs, of one status unless status is empty.
+SELECT * FROM jobs
+WHERE CAST(@status AS TEXT) = '' OR status = @status
+ORDER BY id DESC
+LIMIT @limit;
+
+-- name: CountJobsByStatus :many
+SELECT status, COUNT(*) AS count FROM jobs GROUP BY status;
+
+-- name: DeleteSucceededJobs :execrows
+DELETE FROM jobs
+WHERE status = 'succeeded' AND finished_at < datetime('now', CAST(@older_than_seconds AS INTEGER) || ' seconds');
+
+-- name: GetWebhooks :many
+SELECT * FROM webhooks ORDER BY id;
+
+-- name: GetWebhook :one
+SELECT * FROM webhooks WHERE id = ?;
+
+-- name: CreateWebhook :one
+INSERT INTO webhooks (url, secret, events, created_by) VALUES (?, ?, ?, ?) RETURNING *;
+
+-- name: UpdateWebhook :one
+UPDATE webhooks SET url = ?, secret = ?, events = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING *;
+
+-- name: DeleteWebhook :exec
+DELETE FROM webhooks WHERE id = ?;
+
+-- name: DeleteWebhookDeliveries :exec
+DELETE FROM webhook_deliveries WHERE webhook_id = ?;
+
+-- name: DeleteWebhookAttempts :exec
+DELETE FROM webhook_attempts
+WHERE delivery_id IN (SELECT id FROM webhook_deliveries WHERE webhook_id = ?);
+
+-- name: GetEventWebhooks :many
+-- Lists the webhooks an event is sent to: those listing it and those
+-- listing no events.
+SELECT * FROM webhooks
+WHERE events = '' OR instr(',' || events || ',', ',' || CAST(@event AS TEXT) || ',') > 0
+ORDER BY id;
+
+-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (webhook_id, event, payload) VALUES (?, ?, ?) RETURNING *;
+
+-- name: GetWebhookDelivery :one
+SELECT sqlc.embed(webhook_deliveries), webhooks.url, webhooks.secret
+FROM webhook_deliveries
+JOIN webhooks ON webhooks.id = webhook_deliveries.webhook_id
+WHERE webhook_deliveries.id = ?;
+
+-- name: GetWebhookDeliveries :many
+SELECT * FROM webhook_deliveries WHERE webhook_id = ? ORDER BY id DESC LIMIT ?;
+
+-- name: SetWebhookDeliveryResult :exec
+UPDATE webhook_deliveries SET
+  status = ?,
+  response_code = ?,
+  attempts = attempts + 1,
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: ResetWebhookDelivery :one
+UPDATE webhook_deliveries SET status = 'pending', updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND webhook_id = ?
+RETURNING *;
+
+-- name: CreateWebhookAttempt :exec
+INSERT INTO webhook_attempts (delivery_id, response_code, error, duration_ms) VALUES (?, ?, ?, ?);
+
+-- name: GetWebhookAttempts :many
+SELECT * FROM webhook_attempts WHERE delivery_id IN (sqlc.slice(delivery_ids)) ORDER BY id;
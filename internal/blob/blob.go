@@ -0,0 +1,33 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrNotFound is returned when a blob does not exist.
+var ErrNotFound = errors.New("blob not found")
+
+// Info describes a stored blob.
+type Info struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// Store stores immutable blobs of data. Blobs are content-addressed: the key
+// of a blob is derived from its content, so storing the same data twice
+// yields the same key and keeps a single copy.
+type Store interface {
+	// Put stores the data read from r and returns its key and size.
+	Put(ctx context.Context, r io.Reader) (Info, error)
+	// Open returns the blob stored under key, or ErrNotFound.
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, Info, error)
+	// Delete removes the blob stored under key. Deleting a blob that does not
+	// exist is not an error.
+	Delete(ctx context.Context, key string) error
+	// Walk calls fn for every stored blob.
+	Walk(ctx context.Context, fn func(Info) error) error
+}
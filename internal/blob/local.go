@@ -0,0 +1,127 @@
+package blob
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// keyPattern matches valid blob keys: hex-encoded SHA-256 digests.
+var keyPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// LocalStore is a Store that keeps blobs as files below a directory. A blob
+// is stored at <dir>/<key[0:2]>/<key[2:4]>/<key>, where the key is the
+// SHA-256 digest of its content.
+type LocalStore struct {
+	dir string
+}
+
+// NewLocalStore creates a new LocalStore keeping its files below dir, which
+// is created if it does not exist.
+func NewLocalStore(dir string) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create blob directory: %w", err)
+	}
+	return &LocalStore{dir: dir}, nil
+}
+
+// Put implements Store. The data is written to a temporary file first and
+// only moved into place once it is complete.
+func (s *LocalStore) Put(_ context.Context, r io.Reader) (Info, error) {
+	tmp, err := os.CreateTemp(s.dir, ".upload-*")
+	if err != nil {
+		return Info{}, err
+	}
+	defer os.Remove(tmp.Name()) // fails harmlessly once renamed
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), r)
+	if err != nil {
+		tmp.Close()
+		return Info{}, err
+	}
+	if err := tmp.Close(); err != nil {
+		return Info{}, err
+	}
+
+	key := hex.EncodeToString(h.Sum(nil))
+	path := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return Info{}, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return Info{}, err
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return Info{}, err
+	}
+	return Info{Key: key, Size: size, ModTime: fi.ModTime()}, nil
+}
+
+// Open implements Store.
+func (s *LocalStore) Open(_ context.Context, key string) (io.ReadSeekCloser, Info, error) {
+	if !keyPattern.MatchString(key) {
+		return nil, Info{}, ErrNotFound
+	}
+
+	f, err := os.Open(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, Info{}, ErrNotFound
+	}
+	if err != nil {
+		return nil, Info{}, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, Info{}, err
+	}
+	return f, Info{Key: key, Size: fi.Size(), ModTime: fi.ModTime()}, nil
+}
+
+// Delete implements Store.
+func (s *LocalStore) Delete(_ context.Context, key string) error {
+	if !keyPattern.MatchString(key) {
+		return nil
+	}
+
+	err := os.Remove(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Walk implements Store. Temporary files of uploads in progress are skipped.
+func (s *LocalStore) Walk(ctx context.Context, fn func(Info) error) error {
+	return filepath.WalkDir(s.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() || !keyPattern.MatchString(d.Name()) {
+			return nil
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return fn(Info{Key: d.Name(), Size: fi.Size(), ModTime: fi.ModTime()})
+	})
+}
+
+func (s *LocalStore) path(key string) string {
+	return filepath.Join(s.dir, key[0:2], key[2:4], key)
+}
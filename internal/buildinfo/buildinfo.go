@@ -0,0 +1,98 @@
+// Package buildinfo describes the build of the running binary, from the
+// values the magefile links in and the build information the Go toolchain
+// embeds.
+package buildinfo
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Set at build time with -ldflags "-X <package>.Version=...". They are
+// empty in binaries built with plain go build or go run.
+var (
+	// Version is the release version, such as v0.3.0.
+	Version string
+	// BuildTime is when the binary was built, in RFC 3339 format.
+	BuildTime string
+)
+
+// Info describes a build.
+type Info struct {
+	// Version is the release version, the module version when built with go
+	// install or from a tagged commit, or "dev".
+	Version string `json:"version"`
+	// Revision is the VCS commit the binary was built from, if known, and
+	// Dirty whether the working tree had uncommitted changes.
+	Revision string `json:"revision,omitempty"`
+	Dirty    bool   `json:"dirty"`
+	// BuildTime is when the binary was built, if known.
+	BuildTime *time.Time `json:"build_time,omitempty"`
+	GoVersion string     `json:"go_version"`
+	Platform  string     `json:"platform"`
+}
+
+// Get returns the build information of the running binary.
+func Get() Info {
+	return get()
+}
+
+var get = sync.OnceValue(func() Info {
+	info := Info{
+		Version:   Version,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if t, err := time.Parse(time.RFC3339, BuildTime); err == nil {
+		info.BuildTime = &t
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				info.Revision = s.Value
+			case "vcs.modified":
+				info.Dirty = s.Value == "true"
+			}
+		}
+		// Untagged commits get a pseudo-version ending in the revision,
+		// which says no more than Revision.
+		v := bi.Main.Version
+		if info.Version == "" && v != "(devel)" && (info.Revision == "" || !strings.Contains(v, info.ShortRevision())) {
+			info.Version = v
+		}
+	}
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	return info
+})
+
+// ShortRevision returns the first 12 characters of the revision.
+func (i Info) ShortRevision() string {
+	return i.Revision[:min(len(i.Revision), 12)]
+}
+
+// String describes the build on one line, such as
+// "v0.3.0 (3f2c1a9b0d4e, dirty) built 2026-10-19T18:00:00Z with go1.24.5".
+func (i Info) String() string {
+	var b strings.Builder
+	b.WriteString(i.Version)
+	if i.Revision != "" {
+		b.WriteString(" (" + i.ShortRevision())
+		if i.Dirty {
+			b.WriteString(", dirty")
+		}
+		b.WriteString(")")
+	}
+	if i.BuildTime != nil {
+		b.WriteString(" built " + i.BuildTime.UTC().Format(time.RFC3339))
+	}
+	fmt.Fprintf(&b, " with %s", i.GoVersion)
+	return b.String()
+}
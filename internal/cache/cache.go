@@ -18,6 +18,8 @@ func New(cfg *config.Cache) (*Cache, error) {
 		NumCounters: cfg.NumCounters,
 		MaxCost:     cfg.MaxCost,
 		BufferItems: cfg.BufferItems,
+		// Hits and misses are reported on /metrics.
+		Metrics: true,
 	})
 	if err != nil {
 		return nil, err
@@ -45,3 +47,8 @@ func (c *Cache) Get(key string) (interface{}, bool) {
 func (c *Cache) Del(key string) {
 	c.Memory.Del(key)
 }
+
+// Clear removes every item from the memory cache.
+func (c *Cache) Clear() {
+	c.Memory.Clear()
+}
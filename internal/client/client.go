@@ -0,0 +1,318 @@
+// Package client is a client for the server's JSON API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/buildinfo"
+	"github.com/dunamismax/go-modern-scaffold/internal/db"
+	"github.com/dunamismax/go-modern-scaffold/internal/metrics"
+)
+
+// DefaultUserHeader is the header the server reads the username from unless
+// configured otherwise.
+const DefaultUserHeader = "X-Remote-User"
+
+// Client calls the API of one server.
+type Client struct {
+	baseURL *url.URL
+	// User is the username sent with each request. Without one the server
+	// treats requests as anonymous. The server only believes it on requests
+	// from the addresses it trusts as proxies.
+	User string
+	// UserHeader is the header User is sent in.
+	UserHeader string
+	// Token, if set, is sent as bearer token with each request, for an
+	// authenticating proxy in front of the server to check.
+	Token string
+	// HTTP is the client requests are made with.
+	HTTP *http.Client
+}
+
+// New creates a Client for the server at baseURL.
+func New(baseURL string) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid server URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid server URL %q: want http or https", baseURL)
+	}
+	return &Client{
+		baseURL:    u,
+		UserHeader: DefaultUserHeader,
+		HTTP:       &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// BaseURL returns the URL of the server.
+func (c *Client) BaseURL() string {
+	return c.baseURL.String()
+}
+
+// Message is a message as listed by the API.
+type Message struct {
+	db.Message
+	ReplyCount int64 `json:"reply_count"`
+}
+
+// APIError is an error response of the API.
+type APIError struct {
+	StatusCode int
+	Title      string            `json:"title"`
+	Detail     string            `json:"detail"`
+	Errors     map[string]string `json:"errors"`
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	msg := e.Detail
+	if msg == "" {
+		msg = e.Title
+	}
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	if len(e.Errors) > 0 {
+		fields := make([]string, 0, len(e.Errors))
+		for field := range e.Errors {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			if e.Errors[field] != msg {
+				msg += "; " + e.Errors[field]
+			}
+		}
+	}
+	return fmt.Sprintf("%s (HTTP %d)", msg, e.StatusCode)
+}
+
+// Identity is the user the server takes requests to be made by.
+type Identity struct {
+	User  string `json:"user"`
+	Admin bool   `json:"admin"`
+}
+
+// Identity returns whom the server takes the requests of c to be made by.
+func (c *Client) Identity(ctx context.Context) (Identity, error) {
+	var id Identity
+	err := c.do(ctx, http.MethodGet, "/api/me", nil, nil, &id)
+	return id, err
+}
+
+// Health is the health of a server.
+type Health struct {
+	Status string `json:"status"`
+}
+
+// Health checks that the server is up.
+func (c *Client) Health(ctx context.Context) (Health, error) {
+	var h Health
+	err := c.do(ctx, http.MethodGet, "/health", nil, nil, &h)
+	return h, err
+}
+
+// Version returns the build information of the server.
+func (c *Client) Version(ctx context.Context) (buildinfo.Info, error) {
+	var info buildinfo.Info
+	err := c.do(ctx, http.MethodGet, "/version", nil, nil, &info)
+	return info, err
+}
+
+// Metrics returns the operational metrics of the server. Only admins may
+// read them.
+func (c *Client) Metrics(ctx context.Context) (metrics.Report, error) {
+	var report metrics.Report
+	err := c.do(ctx, http.MethodGet, "/metrics", nil, nil, &report)
+	return report, err
+}
+
+// MaxListLimit is the most messages the server lists in one page.
+const MaxListLimit = 200
+
+// ListOptions selects the messages returned by ListMessages.
+type ListOptions struct {
+	// Channel is the slug of the channel to list; empty means the default.
+	Channel string
+	// Query searches the messages of the channel. Limit and Before do not
+	// apply to searches.
+	Query string
+	// Limit is the number of messages in the page, up to MaxListLimit; 0
+	// means the server's default of 50.
+	Limit int
+	// Before lists the messages with IDs below it, so that the ID of the
+	// last message of a page gives the next one; 0 means the newest.
+	Before int64
+}
+
+// ListMessages returns a page of the root messages of a channel, newest
+// first. A page shorter than the limit is the last one.
+func (c *Client) ListMessages(ctx context.Context, opts ListOptions) ([]Message, error) {
+	query := url.Values{}
+	if opts.Channel != "" {
+		query.Set("channel", opts.Channel)
+	}
+	if opts.Query != "" {
+		query.Set("q", opts.Query)
+	}
+	if opts.Limit != 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Before != 0 {
+		query.Set("before", strconv.FormatInt(opts.Before, 10))
+	}
+	var messages []Message
+	err := c.do(ctx, http.MethodGet, "/api/messages", query, nil, &messages)
+	return messages, err
+}
+
+// GetMessage returns a single message.
+func (c *Client) GetMessage(ctx context.Context, id int64) (db.Message, error) {
+	var msg db.Message
+	err := c.do(ctx, http.MethodGet, messagePath(id), nil, nil, &msg)
+	return msg, err
+}
+
+// CreateMessage posts a message to a channel, or the default channel when
+// channel is empty, and returns it. If idempotencyKey is not empty, the
+// server posts the message only once for all requests with that key, so a
+// request whose outcome is unknown can be retried safely.
+func (c *Client) CreateMessage(ctx context.Context, channel, body, idempotencyKey string) (db.Message, error) {
+	query := url.Values{}
+	if channel != "" {
+		query.Set("channel", channel)
+	}
+	header := http.Header{}
+	if idempotencyKey != "" {
+		header.Set("Idempotency-Key", idempotencyKey)
+	}
+	var msg db.Message
+	err := c.doWithHeader(ctx, http.MethodPost, "/api/messages", query, header, map[string]string{"body": body}, &msg)
+	return msg, err
+}
+
+// UpdateMessage replaces the body of a message and returns it.
+func (c *Client) UpdateMessage(ctx context.Context, id int64, body string) (db.Message, error) {
+	var msg db.Message
+	err := c.do(ctx, http.MethodPut, messagePath(id), nil, map[string]string{"body": body}, &msg)
+	return msg, err
+}
+
+// DeleteMessage deletes a message.
+func (c *Client) DeleteMessage(ctx context.Context, id int64) error {
+	return c.do(ctx, http.MethodDelete, messagePath(id), nil, nil, nil)
+}
+
+// do sends a request with in, if not nil, as JSON body and decodes the
+// response into out, if not nil. Error responses yield an *APIError.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out any) error {
+	return c.doWithHeader(ctx, method, path, query, nil, in, out)
+}
+
+// doWithHeader is do with extra request headers.
+func (c *Client) doWithHeader(ctx context.Context, method, path string, query url.Values, header http.Header, in, out any) error {
+	u := c.baseURL.JoinPath(path)
+	u.RawQuery = query.Encode()
+
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	c.authorize(req)
+
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		apiErr := &APIError{StatusCode: res.StatusCode}
+		// Not every error response is problem+json, e.g. those of a proxy.
+		_ = json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(apiErr)
+		return apiErr
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// IsTemporary reports whether err may go away when the request is retried:
+// whether the server could not be reached, took too long or reported being
+// unable to serve the request for now.
+func IsTemporary(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError,
+			http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET)
+}
+
+// authorize adds the credentials of c to req.
+func (c *Client) authorize(req *http.Request) {
+	if c.User != "" {
+		req.Header.Set(c.UserHeader, c.User)
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+}
+
+// IsStatus reports whether err is an *APIError with one of codes.
+func IsStatus(err error, codes ...int) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	for _, code := range codes {
+		if apiErr.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// messagePath returns the API path of a message.
+func messagePath(id int64) string {
+	return "/api/messages/" + strconv.FormatInt(id, 10)
+}
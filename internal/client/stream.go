@@ -0,0 +1,225 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/db"
+)
+
+// Reconnection delays of FollowMessages. The delay doubles after each failed
+// attempt, up to maxBackoff, and starts over once a connection succeeds.
+const (
+	minBackoff = time.Second
+	maxBackoff = 30 * time.Second
+)
+
+// streamIdleTimeout is how long a stream may stay silent before it is taken
+// for dead. The server sends a keep-alive comment every 30 seconds.
+const streamIdleTimeout = 75 * time.Second
+
+// StreamState is the state of the connection of FollowMessages.
+type StreamState int
+
+const (
+	// StreamConnecting means the first connection is being made.
+	StreamConnecting StreamState = iota
+	// StreamConnected means messages are being received.
+	StreamConnected
+	// StreamReconnecting means the connection was lost or refused and is
+	// tried again after a delay.
+	StreamReconnecting
+)
+
+func (s StreamState) String() string {
+	switch s {
+	case StreamConnected:
+		return "connected"
+	case StreamReconnecting:
+		return "reconnecting"
+	}
+	return "connecting"
+}
+
+// StreamOptions configures FollowMessages.
+type StreamOptions struct {
+	// Channel is the slug of the channel to follow; empty means the default.
+	Channel string
+	// After is the ID of the last message already seen. Later messages are
+	// sent first; when 0 only messages posted from now on are.
+	After int64
+	// OnState, if not nil, is called whenever the connection state changes.
+	// The error is why a connection was lost and nil otherwise.
+	OnState func(state StreamState, err error)
+}
+
+// FollowMessages calls fn for every new message and reply posted to a
+// channel, in order, until ctx is done or fn fails. A lost connection is
+// reopened with exponential backoff, resuming after the last message seen,
+// so none is missed or repeated. It returns the error of fn, of ctx, or the
+// API error for a request that cannot succeed, such as for a channel that
+// does not exist.
+func (c *Client) FollowMessages(ctx context.Context, opts StreamOptions, fn func(db.Message) error) error {
+	setState := func(state StreamState, err error) {
+		if opts.OnState != nil {
+			opts.OnState(state, err)
+		}
+	}
+
+	var lastEventID string
+	if opts.After > 0 {
+		lastEventID = strconv.FormatInt(opts.After, 10)
+	}
+	backoff := minBackoff
+	setState(StreamConnecting, nil)
+	for {
+		connected := false
+		err := c.streamMessages(ctx, opts.Channel, lastEventID, func(ev event) error {
+			if !connected {
+				connected = true
+				backoff = minBackoff
+				setState(StreamConnected, nil)
+			}
+			if ev.id != "" {
+				lastEventID = ev.id
+			}
+			if ev.name != "message" {
+				return nil
+			}
+			var msg db.Message
+			if err := json.Unmarshal([]byte(ev.data), &msg); err != nil {
+				return stopError{fmt.Errorf("decode message: %w", err)}
+			}
+			if err := fn(msg); err != nil {
+				return stopError{err}
+			}
+			return nil
+		})
+		var stop stopError
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case errors.As(err, &stop):
+			return stop.err
+		case IsStatus(err, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound):
+			return err
+		case err == nil:
+			err = errors.New("the server closed the stream")
+		}
+
+		setState(StreamReconnecting, err)
+		// Jitter keeps clients that lost the same server from returning at once.
+		delay := backoff/2 + rand.N(backoff/2)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// stopError is an error that ends FollowMessages instead of causing a
+// reconnect, such as one returned by its caller's function.
+type stopError struct {
+	err error
+}
+
+func (e stopError) Error() string { return e.err.Error() }
+
+// event is a server-sent event.
+type event struct {
+	id, name, data string
+}
+
+// streamMessages opens the message stream of channel once, resuming after
+// lastEventID unless it is empty, and calls fn for each event until the
+// stream ends or fn returns a non-nil error.
+func (c *Client) streamMessages(ctx context.Context, channel, lastEventID string, fn func(event) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	query := url.Values{}
+	if channel != "" {
+		query.Set("channel", channel)
+	}
+	u := c.baseURL.JoinPath("/api/messages/stream")
+	u.RawQuery = query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	c.authorize(req)
+
+	// The timeout of c.HTTP would cut every stream short; the idle timer
+	// below notices dead connections instead.
+	hc := *c.HTTP
+	hc.Timeout = 0
+	res, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 400 {
+		apiErr := &APIError{StatusCode: res.StatusCode}
+		_ = json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(apiErr)
+		return apiErr
+	}
+
+	idle := time.AfterFunc(streamIdleTimeout, cancel)
+	defer idle.Stop()
+
+	scanner := bufio.NewScanner(res.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	var ev event
+	var data []string
+	for scanner.Scan() {
+		idle.Reset(streamIdleTimeout)
+		line := scanner.Text()
+		if line == "" {
+			if data != nil || ev.id != "" {
+				ev.data = strings.Join(data, "\n")
+				if err := fn(ev); err != nil {
+					return err
+				}
+			}
+			ev, data = event{}, nil
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue // a comment, such as a keep-alive
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			ev.id = value
+		case "event":
+			ev.name = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if ctx.Err() != nil {
+			// Cancelled by the idle timer, or the caller, who ignores this.
+			return fmt.Errorf("no data received for %s", streamIdleTimeout)
+		}
+		return err
+	}
+	return nil
+}
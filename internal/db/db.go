@@ -24,27 +24,555 @@ func New(db DBTX) *Queries {
 func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	q := Queries{db: db}
 	var err error
+	if q.addMessageTagStmt, err = db.PrepareContext(ctx, addMessageTag); err != nil {
+		return nil, fmt.Errorf("error preparing query AddMessageTag: %w", err)
+	}
+	if q.addReactionStmt, err = db.PrepareContext(ctx, addReaction); err != nil {
+		return nil, fmt.Errorf("error preparing query AddReaction: %w", err)
+	}
+	if q.buryJobStmt, err = db.PrepareContext(ctx, buryJob); err != nil {
+		return nil, fmt.Errorf("error preparing query BuryJob: %w", err)
+	}
+	if q.claimJobStmt, err = db.PrepareContext(ctx, claimJob); err != nil {
+		return nil, fmt.Errorf("error preparing query ClaimJob: %w", err)
+	}
+	if q.clearMessageTagsStmt, err = db.PrepareContext(ctx, clearMessageTags); err != nil {
+		return nil, fmt.Errorf("error preparing query ClearMessageTags: %w", err)
+	}
+	if q.completeJobStmt, err = db.PrepareContext(ctx, completeJob); err != nil {
+		return nil, fmt.Errorf("error preparing query CompleteJob: %w", err)
+	}
+	if q.countJobsByStatusStmt, err = db.PrepareContext(ctx, countJobsByStatus); err != nil {
+		return nil, fmt.Errorf("error preparing query CountJobsByStatus: %w", err)
+	}
+	if q.countRepliesStmt, err = db.PrepareContext(ctx, countReplies); err != nil {
+		return nil, fmt.Errorf("error preparing query CountReplies: %w", err)
+	}
+	if q.countUnreadNotificationsStmt, err = db.PrepareContext(ctx, countUnreadNotifications); err != nil {
+		return nil, fmt.Errorf("error preparing query CountUnreadNotifications: %w", err)
+	}
+	if q.createAttachmentStmt, err = db.PrepareContext(ctx, createAttachment); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateAttachment: %w", err)
+	}
+	if q.createChannelStmt, err = db.PrepareContext(ctx, createChannel); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateChannel: %w", err)
+	}
+	if q.createIdempotencyKeyStmt, err = db.PrepareContext(ctx, createIdempotencyKey); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateIdempotencyKey: %w", err)
+	}
+	if q.createJobStmt, err = db.PrepareContext(ctx, createJob); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateJob: %w", err)
+	}
 	if q.createMessageStmt, err = db.PrepareContext(ctx, createMessage); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateMessage: %w", err)
 	}
+	if q.createNotificationStmt, err = db.PrepareContext(ctx, createNotification); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateNotification: %w", err)
+	}
+	if q.createWebhookStmt, err = db.PrepareContext(ctx, createWebhook); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateWebhook: %w", err)
+	}
+	if q.createWebhookAttemptStmt, err = db.PrepareContext(ctx, createWebhookAttempt); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateWebhookAttempt: %w", err)
+	}
+	if q.createWebhookDeliveryStmt, err = db.PrepareContext(ctx, createWebhookDelivery); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateWebhookDelivery: %w", err)
+	}
+	if q.deleteChannelStmt, err = db.PrepareContext(ctx, deleteChannel); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteChannel: %w", err)
+	}
+	if q.deleteMessageStmt, err = db.PrepareContext(ctx, deleteMessage); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteMessage: %w", err)
+	}
+	if q.deleteSucceededJobsStmt, err = db.PrepareContext(ctx, deleteSucceededJobs); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteSucceededJobs: %w", err)
+	}
+	if q.deleteWebhookStmt, err = db.PrepareContext(ctx, deleteWebhook); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteWebhook: %w", err)
+	}
+	if q.deleteWebhookAttemptsStmt, err = db.PrepareContext(ctx, deleteWebhookAttempts); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteWebhookAttempts: %w", err)
+	}
+	if q.deleteWebhookDeliveriesStmt, err = db.PrepareContext(ctx, deleteWebhookDeliveries); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteWebhookDeliveries: %w", err)
+	}
+	if q.exportMessagesStmt, err = db.PrepareContext(ctx, exportMessages); err != nil {
+		return nil, fmt.Errorf("error preparing query ExportMessages: %w", err)
+	}
+	if q.getAttachmentStmt, err = db.PrepareContext(ctx, getAttachment); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAttachment: %w", err)
+	}
+	if q.getAttachmentBlobKeysStmt, err = db.PrepareContext(ctx, getAttachmentBlobKeys); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAttachmentBlobKeys: %w", err)
+	}
+	if q.getAttachmentsStmt, err = db.PrepareContext(ctx, getAttachments); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAttachments: %w", err)
+	}
+	if q.getChannelStmt, err = db.PrepareContext(ctx, getChannel); err != nil {
+		return nil, fmt.Errorf("error preparing query GetChannel: %w", err)
+	}
+	if q.getChannelsStmt, err = db.PrepareContext(ctx, getChannels); err != nil {
+		return nil, fmt.Errorf("error preparing query GetChannels: %w", err)
+	}
+	if q.getDeletedMessagesStmt, err = db.PrepareContext(ctx, getDeletedMessages); err != nil {
+		return nil, fmt.Errorf("error preparing query GetDeletedMessages: %w", err)
+	}
+	if q.getEventWebhooksStmt, err = db.PrepareContext(ctx, getEventWebhooks); err != nil {
+		return nil, fmt.Errorf("error preparing query GetEventWebhooks: %w", err)
+	}
+	if q.getIdempotentMessageStmt, err = db.PrepareContext(ctx, getIdempotentMessage); err != nil {
+		return nil, fmt.Errorf("error preparing query GetIdempotentMessage: %w", err)
+	}
+	if q.getJobsStmt, err = db.PrepareContext(ctx, getJobs); err != nil {
+		return nil, fmt.Errorf("error preparing query GetJobs: %w", err)
+	}
+	if q.getLatestMessageIDStmt, err = db.PrepareContext(ctx, getLatestMessageID); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLatestMessageID: %w", err)
+	}
+	if q.getMessageStmt, err = db.PrepareContext(ctx, getMessage); err != nil {
+		return nil, fmt.Errorf("error preparing query GetMessage: %w", err)
+	}
+	if q.getMessageRevisionsStmt, err = db.PrepareContext(ctx, getMessageRevisions); err != nil {
+		return nil, fmt.Errorf("error preparing query GetMessageRevisions: %w", err)
+	}
 	if q.getMessagesStmt, err = db.PrepareContext(ctx, getMessages); err != nil {
 		return nil, fmt.Errorf("error preparing query GetMessages: %w", err)
 	}
+	if q.getMessagesAfterStmt, err = db.PrepareContext(ctx, getMessagesAfter); err != nil {
+		return nil, fmt.Errorf("error preparing query GetMessagesAfter: %w", err)
+	}
+	if q.getMessagesByTagStmt, err = db.PrepareContext(ctx, getMessagesByTag); err != nil {
+		return nil, fmt.Errorf("error preparing query GetMessagesByTag: %w", err)
+	}
+	if q.getNotificationPreferencesStmt, err = db.PrepareContext(ctx, getNotificationPreferences); err != nil {
+		return nil, fmt.Errorf("error preparing query GetNotificationPreferences: %w", err)
+	}
+	if q.getNotificationsStmt, err = db.PrepareContext(ctx, getNotifications); err != nil {
+		return nil, fmt.Errorf("error preparing query GetNotifications: %w", err)
+	}
+	if q.getReactionCountsStmt, err = db.PrepareContext(ctx, getReactionCounts); err != nil {
+		return nil, fmt.Errorf("error preparing query GetReactionCounts: %w", err)
+	}
+	if q.getRepliesStmt, err = db.PrepareContext(ctx, getReplies); err != nil {
+		return nil, fmt.Errorf("error preparing query GetReplies: %w", err)
+	}
+	if q.getTagCountsStmt, err = db.PrepareContext(ctx, getTagCounts); err != nil {
+		return nil, fmt.Errorf("error preparing query GetTagCounts: %w", err)
+	}
+	if q.getWebhookStmt, err = db.PrepareContext(ctx, getWebhook); err != nil {
+		return nil, fmt.Errorf("error preparing query GetWebhook: %w", err)
+	}
+	if q.getWebhookAttemptsStmt, err = db.PrepareContext(ctx, getWebhookAttempts); err != nil {
+		return nil, fmt.Errorf("error preparing query GetWebhookAttempts: %w", err)
+	}
+	if q.getWebhookDeliveriesStmt, err = db.PrepareContext(ctx, getWebhookDeliveries); err != nil {
+		return nil, fmt.Errorf("error preparing query GetWebhookDeliveries: %w", err)
+	}
+	if q.getWebhookDeliveryStmt, err = db.PrepareContext(ctx, getWebhookDelivery); err != nil {
+		return nil, fmt.Errorf("error preparing query GetWebhookDelivery: %w", err)
+	}
+	if q.getWebhooksStmt, err = db.PrepareContext(ctx, getWebhooks); err != nil {
+		return nil, fmt.Errorf("error preparing query GetWebhooks: %w", err)
+	}
+	if q.importMessageStmt, err = db.PrepareContext(ctx, importMessage); err != nil {
+		return nil, fmt.Errorf("error preparing query ImportMessage: %w", err)
+	}
+	if q.markAllNotificationsReadStmt, err = db.PrepareContext(ctx, markAllNotificationsRead); err != nil {
+		return nil, fmt.Errorf("error preparing query MarkAllNotificationsRead: %w", err)
+	}
+	if q.markNotificationReadStmt, err = db.PrepareContext(ctx, markNotificationRead); err != nil {
+		return nil, fmt.Errorf("error preparing query MarkNotificationRead: %w", err)
+	}
+	if q.moveChannelMessagesStmt, err = db.PrepareContext(ctx, moveChannelMessages); err != nil {
+		return nil, fmt.Errorf("error preparing query MoveChannelMessages: %w", err)
+	}
+	if q.releaseJobStmt, err = db.PrepareContext(ctx, releaseJob); err != nil {
+		return nil, fmt.Errorf("error preparing query ReleaseJob: %w", err)
+	}
+	if q.removeReactionStmt, err = db.PrepareContext(ctx, removeReaction); err != nil {
+		return nil, fmt.Errorf("error preparing query RemoveReaction: %w", err)
+	}
+	if q.requeueDeadJobStmt, err = db.PrepareContext(ctx, requeueDeadJob); err != nil {
+		return nil, fmt.Errorf("error preparing query RequeueDeadJob: %w", err)
+	}
+	if q.resetWebhookDeliveryStmt, err = db.PrepareContext(ctx, resetWebhookDelivery); err != nil {
+		return nil, fmt.Errorf("error preparing query ResetWebhookDelivery: %w", err)
+	}
+	if q.restoreMessageStmt, err = db.PrepareContext(ctx, restoreMessage); err != nil {
+		return nil, fmt.Errorf("error preparing query RestoreMessage: %w", err)
+	}
+	if q.retryJobStmt, err = db.PrepareContext(ctx, retryJob); err != nil {
+		return nil, fmt.Errorf("error preparing query RetryJob: %w", err)
+	}
+	if q.searchMessagesStmt, err = db.PrepareContext(ctx, searchMessages); err != nil {
+		return nil, fmt.Errorf("error preparing query SearchMessages: %w", err)
+	}
+	if q.setAttachmentThumbnailStmt, err = db.PrepareContext(ctx, setAttachmentThumbnail); err != nil {
+		return nil, fmt.Errorf("error preparing query SetAttachmentThumbnail: %w", err)
+	}
+	if q.setNotificationPreferencesStmt, err = db.PrepareContext(ctx, setNotificationPreferences); err != nil {
+		return nil, fmt.Errorf("error preparing query SetNotificationPreferences: %w", err)
+	}
+	if q.setWebhookDeliveryResultStmt, err = db.PrepareContext(ctx, setWebhookDeliveryResult); err != nil {
+		return nil, fmt.Errorf("error preparing query SetWebhookDeliveryResult: %w", err)
+	}
+	if q.updateChannelStmt, err = db.PrepareContext(ctx, updateChannel); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateChannel: %w", err)
+	}
+	if q.updateMessageStmt, err = db.PrepareContext(ctx, updateMessage); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateMessage: %w", err)
+	}
+	if q.updateWebhookStmt, err = db.PrepareContext(ctx, updateWebhook); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateWebhook: %w", err)
+	}
+	if q.upsertTagStmt, err = db.PrepareContext(ctx, upsertTag); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertTag: %w", err)
+	}
 	return &q, nil
 }
 
 func (q *Queries) Close() error {
 	var err error
+	if q.addMessageTagStmt != nil {
+		if cerr := q.addMessageTagStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing addMessageTagStmt: %w", cerr)
+		}
+	}
+	if q.addReactionStmt != nil {
+		if cerr := q.addReactionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing addReactionStmt: %w", cerr)
+		}
+	}
+	if q.buryJobStmt != nil {
+		if cerr := q.buryJobStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing buryJobStmt: %w", cerr)
+		}
+	}
+	if q.claimJobStmt != nil {
+		if cerr := q.claimJobStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing claimJobStmt: %w", cerr)
+		}
+	}
+	if q.clearMessageTagsStmt != nil {
+		if cerr := q.clearMessageTagsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing clearMessageTagsStmt: %w", cerr)
+		}
+	}
+	if q.completeJobStmt != nil {
+		if cerr := q.completeJobStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing completeJobStmt: %w", cerr)
+		}
+	}
+	if q.countJobsByStatusStmt != nil {
+		if cerr := q.countJobsByStatusStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countJobsByStatusStmt: %w", cerr)
+		}
+	}
+	if q.countRepliesStmt != nil {
+		if cerr := q.countRepliesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countRepliesStmt: %w", cerr)
+		}
+	}
+	if q.countUnreadNotificationsStmt != nil {
+		if cerr := q.countUnreadNotificationsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countUnreadNotificationsStmt: %w", cerr)
+		}
+	}
+	if q.createAttachmentStmt != nil {
+		if cerr := q.createAttachmentStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createAttachmentStmt: %w", cerr)
+		}
+	}
+	if q.createChannelStmt != nil {
+		if cerr := q.createChannelStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createChannelStmt: %w", cerr)
+		}
+	}
+	if q.createIdempotencyKeyStmt != nil {
+		if cerr := q.createIdempotencyKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createIdempotencyKeyStmt: %w", cerr)
+		}
+	}
+	if q.createJobStmt != nil {
+		if cerr := q.createJobStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createJobStmt: %w", cerr)
+		}
+	}
 	if q.createMessageStmt != nil {
 		if cerr := q.createMessageStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createMessageStmt: %w", cerr)
 		}
 	}
+	if q.createNotificationStmt != nil {
+		if cerr := q.createNotificationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createNotificationStmt: %w", cerr)
+		}
+	}
+	if q.createWebhookStmt != nil {
+		if cerr := q.createWebhookStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createWebhookStmt: %w", cerr)
+		}
+	}
+	if q.createWebhookAttemptStmt != nil {
+		if cerr := q.createWebhookAttemptStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createWebhookAttemptStmt: %w", cerr)
+		}
+	}
+	if q.createWebhookDeliveryStmt != nil {
+		if cerr := q.createWebhookDeliveryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createWebhookDeliveryStmt: %w", cerr)
+		}
+	}
+	if q.deleteChannelStmt != nil {
+		if cerr := q.deleteChannelStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteChannelStmt: %w", cerr)
+		}
+	}
+	if q.deleteMessageStmt != nil {
+		if cerr := q.deleteMessageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteMessageStmt: %w", cerr)
+		}
+	}
+	if q.deleteSucceededJobsStmt != nil {
+		if cerr := q.deleteSucceededJobsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteSucceededJobsStmt: %w", cerr)
+		}
+	}
+	if q.deleteWebhookStmt != nil {
+		if cerr := q.deleteWebhookStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteWebhookStmt: %w", cerr)
+		}
+	}
+	if q.deleteWebhookAttemptsStmt != nil {
+		if cerr := q.deleteWebhookAttemptsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteWebhookAttemptsStmt: %w", cerr)
+		}
+	}
+	if q.deleteWebhookDeliveriesStmt != nil {
+		if cerr := q.deleteWebhookDeliveriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteWebhookDeliveriesStmt: %w", cerr)
+		}
+	}
+	if q.exportMessagesStmt != nil {
+		if cerr := q.exportMessagesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing exportMessagesStmt: %w", cerr)
+		}
+	}
+	if q.getAttachmentStmt != nil {
+		if cerr := q.getAttachmentStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAttachmentStmt: %w", cerr)
+		}
+	}
+	if q.getAttachmentBlobKeysStmt != nil {
+		if cerr := q.getAttachmentBlobKeysStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAttachmentBlobKeysStmt: %w", cerr)
+		}
+	}
+	if q.getAttachmentsStmt != nil {
+		if cerr := q.getAttachmentsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAttachmentsStmt: %w", cerr)
+		}
+	}
+	if q.getChannelStmt != nil {
+		if cerr := q.getChannelStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getChannelStmt: %w", cerr)
+		}
+	}
+	if q.getChannelsStmt != nil {
+		if cerr := q.getChannelsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getChannelsStmt: %w", cerr)
+		}
+	}
+	if q.getDeletedMessagesStmt != nil {
+		if cerr := q.getDeletedMessagesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDeletedMessagesStmt: %w", cerr)
+		}
+	}
+	if q.getEventWebhooksStmt != nil {
+		if cerr := q.getEventWebhooksStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getEventWebhooksStmt: %w", cerr)
+		}
+	}
+	if q.getIdempotentMessageStmt != nil {
+		if cerr := q.getIdempotentMessageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getIdempotentMessageStmt: %w", cerr)
+		}
+	}
+	if q.getJobsStmt != nil {
+		if cerr := q.getJobsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getJobsStmt: %w", cerr)
+		}
+	}
+	if q.getLatestMessageIDStmt != nil {
+		if cerr := q.getLatestMessageIDStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLatestMessageIDStmt: %w", cerr)
+		}
+	}
+	if q.getMessageStmt != nil {
+		if cerr := q.getMessageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getMessageStmt: %w", cerr)
+		}
+	}
+	if q.getMessageRevisionsStmt != nil {
+		if cerr := q.getMessageRevisionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getMessageRevisionsStmt: %w", cerr)
+		}
+	}
 	if q.getMessagesStmt != nil {
 		if cerr := q.getMessagesStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getMessagesStmt: %w", cerr)
 		}
 	}
+	if q.getMessagesAfterStmt != nil {
+		if cerr := q.getMessagesAfterStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getMessagesAfterStmt: %w", cerr)
+		}
+	}
+	if q.getMessagesByTagStmt != nil {
+		if cerr := q.getMessagesByTagStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getMessagesByTagStmt: %w", cerr)
+		}
+	}
+	if q.getNotificationPreferencesStmt != nil {
+		if cerr := q.getNotificationPreferencesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getNotificationPreferencesStmt: %w", cerr)
+		}
+	}
+	if q.getNotificationsStmt != nil {
+		if cerr := q.getNotificationsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getNotificationsStmt: %w", cerr)
+		}
+	}
+	if q.getReactionCountsStmt != nil {
+		if cerr := q.getReactionCountsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getReactionCountsStmt: %w", cerr)
+		}
+	}
+	if q.getRepliesStmt != nil {
+		if cerr := q.getRepliesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getRepliesStmt: %w", cerr)
+		}
+	}
+	if q.getTagCountsStmt != nil {
+		if cerr := q.getTagCountsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getTagCountsStmt: %w", cerr)
+		}
+	}
+	if q.getWebhookStmt != nil {
+		if cerr := q.getWebhookStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getWebhookStmt: %w", cerr)
+		}
+	}
+	if q.getWebhookAttemptsStmt != nil {
+		if cerr := q.getWebhookAttemptsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getWebhookAttemptsStmt: %w", cerr)
+		}
+	}
+	if q.getWebhookDeliveriesStmt != nil {
+		if cerr := q.getWebhookDeliveriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getWebhookDeliveriesStmt: %w", cerr)
+		}
+	}
+	if q.getWebhookDeliveryStmt != nil {
+		if cerr := q.getWebhookDeliveryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getWebhookDeliveryStmt: %w", cerr)
+		}
+	}
+	if q.getWebhooksStmt != nil {
+		if cerr := q.getWebhooksStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getWebhooksStmt: %w", cerr)
+		}
+	}
+	if q.importMessageStmt != nil {
+		if cerr := q.importMessageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing importMessageStmt: %w", cerr)
+		}
+	}
+	if q.markAllNotificationsReadStmt != nil {
+		if cerr := q.markAllNotificationsReadStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing markAllNotificationsReadStmt: %w", cerr)
+		}
+	}
+	if q.markNotificationReadStmt != nil {
+		if cerr := q.markNotificationReadStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing markNotificationReadStmt: %w", cerr)
+		}
+	}
+	if q.moveChannelMessagesStmt != nil {
+		if cerr := q.moveChannelMessagesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing moveChannelMessagesStmt: %w", cerr)
+		}
+	}
+	if q.releaseJobStmt != nil {
+		if cerr := q.releaseJobStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing releaseJobStmt: %w", cerr)
+		}
+	}
+	if q.removeReactionStmt != nil {
+		if cerr := q.removeReactionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing removeReactionStmt: %w", cerr)
+		}
+	}
+	if q.requeueDeadJobStmt != nil {
+		if cerr := q.requeueDeadJobStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing requeueDeadJobStmt: %w", cerr)
+		}
+	}
+	if q.resetWebhookDeliveryStmt != nil {
+		if cerr := q.resetWebhookDeliveryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing resetWebhookDeliveryStmt: %w", cerr)
+		}
+	}
+	if q.restoreMessageStmt != nil {
+		if cerr := q.restoreMessageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing restoreMessageStmt: %w", cerr)
+		}
+	}
+	if q.retryJobStmt != nil {
+		if cerr := q.retryJobStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing retryJobStmt: %w", cerr)
+		}
+	}
+	if q.searchMessagesStmt != nil {
+		if cerr := q.searchMessagesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing searchMessagesStmt: %w", cerr)
+		}
+	}
+	if q.setAttachmentThumbnailStmt != nil {
+		if cerr := q.setAttachmentThumbnailStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setAttachmentThumbnailStmt: %w", cerr)
+		}
+	}
+	if q.setNotificationPreferencesStmt != nil {
+		if cerr := q.setNotificationPreferencesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setNotificationPreferencesStmt: %w", cerr)
+		}
+	}
+	if q.setWebhookDeliveryResultStmt != nil {
+		if cerr := q.setWebhookDeliveryResultStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setWebhookDeliveryResultStmt: %w", cerr)
+		}
+	}
+	if q.updateChannelStmt != nil {
+		if cerr := q.updateChannelStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateChannelStmt: %w", cerr)
+		}
+	}
+	if q.updateMessageStmt != nil {
+		if cerr := q.updateMessageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateMessageStmt: %w", cerr)
+		}
+	}
+	if q.updateWebhookStmt != nil {
+		if cerr := q.updateWebhookStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateWebhookStmt: %w", cerr)
+		}
+	}
+	if q.upsertTagStmt != nil {
+		if cerr := q.upsertTagStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertTagStmt: %w", cerr)
+		}
+	}
 	return err
 }
 
@@ -82,17 +610,149 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 }
 
 type Queries struct {
-	db                DBTX
-	tx                *sql.Tx
-	createMessageStmt *sql.Stmt
-	getMessagesStmt   *sql.Stmt
+	db                             DBTX
+	tx                             *sql.Tx
+	addMessageTagStmt              *sql.Stmt
+	addReactionStmt                *sql.Stmt
+	buryJobStmt                    *sql.Stmt
+	claimJobStmt                   *sql.Stmt
+	clearMessageTagsStmt           *sql.Stmt
+	completeJobStmt                *sql.Stmt
+	countJobsByStatusStmt          *sql.Stmt
+	countRepliesStmt               *sql.Stmt
+	countUnreadNotificationsStmt   *sql.Stmt
+	createAttachmentStmt           *sql.Stmt
+	createChannelStmt              *sql.Stmt
+	createIdempotencyKeyStmt       *sql.Stmt
+	createJobStmt                  *sql.Stmt
+	createMessageStmt              *sql.Stmt
+	createNotificationStmt         *sql.Stmt
+	createWebhookStmt              *sql.Stmt
+	createWebhookAttemptStmt       *sql.Stmt
+	createWebhookDeliveryStmt      *sql.Stmt
+	deleteChannelStmt              *sql.Stmt
+	deleteMessageStmt              *sql.Stmt
+	deleteSucceededJobsStmt        *sql.Stmt
+	deleteWebhookStmt              *sql.Stmt
+	deleteWebhookAttemptsStmt      *sql.Stmt
+	deleteWebhookDeliveriesStmt    *sql.Stmt
+	exportMessagesStmt             *sql.Stmt
+	getAttachmentStmt              *sql.Stmt
+	getAttachmentBlobKeysStmt      *sql.Stmt
+	getAttachmentsStmt             *sql.Stmt
+	getChannelStmt                 *sql.Stmt
+	getChannelsStmt                *sql.Stmt
+	getDeletedMessagesStmt         *sql.Stmt
+	getEventWebhooksStmt           *sql.Stmt
+	getIdempotentMessageStmt       *sql.Stmt
+	getJobsStmt                    *sql.Stmt
+	getLatestMessageIDStmt         *sql.Stmt
+	getMessageStmt                 *sql.Stmt
+	getMessageRevisionsStmt        *sql.Stmt
+	getMessagesStmt                *sql.Stmt
+	getMessagesAfterStmt           *sql.Stmt
+	getMessagesByTagStmt           *sql.Stmt
+	getNotificationPreferencesStmt *sql.Stmt
+	getNotificationsStmt           *sql.Stmt
+	getReactionCountsStmt          *sql.Stmt
+	getRepliesStmt                 *sql.Stmt
+	getTagCountsStmt               *sql.Stmt
+	getWebhookStmt                 *sql.Stmt
+	getWebhookAttemptsStmt         *sql.Stmt
+	getWebhookDeliveriesStmt       *sql.Stmt
+	getWebhookDeliveryStmt         *sql.Stmt
+	getWebhooksStmt                *sql.Stmt
+	importMessageStmt              *sql.Stmt
+	markAllNotificationsReadStmt   *sql.Stmt
+	markNotificationReadStmt       *sql.Stmt
+	moveChannelMessagesStmt        *sql.Stmt
+	releaseJobStmt                 *sql.Stmt
+	removeReactionStmt             *sql.Stmt
+	requeueDeadJobStmt             *sql.Stmt
+	resetWebhookDeliveryStmt       *sql.Stmt
+	restoreMessageStmt             *sql.Stmt
+	retryJobStmt                   *sql.Stmt
+	searchMessagesStmt             *sql.Stmt
+	setAttachmentThumbnailStmt     *sql.Stmt
+	setNotificationPreferencesStmt *sql.Stmt
+	setWebhookDeliveryResultStmt   *sql.Stmt
+	updateChannelStmt              *sql.Stmt
+	updateMessageStmt              *sql.Stmt
+	updateWebhookStmt              *sql.Stmt
+	upsertTagStmt                  *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
-		db:                tx,
-		tx:                tx,
-		createMessageStmt: q.createMessageStmt,
-		getMessagesStmt:   q.getMessagesStmt,
+		db:                             tx,
+		tx:                             tx,
+		addMessageTagStmt:              q.addMessageTagStmt,
+		addReactionStmt:                q.addReactionStmt,
+		buryJobStmt:                    q.buryJobStmt,
+		claimJobStmt:                   q.claimJobStmt,
+		clearMessageTagsStmt:           q.clearMessageTagsStmt,
+		completeJobStmt:                q.completeJobStmt,
+		countJobsByStatusStmt:          q.countJobsByStatusStmt,
+		countRepliesStmt:               q.countRepliesStmt,
+		countUnreadNotificationsStmt:   q.countUnreadNotificationsStmt,
+		createAttachmentStmt:           q.createAttachmentStmt,
+		createChannelStmt:              q.createChannelStmt,
+		createIdempotencyKeyStmt:       q.createIdempotencyKeyStmt,
+		createJobStmt:                  q.createJobStmt,
+		createMessageStmt:              q.createMessageStmt,
+		createNotificationStmt:         q.createNotificationStmt,
+		createWebhookStmt:              q.createWebhookStmt,
+		createWebhookAttemptStmt:       q.createWebhookAttemptStmt,
+		createWebhookDeliveryStmt:      q.createWebhookDeliveryStmt,
+		deleteChannelStmt:              q.deleteChannelStmt,
+		deleteMessageStmt:              q.deleteMessageStmt,
+		deleteSucceededJobsStmt:        q.deleteSucceededJobsStmt,
+		deleteWebhookStmt:              q.deleteWebhookStmt,
+		deleteWebhookAttemptsStmt:      q.deleteWebhookAttemptsStmt,
+		deleteWebhookDeliveriesStmt:    q.deleteWebhookDeliveriesStmt,
+		exportMessagesStmt:             q.exportMessagesStmt,
+		getAttachmentStmt:              q.getAttachmentStmt,
+		getAttachmentBlobKeysStmt:      q.getAttachmentBlobKeysStmt,
+		getAttachmentsStmt:             q.getAttachmentsStmt,
+		getChannelStmt:                 q.getChannelStmt,
+		getChannelsStmt:                q.getChannelsStmt,
+		getDeletedMessagesStmt:         q.getDeletedMessagesStmt,
+		getEventWebhooksStmt:           q.getEventWebhooksStmt,
+		getIdempotentMessageStmt:       q.getIdempotentMessageStmt,
+		getJobsStmt:                    q.getJobsStmt,
+		getLatestMessageIDStmt:         q.getLatestMessageIDStmt,
+		getMessageStmt:                 q.getMessageStmt,
+		getMessageRevisionsStmt:        q.getMessageRevisionsStmt,
+		getMessagesStmt:                q.getMessagesStmt,
+		getMessagesAfterStmt:           q.getMessagesAfterStmt,
+		getMessagesByTagStmt:           q.getMessagesByTagStmt,
+		getNotificationPreferencesStmt: q.getNotificationPreferencesStmt,
+		getNotificationsStmt:           q.getNotificationsStmt,
+		getReactionCountsStmt:          q.getReactionCountsStmt,
+		getRepliesStmt:                 q.getRepliesStmt,
+		getTagCountsStmt:               q.getTagCountsStmt,
+		getWebhookStmt:                 q.getWebhookStmt,
+		getWebhookAttemptsStmt:         q.getWebhookAttemptsStmt,
+		getWebhookDeliveriesStmt:       q.getWebhookDeliveriesStmt,
+		getWebhookDeliveryStmt:         q.getWebhookDeliveryStmt,
+		getWebhooksStmt:                q.getWebhooksStmt,
+		importMessageStmt:              q.importMessageStmt,
+		markAllNotificationsReadStmt:   q.markAllNotificationsReadStmt,
+		markNotificationReadStmt:       q.markNotificationReadStmt,
+		moveChannelMessagesStmt:        q.moveChannelMessagesStmt,
+		releaseJobStmt:                 q.releaseJobStmt,
+		removeReactionStmt:             q.removeReactionStmt,
+		requeueDeadJobStmt:             q.requeueDeadJobStmt,
+		resetWebhookDeliveryStmt:       q.resetWebhookDeliveryStmt,
+		restoreMessageStmt:             q.restoreMessageStmt,
+		retryJobStmt:                   q.retryJobStmt,
+		searchMessagesStmt:             q.searchMessagesStmt,
+		setAttachmentThumbnailStmt:     q.setAttachmentThumbnailStmt,
+		setNotificationPreferencesStmt: q.setNotificationPreferencesStmt,
+		setWebhookDeliveryResultStmt:   q.setWebhookDeliveryResultStmt,
+		updateChannelStmt:              q.updateChannelStmt,
+		updateMessageStmt:              q.updateMessageStmt,
+		updateWebhookStmt:              q.updateWebhookStmt,
+		upsertTagStmt:                  q.upsertTagStmt,
 	}
 }
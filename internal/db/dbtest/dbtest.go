@@ -0,0 +1,38 @@
+// Package dbtest opens migrated databases for tests.
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/dunamismax/go-modern-scaffold/db/migrations"
+	"github.com/dunamismax/go-modern-scaffold/internal/db"
+	"github.com/pressly/goose/v3"
+)
+
+// Open opens a new database in a temporary directory of t with the preferred
+// driver and applies the migrations to it. The database is closed when t
+// finishes. Open fails t if the driver lacks FTS5, which the migrations need.
+func Open(t testing.TB) *sql.DB {
+	t.Helper()
+	ctx := context.Background()
+	conn, err := db.Open("", filepath.Join(t.TempDir(), "app.db"))
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	if err := db.CheckFTS5(ctx, conn); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := goose.NewProvider(goose.DialectSQLite3, conn, migrations.FS)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if _, err := p.Up(ctx); err != nil {
+		t.Fatalf("migrate database: %v", err)
+	}
+	return conn
+}
@@ -0,0 +1,7 @@
+//go:build cgo && sqlite_fts5 && !puresqlite
+
+package db
+
+import _ "github.com/mattn/go-sqlite3"
+
+const cgoDriverAvailable = true
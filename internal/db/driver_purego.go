@@ -0,0 +1,5 @@
+//go:build !cgo || !sqlite_fts5 || puresqlite
+
+package db
+
+const cgoDriverAvailable = false
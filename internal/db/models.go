@@ -8,9 +8,135 @@ import (
 	"time"
 )
 
+type Attachment struct {
+	ID           int64     `json:"id"`
+	MessageID    int64     `json:"message_id"`
+	BlobKey      string    `json:"blob_key"`
+	Filename     string    `json:"filename"`
+	ContentType  string    `json:"content_type"`
+	Size         int64     `json:"size"`
+	ThumbnailKey *string   `json:"thumbnail_key"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type Channel struct {
+	ID          int64     `json:"id"`
+	Slug        string    `json:"slug"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedBy   string    `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type IdempotencyKey struct {
+	Author    string    `json:"author"`
+	Key       string    `json:"key"`
+	MessageID int64     `json:"message_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Job struct {
+	ID             int64      `json:"id"`
+	Type           string     `json:"type"`
+	Payload        string     `json:"payload"`
+	Status         string     `json:"status"`
+	Attempts       int64      `json:"attempts"`
+	MaxAttempts    int64      `json:"max_attempts"`
+	RunAt          time.Time  `json:"run_at"`
+	LeaseID        *string    `json:"lease_id"`
+	LeaseExpiresAt *time.Time `json:"lease_expires_at"`
+	LastError      *string    `json:"last_error"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	FinishedAt     *time.Time `json:"finished_at"`
+}
+
 type Message struct {
+	ID        int64      `json:"id"`
+	Body      string     `json:"body"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Author    string     `json:"author"`
+	Editor    string     `json:"editor"`
+	DeletedAt *time.Time `json:"deleted_at"`
+	DeletedBy *string    `json:"deleted_by"`
+	ParentID  *int64     `json:"parent_id"`
+	ChannelID int64      `json:"channel_id"`
+}
+
+type MessageRevision struct {
 	ID        int64     `json:"id"`
+	MessageID int64     `json:"message_id"`
 	Body      string    `json:"body"`
+	Editor    string    `json:"editor"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type MessageTag struct {
+	MessageID int64 `json:"message_id"`
+	TagID     int64 `json:"tag_id"`
+}
+
+type MessagesFt struct {
+	Body string `json:"body"`
+}
+
+type Notification struct {
+	ID        int64      `json:"id"`
+	Recipient string     `json:"recipient"`
+	Kind      string     `json:"kind"`
+	MessageID int64      `json:"message_id"`
+	Actor     string     `json:"actor"`
+	CreatedAt time.Time  `json:"created_at"`
+	ReadAt    *time.Time `json:"read_at"`
+}
+
+type NotificationPreference struct {
+	Username  string `json:"username"`
+	Mentions  bool   `json:"mentions"`
+	Replies   bool   `json:"replies"`
+	Reactions bool   `json:"reactions"`
+}
+
+type Reaction struct {
+	MessageID int64     `json:"message_id"`
+	Username  string    `json:"username"`
+	Emoji     string    `json:"emoji"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Tag struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type Webhook struct {
+	ID        int64     `json:"id"`
+	Url       string    `json:"url"`
+	Secret    string    `json:"secret"`
+	Events    string    `json:"events"`
+	CreatedBy string    `json:"created_by"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
+
+type WebhookAttempt struct {
+	ID           int64     `json:"id"`
+	DeliveryID   int64     `json:"delivery_id"`
+	ResponseCode *int64    `json:"response_code"`
+	Error        *string   `json:"error"`
+	DurationMs   int64     `json:"duration_ms"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type WebhookDelivery struct {
+	ID           int64     `json:"id"`
+	WebhookID    int64     `json:"webhook_id"`
+	Event        string    `json:"event"`
+	Payload      string    `json:"payload"`
+	Status       string    `json:"status"`
+	Attempts     int64     `json:"attempts"`
+	ResponseCode *int64    `json:"response_code"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
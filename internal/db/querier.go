@@ -9,8 +9,83 @@ import (
 )
 
 type Querier interface {
-	CreateMessage(ctx context.Context, body string) error
-	GetMessages(ctx context.Context) ([]Message, error)
+	AddMessageTag(ctx context.Context, arg AddMessageTagParams) error
+	AddReaction(ctx context.Context, arg AddReactionParams) (int64, error)
+	BuryJob(ctx context.Context, arg BuryJobParams) (int64, error)
+	// Leases the job that is due first: a queued one whose time has come or a
+	// running one whose lease expired, as its worker is gone.
+	ClaimJob(ctx context.Context, arg ClaimJobParams) (Job, error)
+	ClearMessageTags(ctx context.Context, messageID int64) error
+	CompleteJob(ctx context.Context, arg CompleteJobParams) (int64, error)
+	CountJobsByStatus(ctx context.Context) ([]CountJobsByStatusRow, error)
+	CountReplies(ctx context.Context, parentID int64) (int64, error)
+	CountUnreadNotifications(ctx context.Context, recipient string) (int64, error)
+	CreateAttachment(ctx context.Context, arg CreateAttachmentParams) (Attachment, error)
+	CreateChannel(ctx context.Context, arg CreateChannelParams) (Channel, error)
+	CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) error
+	CreateJob(ctx context.Context, arg CreateJobParams) (Job, error)
+	CreateMessage(ctx context.Context, arg CreateMessageParams) (Message, error)
+	CreateNotification(ctx context.Context, arg CreateNotificationParams) error
+	CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error)
+	CreateWebhookAttempt(ctx context.Context, arg CreateWebhookAttemptParams) error
+	CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) (WebhookDelivery, error)
+	DeleteChannel(ctx context.Context, id int64) error
+	DeleteMessage(ctx context.Context, arg DeleteMessageParams) (int64, error)
+	DeleteSucceededJobs(ctx context.Context, olderThanSeconds int64) (int64, error)
+	DeleteWebhook(ctx context.Context, id int64) error
+	DeleteWebhookAttempts(ctx context.Context, webhookID int64) error
+	DeleteWebhookDeliveries(ctx context.Context, webhookID int64) error
+	ExportMessages(ctx context.Context, arg ExportMessagesParams) ([]ExportMessagesRow, error)
+	GetAttachment(ctx context.Context, id int64) (Attachment, error)
+	GetAttachmentBlobKeys(ctx context.Context) ([]string, error)
+	GetAttachments(ctx context.Context, messageIds []int64) ([]Attachment, error)
+	GetChannel(ctx context.Context, slug string) (Channel, error)
+	GetChannels(ctx context.Context) ([]Channel, error)
+	GetDeletedMessages(ctx context.Context) ([]Message, error)
+	// Lists the webhooks an event is sent to: those listing it and those
+	// listing no events.
+	GetEventWebhooks(ctx context.Context, event string) ([]Webhook, error)
+	// Deleted messages are returned too: the request that posted one succeeded.
+	GetIdempotentMessage(ctx context.Context, arg GetIdempotentMessageParams) (Message, error)
+	// Lists the latest jobs, of one status unless status is empty.
+	GetJobs(ctx context.Context, arg GetJobsParams) ([]Job, error)
+	GetLatestMessageID(ctx context.Context, channelID int64) (int64, error)
+	GetMessage(ctx context.Context, id int64) (Message, error)
+	GetMessageRevisions(ctx context.Context, messageID int64) ([]MessageRevision, error)
+	GetMessages(ctx context.Context, arg GetMessagesParams) ([]GetMessagesRow, error)
+	GetMessagesAfter(ctx context.Context, arg GetMessagesAfterParams) ([]Message, error)
+	GetMessagesByTag(ctx context.Context, tag string) ([]GetMessagesByTagRow, error)
+	GetNotificationPreferences(ctx context.Context, username string) (NotificationPreference, error)
+	GetNotifications(ctx context.Context, arg GetNotificationsParams) ([]GetNotificationsRow, error)
+	GetReactionCounts(ctx context.Context, arg GetReactionCountsParams) ([]GetReactionCountsRow, error)
+	GetReplies(ctx context.Context, parentID int64) ([]Message, error)
+	GetTagCounts(ctx context.Context, limit int64) ([]GetTagCountsRow, error)
+	GetWebhook(ctx context.Context, id int64) (Webhook, error)
+	GetWebhookAttempts(ctx context.Context, deliveryIds []int64) ([]WebhookAttempt, error)
+	GetWebhookDeliveries(ctx context.Context, arg GetWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	GetWebhookDelivery(ctx context.Context, id int64) (GetWebhookDeliveryRow, error)
+	GetWebhooks(ctx context.Context) ([]Webhook, error)
+	// Timestamps are passed as text in the format of CURRENT_TIMESTAMP, so
+	// imported messages sort among the others whichever driver wrote them.
+	ImportMessage(ctx context.Context, arg ImportMessageParams) (Message, error)
+	MarkAllNotificationsRead(ctx context.Context, recipient string) error
+	MarkNotificationRead(ctx context.Context, arg MarkNotificationReadParams) (int64, error)
+	MoveChannelMessages(ctx context.Context, arg MoveChannelMessagesParams) error
+	// Gives a job back without counting the attempt, as it was interrupted.
+	ReleaseJob(ctx context.Context, arg ReleaseJobParams) (int64, error)
+	RemoveReaction(ctx context.Context, arg RemoveReactionParams) (int64, error)
+	RequeueDeadJob(ctx context.Context, id int64) (Job, error)
+	ResetWebhookDelivery(ctx context.Context, arg ResetWebhookDeliveryParams) (WebhookDelivery, error)
+	RestoreMessage(ctx context.Context, id int64) (int64, error)
+	RetryJob(ctx context.Context, arg RetryJobParams) (int64, error)
+	SearchMessages(ctx context.Context, arg SearchMessagesParams) ([]SearchMessagesRow, error)
+	SetAttachmentThumbnail(ctx context.Context, arg SetAttachmentThumbnailParams) error
+	SetNotificationPreferences(ctx context.Context, arg SetNotificationPreferencesParams) (NotificationPreference, error)
+	SetWebhookDeliveryResult(ctx context.Context, arg SetWebhookDeliveryResultParams) error
+	UpdateChannel(ctx context.Context, arg UpdateChannelParams) (Channel, error)
+	UpdateMessage(ctx context.Context, arg UpdateMessageParams) (Message, error)
+	UpdateWebhook(ctx context.Context, arg UpdateWebhookParams) (Webhook, error)
+	UpsertTag(ctx context.Context, name string) (int64, error)
 }
 
 var _ Querier = (*Queries)(nil)
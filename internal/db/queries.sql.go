@@ -7,23 +7,1274 @@ package db
 
 import (
 	"context"
+	"strings"
+	"time"
 )
 
-const createMessage = `-- name: CreateMessage :exec
-INSERT INTO messages (body) VALUES (?)
+const addMessageTag = `-- name: AddMessageTag :exec
+INSERT INTO message_tags (message_id, tag_id) VALUES (?, ?) ON CONFLICT DO NOTHING
 `
 
-func (q *Queries) CreateMessage(ctx context.Context, body string) error {
-	_, err := q.exec(ctx, q.createMessageStmt, createMessage, body)
+type AddMessageTagParams struct {
+	MessageID int64 `json:"message_id"`
+	TagID     int64 `json:"tag_id"`
+}
+
+func (q *Queries) AddMessageTag(ctx context.Context, arg AddMessageTagParams) error {
+	_, err := q.exec(ctx, q.addMessageTagStmt, addMessageTag, arg.MessageID, arg.TagID)
+	return err
+}
+
+const addReaction = `-- name: AddReaction :execrows
+INSERT INTO reactions (message_id, username, emoji) VALUES (?, ?, ?) ON CONFLICT DO NOTHING
+`
+
+type AddReactionParams struct {
+	MessageID int64  `json:"message_id"`
+	Username  string `json:"username"`
+	Emoji     string `json:"emoji"`
+}
+
+func (q *Queries) AddReaction(ctx context.Context, arg AddReactionParams) (int64, error) {
+	result, err := q.exec(ctx, q.addReactionStmt, addReaction, arg.MessageID, arg.Username, arg.Emoji)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const buryJob = `-- name: BuryJob :execrows
+UPDATE jobs SET
+  status = 'dead',
+  last_error = ?1,
+  lease_id = NULL,
+  lease_expires_at = NULL,
+  updated_at = CURRENT_TIMESTAMP,
+  finished_at = CURRENT_TIMESTAMP
+WHERE id = ?2 AND lease_id = ?3
+`
+
+type BuryJobParams struct {
+	LastError *string `json:"last_error"`
+	ID        int64   `json:"id"`
+	LeaseID   *string `json:"lease_id"`
+}
+
+func (q *Queries) BuryJob(ctx context.Context, arg BuryJobParams) (int64, error) {
+	result, err := q.exec(ctx, q.buryJobStmt, buryJob, arg.LastError, arg.ID, arg.LeaseID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const claimJob = `-- name: ClaimJob :one
+UPDATE jobs SET
+  status = 'running',
+  attempts = attempts + 1,
+  lease_id = ?1,
+  lease_expires_at = datetime('now', CAST(?2 AS INTEGER) || ' seconds'),
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = (
+  SELECT id FROM jobs
+  WHERE (status = 'queued' AND run_at <= datetime('now'))
+     OR (status = 'running' AND lease_expires_at <= datetime('now'))
+  ORDER BY run_at, id
+  LIMIT 1
+)
+RETURNING id, type, payload, status, attempts, max_attempts, run_at, lease_id, lease_expires_at, last_error, created_at, updated_at, finished_at
+`
+
+type ClaimJobParams struct {
+	LeaseID      *string `json:"lease_id"`
+	LeaseSeconds int64   `json:"lease_seconds"`
+}
+
+// Leases the job that is due first: a queued one whose time has come or a
+// running one whose lease expired, as its worker is gone.
+func (q *Queries) ClaimJob(ctx context.Context, arg ClaimJobParams) (Job, error) {
+	row := q.queryRow(ctx, q.claimJobStmt, claimJob, arg.LeaseID, arg.LeaseSeconds)
+	var i Job
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.RunAt,
+		&i.LeaseID,
+		&i.LeaseExpiresAt,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
+
+const clearMessageTags = `-- name: ClearMessageTags :exec
+DELETE FROM message_tags WHERE message_id = ?
+`
+
+func (q *Queries) ClearMessageTags(ctx context.Context, messageID int64) error {
+	_, err := q.exec(ctx, q.clearMessageTagsStmt, clearMessageTags, messageID)
+	return err
+}
+
+const completeJob = `-- name: CompleteJob :execrows
+UPDATE jobs SET
+  status = 'succeeded',
+  lease_id = NULL,
+  lease_expires_at = NULL,
+  updated_at = CURRENT_TIMESTAMP,
+  finished_at = CURRENT_TIMESTAMP
+WHERE id = ?1 AND lease_id = ?2
+`
+
+type CompleteJobParams struct {
+	ID      int64   `json:"id"`
+	LeaseID *string `json:"lease_id"`
+}
+
+func (q *Queries) CompleteJob(ctx context.Context, arg CompleteJobParams) (int64, error) {
+	result, err := q.exec(ctx, q.completeJobStmt, completeJob, arg.ID, arg.LeaseID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const countJobsByStatus = `-- name: CountJobsByStatus :many
+SELECT status, COUNT(*) AS count FROM jobs GROUP BY status
+`
+
+type CountJobsByStatusRow struct {
+	Status string `json:"status"`
+	Count  int64  `json:"count"`
+}
+
+func (q *Queries) CountJobsByStatus(ctx context.Context) ([]CountJobsByStatusRow, error) {
+	rows, err := q.query(ctx, q.countJobsByStatusStmt, countJobsByStatus)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CountJobsByStatusRow{}
+	for rows.Next() {
+		var i CountJobsByStatusRow
+		if err := rows.Scan(&i.Status, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countReplies = `-- name: CountReplies :one
+SELECT COUNT(*) FROM messages WHERE parent_id = CAST(?1 AS INTEGER) AND deleted_at IS NULL
+`
+
+func (q *Queries) CountReplies(ctx context.Context, parentID int64) (int64, error) {
+	row := q.queryRow(ctx, q.countRepliesStmt, countReplies, parentID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countUnreadNotifications = `-- name: CountUnreadNotifications :one
+SELECT COUNT(*)
+FROM notifications
+JOIN messages ON messages.id = notifications.message_id
+WHERE notifications.recipient = ? AND notifications.read_at IS NULL AND messages.deleted_at IS NULL
+`
+
+func (q *Queries) CountUnreadNotifications(ctx context.Context, recipient string) (int64, error) {
+	row := q.queryRow(ctx, q.countUnreadNotificationsStmt, countUnreadNotifications, recipient)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createAttachment = `-- name: CreateAttachment :one
+INSERT INTO attachments (message_id, blob_key, filename, content_type, size, thumbnail_key)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING id, message_id, blob_key, filename, content_type, size, thumbnail_key, created_at
+`
+
+type CreateAttachmentParams struct {
+	MessageID    int64   `json:"message_id"`
+	BlobKey      string  `json:"blob_key"`
+	Filename     string  `json:"filename"`
+	ContentType  string  `json:"content_type"`
+	Size         int64   `json:"size"`
+	ThumbnailKey *string `json:"thumbnail_key"`
+}
+
+func (q *Queries) CreateAttachment(ctx context.Context, arg CreateAttachmentParams) (Attachment, error) {
+	row := q.queryRow(ctx, q.createAttachmentStmt, createAttachment,
+		arg.MessageID,
+		arg.BlobKey,
+		arg.Filename,
+		arg.ContentType,
+		arg.Size,
+		arg.ThumbnailKey,
+	)
+	var i Attachment
+	err := row.Scan(
+		&i.ID,
+		&i.MessageID,
+		&i.BlobKey,
+		&i.Filename,
+		&i.ContentType,
+		&i.Size,
+		&i.ThumbnailKey,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createChannel = `-- name: CreateChannel :one
+INSERT INTO channels (slug, name, description, created_by) VALUES (?, ?, ?, ?) RETURNING id, slug, name, description, created_by, created_at
+`
+
+type CreateChannelParams struct {
+	Slug        string `json:"slug"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	CreatedBy   string `json:"created_by"`
+}
+
+func (q *Queries) CreateChannel(ctx context.Context, arg CreateChannelParams) (Channel, error) {
+	row := q.queryRow(ctx, q.createChannelStmt, createChannel,
+		arg.Slug,
+		arg.Name,
+		arg.Description,
+		arg.CreatedBy,
+	)
+	var i Channel
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.Name,
+		&i.Description,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createIdempotencyKey = `-- name: CreateIdempotencyKey :exec
+INSERT INTO idempotency_keys (author, key, message_id) VALUES (?, ?, ?)
+`
+
+type CreateIdempotencyKeyParams struct {
+	Author    string `json:"author"`
+	Key       string `json:"key"`
+	MessageID int64  `json:"message_id"`
+}
+
+func (q *Queries) CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) error {
+	_, err := q.exec(ctx, q.createIdempotencyKeyStmt, createIdempotencyKey, arg.Author, arg.Key, arg.MessageID)
+	return err
+}
+
+const createJob = `-- name: CreateJob :one
+INSERT INTO jobs (type, payload, max_attempts, run_at)
+VALUES (?1, ?2, ?3, datetime('now', CAST(?4 AS INTEGER) || ' seconds'))
+RETURNING id, type, payload, status, attempts, max_attempts, run_at, lease_id, lease_expires_at, last_error, created_at, updated_at, finished_at
+`
+
+type CreateJobParams struct {
+	Type         string `json:"type"`
+	Payload      string `json:"payload"`
+	MaxAttempts  int64  `json:"max_attempts"`
+	DelaySeconds int64  `json:"delay_seconds"`
+}
+
+func (q *Queries) CreateJob(ctx context.Context, arg CreateJobParams) (Job, error) {
+	row := q.queryRow(ctx, q.createJobStmt, createJob,
+		arg.Type,
+		arg.Payload,
+		arg.MaxAttempts,
+		arg.DelaySeconds,
+	)
+	var i Job
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.RunAt,
+		&i.LeaseID,
+		&i.LeaseExpiresAt,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
+
+const createMessage = `-- name: CreateMessage :one
+INSERT INTO messages (body, author, editor, parent_id, channel_id)
+VALUES (?1, ?2, ?2, ?3, ?4)
+RETURNING id, body, created_at, updated_at, author, editor, deleted_at, deleted_by, parent_id, channel_id
+`
+
+type CreateMessageParams struct {
+	Body      string `json:"body"`
+	Author    string `json:"author"`
+	ParentID  *int64 `json:"parent_id"`
+	ChannelID int64  `json:"channel_id"`
+}
+
+func (q *Queries) CreateMessage(ctx context.Context, arg CreateMessageParams) (Message, error) {
+	row := q.queryRow(ctx, q.createMessageStmt, createMessage,
+		arg.Body,
+		arg.Author,
+		arg.ParentID,
+		arg.ChannelID,
+	)
+	var i Message
+	err := row.Scan(
+		&i.ID,
+		&i.Body,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Author,
+		&i.Editor,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.ParentID,
+		&i.ChannelID,
+	)
+	return i, err
+}
+
+const createNotification = `-- name: CreateNotification :exec
+INSERT INTO notifications (recipient, kind, message_id, actor) VALUES (?, ?, ?, ?)
+`
+
+type CreateNotificationParams struct {
+	Recipient string `json:"recipient"`
+	Kind      string `json:"kind"`
+	MessageID int64  `json:"message_id"`
+	Actor     string `json:"actor"`
+}
+
+func (q *Queries) CreateNotification(ctx context.Context, arg CreateNotificationParams) error {
+	_, err := q.exec(ctx, q.createNotificationStmt, createNotification,
+		arg.Recipient,
+		arg.Kind,
+		arg.MessageID,
+		arg.Actor,
+	)
+	return err
+}
+
+const createWebhook = `-- name: CreateWebhook :one
+INSERT INTO webhooks (url, secret, events, created_by) VALUES (?, ?, ?, ?) RETURNING id, url, secret, events, created_by, created_at, updated_at
+`
+
+type CreateWebhookParams struct {
+	Url       string `json:"url"`
+	Secret    string `json:"secret"`
+	Events    string `json:"events"`
+	CreatedBy string `json:"created_by"`
+}
+
+func (q *Queries) CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error) {
+	row := q.queryRow(ctx, q.createWebhookStmt, createWebhook,
+		arg.Url,
+		arg.Secret,
+		arg.Events,
+		arg.CreatedBy,
+	)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.Secret,
+		&i.Events,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createWebhookAttempt = `-- name: CreateWebhookAttempt :exec
+INSERT INTO webhook_attempts (delivery_id, response_code, error, duration_ms) VALUES (?, ?, ?, ?)
+`
+
+type CreateWebhookAttemptParams struct {
+	DeliveryID   int64   `json:"delivery_id"`
+	ResponseCode *int64  `json:"response_code"`
+	Error        *string `json:"error"`
+	DurationMs   int64   `json:"duration_ms"`
+}
+
+func (q *Queries) CreateWebhookAttempt(ctx context.Context, arg CreateWebhookAttemptParams) error {
+	_, err := q.exec(ctx, q.createWebhookAttemptStmt, createWebhookAttempt,
+		arg.DeliveryID,
+		arg.ResponseCode,
+		arg.Error,
+		arg.DurationMs,
+	)
+	return err
+}
+
+const createWebhookDelivery = `-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (webhook_id, event, payload) VALUES (?, ?, ?) RETURNING id, webhook_id, event, payload, status, attempts, response_code, created_at, updated_at
+`
+
+type CreateWebhookDeliveryParams struct {
+	WebhookID int64  `json:"webhook_id"`
+	Event     string `json:"event"`
+	Payload   string `json:"payload"`
+}
+
+func (q *Queries) CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) (WebhookDelivery, error) {
+	row := q.queryRow(ctx, q.createWebhookDeliveryStmt, createWebhookDelivery, arg.WebhookID, arg.Event, arg.Payload)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.WebhookID,
+		&i.Event,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.ResponseCode,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const deleteChannel = `-- name: DeleteChannel :exec
+DELETE FROM channels WHERE id = ?
+`
+
+func (q *Queries) DeleteChannel(ctx context.Context, id int64) error {
+	_, err := q.exec(ctx, q.deleteChannelStmt, deleteChannel, id)
+	return err
+}
+
+const deleteMessage = `-- name: DeleteMessage :execrows
+UPDATE messages
+SET deleted_at = CURRENT_TIMESTAMP, deleted_by = ?1
+WHERE id = ?2 AND deleted_at IS NULL
+`
+
+type DeleteMessageParams struct {
+	DeletedBy *string `json:"deleted_by"`
+	ID        int64   `json:"id"`
+}
+
+func (q *Queries) DeleteMessage(ctx context.Context, arg DeleteMessageParams) (int64, error) {
+	result, err := q.exec(ctx, q.deleteMessageStmt, deleteMessage, arg.DeletedBy, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteSucceededJobs = `-- name: DeleteSucceededJobs :execrows
+DELETE FROM jobs
+WHERE status = 'succeeded' AND finished_at < datetime('now', CAST(?1 AS INTEGER) || ' seconds')
+`
+
+func (q *Queries) DeleteSucceededJobs(ctx context.Context, olderThanSeconds int64) (int64, error) {
+	result, err := q.exec(ctx, q.deleteSucceededJobsStmt, deleteSucceededJobs, olderThanSeconds)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteWebhook = `-- name: DeleteWebhook :exec
+DELETE FROM webhooks WHERE id = ?
+`
+
+func (q *Queries) DeleteWebhook(ctx context.Context, id int64) error {
+	_, err := q.exec(ctx, q.deleteWebhookStmt, deleteWebhook, id)
+	return err
+}
+
+const deleteWebhookAttempts = `-- name: DeleteWebhookAttempts :exec
+DELETE FROM webhook_attempts
+WHERE delivery_id IN (SELECT id FROM webhook_deliveries WHERE webhook_id = ?)
+`
+
+func (q *Queries) DeleteWebhookAttempts(ctx context.Context, webhookID int64) error {
+	_, err := q.exec(ctx, q.deleteWebhookAttemptsStmt, deleteWebhookAttempts, webhookID)
+	return err
+}
+
+const deleteWebhookDeliveries = `-- name: DeleteWebhookDeliveries :exec
+DELETE FROM webhook_deliveries WHERE webhook_id = ?
+`
+
+func (q *Queries) DeleteWebhookDeliveries(ctx context.Context, webhookID int64) error {
+	_, err := q.exec(ctx, q.deleteWebhookDeliveriesStmt, deleteWebhookDeliveries, webhookID)
 	return err
 }
 
-const getMessages = `-- name: GetMessages :many
-SELECT id, body, created_at, updated_at FROM messages ORDER BY created_at DESC
+const exportMessages = `-- name: ExportMessages :many
+SELECT
+  messages.id,
+  channels.slug AS channel,
+  messages.parent_id,
+  messages.author,
+  messages.body,
+  messages.created_at,
+  messages.updated_at
+FROM messages
+JOIN channels ON channels.id = messages.channel_id
+WHERE messages.deleted_at IS NULL
+  AND messages.id > ?1
+  AND datetime(messages.created_at) >= datetime(CAST(?2 AS TEXT))
+  AND datetime(messages.created_at) < datetime(CAST(?3 AS TEXT))
+ORDER BY messages.id
+LIMIT ?4
+`
+
+type ExportMessagesParams struct {
+	AfterID int64  `json:"after_id"`
+	Since   string `json:"since"`
+	Until   string `json:"until"`
+	Limit   int64  `json:"limit"`
+}
+
+type ExportMessagesRow struct {
+	ID        int64     `json:"id"`
+	Channel   string    `json:"channel"`
+	ParentID  *int64    `json:"parent_id"`
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (q *Queries) ExportMessages(ctx context.Context, arg ExportMessagesParams) ([]ExportMessagesRow, error) {
+	rows, err := q.query(ctx, q.exportMessagesStmt, exportMessages,
+		arg.AfterID,
+		arg.Since,
+		arg.Until,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ExportMessagesRow{}
+	for rows.Next() {
+		var i ExportMessagesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Channel,
+			&i.ParentID,
+			&i.Author,
+			&i.Body,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAttachment = `-- name: GetAttachment :one
+SELECT attachments.id, attachments.message_id, attachments.blob_key, attachments.filename, attachments.content_type, attachments.size, attachments.thumbnail_key, attachments.created_at
+FROM attachments
+JOIN messages ON messages.id = attachments.message_id
+WHERE attachments.id = ? AND messages.deleted_at IS NULL
+`
+
+func (q *Queries) GetAttachment(ctx context.Context, id int64) (Attachment, error) {
+	row := q.queryRow(ctx, q.getAttachmentStmt, getAttachment, id)
+	var i Attachment
+	err := row.Scan(
+		&i.ID,
+		&i.MessageID,
+		&i.BlobKey,
+		&i.Filename,
+		&i.ContentType,
+		&i.Size,
+		&i.ThumbnailKey,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getAttachmentBlobKeys = `-- name: GetAttachmentBlobKeys :many
+SELECT blob_key FROM attachments
+UNION
+SELECT CAST(thumbnail_key AS TEXT) FROM attachments WHERE thumbnail_key IS NOT NULL
+`
+
+func (q *Queries) GetAttachmentBlobKeys(ctx context.Context) ([]string, error) {
+	rows, err := q.query(ctx, q.getAttachmentBlobKeysStmt, getAttachmentBlobKeys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var blob_key string
+		if err := rows.Scan(&blob_key); err != nil {
+			return nil, err
+		}
+		items = append(items, blob_key)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAttachments = `-- name: GetAttachments :many
+SELECT id, message_id, blob_key, filename, content_type, size, thumbnail_key, created_at FROM attachments WHERE message_id IN (/*SLICE:message_ids*/?) ORDER BY id
+`
+
+func (q *Queries) GetAttachments(ctx context.Context, messageIds []int64) ([]Attachment, error) {
+	query := getAttachments
+	var queryParams []interface{}
+	if len(messageIds) > 0 {
+		for _, v := range messageIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:message_ids*/?", strings.Repeat(",?", len(messageIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:message_ids*/?", "NULL", 1)
+	}
+	rows, err := q.query(ctx, nil, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Attachment{}
+	for rows.Next() {
+		var i Attachment
+		if err := rows.Scan(
+			&i.ID,
+			&i.MessageID,
+			&i.BlobKey,
+			&i.Filename,
+			&i.ContentType,
+			&i.Size,
+			&i.ThumbnailKey,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChannel = `-- name: GetChannel :one
+SELECT id, slug, name, description, created_by, created_at FROM channels WHERE slug = ?
+`
+
+func (q *Queries) GetChannel(ctx context.Context, slug string) (Channel, error) {
+	row := q.queryRow(ctx, q.getChannelStmt, getChannel, slug)
+	var i Channel
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.Name,
+		&i.Description,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getChannels = `-- name: GetChannels :many
+SELECT id, slug, name, description, created_by, created_at FROM channels ORDER BY name
+`
+
+func (q *Queries) GetChannels(ctx context.Context) ([]Channel, error) {
+	rows, err := q.query(ctx, q.getChannelsStmt, getChannels)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Channel{}
+	for rows.Next() {
+		var i Channel
+		if err := rows.Scan(
+			&i.ID,
+			&i.Slug,
+			&i.Name,
+			&i.Description,
+			&i.CreatedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDeletedMessages = `-- name: GetDeletedMessages :many
+SELECT id, body, created_at, updated_at, author, editor, deleted_at, deleted_by, parent_id, channel_id FROM messages WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC
+`
+
+func (q *Queries) GetDeletedMessages(ctx context.Context) ([]Message, error) {
+	rows, err := q.query(ctx, q.getDeletedMessagesStmt, getDeletedMessages)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Message{}
+	for rows.Next() {
+		var i Message
+		if err := rows.Scan(
+			&i.ID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Author,
+			&i.Editor,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.ParentID,
+			&i.ChannelID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEventWebhooks = `-- name: GetEventWebhooks :many
+SELECT id, url, secret, events, created_by, created_at, updated_at FROM webhooks
+WHERE events = '' OR instr(',' || events || ',', ',' || CAST(?1 AS TEXT) || ',') > 0
+ORDER BY id
+`
+
+// Lists the webhooks an event is sent to: those listing it and those
+// listing no events.
+func (q *Queries) GetEventWebhooks(ctx context.Context, event string) ([]Webhook, error) {
+	rows, err := q.query(ctx, q.getEventWebhooksStmt, getEventWebhooks, event)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Webhook{}
+	for rows.Next() {
+		var i Webhook
+		if err := rows.Scan(
+			&i.ID,
+			&i.Url,
+			&i.Secret,
+			&i.Events,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getIdempotentMessage = `-- name: GetIdempotentMessage :one
+SELECT messages.id, messages.body, messages.created_at, messages.updated_at, messages.author, messages.editor, messages.deleted_at, messages.deleted_by, messages.parent_id, messages.channel_id
+FROM idempotency_keys
+JOIN messages ON messages.id = idempotency_keys.message_id
+WHERE idempotency_keys.author = ? AND idempotency_keys.key = ?
+`
+
+type GetIdempotentMessageParams struct {
+	Author string `json:"author"`
+	Key    string `json:"key"`
+}
+
+// Deleted messages are returned too: the request that posted one succeeded.
+func (q *Queries) GetIdempotentMessage(ctx context.Context, arg GetIdempotentMessageParams) (Message, error) {
+	row := q.queryRow(ctx, q.getIdempotentMessageStmt, getIdempotentMessage, arg.Author, arg.Key)
+	var i Message
+	err := row.Scan(
+		&i.ID,
+		&i.Body,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Author,
+		&i.Editor,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.ParentID,
+		&i.ChannelID,
+	)
+	return i, err
+}
+
+const getJobs = `-- name: GetJobs :many
+SELECT id, type, payload, status, attempts, max_attempts, run_at, lease_id, lease_expires_at, last_error, created_at, updated_at, finished_at FROM jobs
+WHERE CAST(?1 AS TEXT) = '' OR status = ?1
+ORDER BY id DESC
+LIMIT ?2
+`
+
+type GetJobsParams struct {
+	Status string `json:"status"`
+	Limit  int64  `json:"limit"`
+}
+
+// Lists the latest jobs, of one status unless status is empty.
+func (q *Queries) GetJobs(ctx context.Context, arg GetJobsParams) ([]Job, error) {
+	rows, err := q.query(ctx, q.getJobsStmt, getJobs, arg.Status, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Job{}
+	for rows.Next() {
+		var i Job
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.MaxAttempts,
+			&i.RunAt,
+			&i.LeaseID,
+			&i.LeaseExpiresAt,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.FinishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLatestMessageID = `-- name: GetLatestMessageID :one
+SELECT CAST(COALESCE(MAX(id), 0) AS INTEGER) FROM messages WHERE channel_id = ?
+`
+
+func (q *Queries) GetLatestMessageID(ctx context.Context, channelID int64) (int64, error) {
+	row := q.queryRow(ctx, q.getLatestMessageIDStmt, getLatestMessageID, channelID)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const getMessage = `-- name: GetMessage :one
+SELECT id, body, created_at, updated_at, author, editor, deleted_at, deleted_by, parent_id, channel_id FROM messages WHERE id = ? AND deleted_at IS NULL
+`
+
+func (q *Queries) GetMessage(ctx context.Context, id int64) (Message, error) {
+	row := q.queryRow(ctx, q.getMessageStmt, getMessage, id)
+	var i Message
+	err := row.Scan(
+		&i.ID,
+		&i.Body,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Author,
+		&i.Editor,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.ParentID,
+		&i.ChannelID,
+	)
+	return i, err
+}
+
+const getMessageRevisions = `-- name: GetMessageRevisions :many
+SELECT id, message_id, body, editor, created_at FROM message_revisions WHERE message_id = ? ORDER BY id DESC
+`
+
+func (q *Queries) GetMessageRevisions(ctx context.Context, messageID int64) ([]MessageRevision, error) {
+	rows, err := q.query(ctx, q.getMessageRevisionsStmt, getMessageRevisions, messageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []MessageRevision{}
+	for rows.Next() {
+		var i MessageRevision
+		if err := rows.Scan(
+			&i.ID,
+			&i.MessageID,
+			&i.Body,
+			&i.Editor,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMessages = `-- name: GetMessages :many
+SELECT
+  messages.id, messages.body, messages.created_at, messages.updated_at, messages.author, messages.editor, messages.deleted_at, messages.deleted_by, messages.parent_id, messages.channel_id,
+  (SELECT COUNT(*) FROM messages AS replies WHERE replies.parent_id = messages.id AND replies.deleted_at IS NULL) AS reply_count
+FROM messages
+WHERE messages.channel_id = ?1 AND messages.parent_id IS NULL AND messages.deleted_at IS NULL
+  AND messages.id < ?2
+ORDER BY messages.id DESC
+LIMIT ?3
+`
+
+type GetMessagesParams struct {
+	ChannelID int64 `json:"channel_id"`
+	BeforeID  int64 `json:"before_id"`
+	Limit     int64 `json:"limit"`
+}
+
+type GetMessagesRow struct {
+	Message    Message `json:"message"`
+	ReplyCount int64   `json:"reply_count"`
+}
+
+func (q *Queries) GetMessages(ctx context.Context, arg GetMessagesParams) ([]GetMessagesRow, error) {
+	rows, err := q.query(ctx, q.getMessagesStmt, getMessages, arg.ChannelID, arg.BeforeID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetMessagesRow{}
+	for rows.Next() {
+		var i GetMessagesRow
+		if err := rows.Scan(
+			&i.Message.ID,
+			&i.Message.Body,
+			&i.Message.CreatedAt,
+			&i.Message.UpdatedAt,
+			&i.Message.Author,
+			&i.Message.Editor,
+			&i.Message.DeletedAt,
+			&i.Message.DeletedBy,
+			&i.Message.ParentID,
+			&i.Message.ChannelID,
+			&i.ReplyCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMessagesAfter = `-- name: GetMessagesAfter :many
+SELECT id, body, created_at, updated_at, author, editor, deleted_at, deleted_by, parent_id, channel_id FROM messages
+WHERE channel_id = ?1 AND id > ?2 AND deleted_at IS NULL
+ORDER BY id
+LIMIT ?3
+`
+
+type GetMessagesAfterParams struct {
+	ChannelID int64 `json:"channel_id"`
+	AfterID   int64 `json:"after_id"`
+	Limit     int64 `json:"limit"`
+}
+
+func (q *Queries) GetMessagesAfter(ctx context.Context, arg GetMessagesAfterParams) ([]Message, error) {
+	rows, err := q.query(ctx, q.getMessagesAfterStmt, getMessagesAfter, arg.ChannelID, arg.AfterID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Message{}
+	for rows.Next() {
+		var i Message
+		if err := rows.Scan(
+			&i.ID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Author,
+			&i.Editor,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.ParentID,
+			&i.ChannelID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMessagesByTag = `-- name: GetMessagesByTag :many
+SELECT
+  messages.id, messages.body, messages.created_at, messages.updated_at, messages.author, messages.editor, messages.deleted_at, messages.deleted_by, messages.parent_id, messages.channel_id,
+  (SELECT COUNT(*) FROM messages AS replies WHERE replies.parent_id = messages.id AND replies.deleted_at IS NULL) AS reply_count
+FROM messages
+JOIN message_tags ON message_tags.message_id = messages.id
+JOIN tags ON tags.id = message_tags.tag_id
+WHERE tags.name = ?1 AND messages.deleted_at IS NULL
+ORDER BY messages.created_at DESC
+`
+
+type GetMessagesByTagRow struct {
+	Message    Message `json:"message"`
+	ReplyCount int64   `json:"reply_count"`
+}
+
+func (q *Queries) GetMessagesByTag(ctx context.Context, tag string) ([]GetMessagesByTagRow, error) {
+	rows, err := q.query(ctx, q.getMessagesByTagStmt, getMessagesByTag, tag)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetMessagesByTagRow{}
+	for rows.Next() {
+		var i GetMessagesByTagRow
+		if err := rows.Scan(
+			&i.Message.ID,
+			&i.Message.Body,
+			&i.Message.CreatedAt,
+			&i.Message.UpdatedAt,
+			&i.Message.Author,
+			&i.Message.Editor,
+			&i.Message.DeletedAt,
+			&i.Message.DeletedBy,
+			&i.Message.ParentID,
+			&i.Message.ChannelID,
+			&i.ReplyCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNotificationPreferences = `-- name: GetNotificationPreferences :one
+SELECT username, mentions, replies, reactions FROM notification_preferences WHERE username = ?
+`
+
+func (q *Queries) GetNotificationPreferences(ctx context.Context, username string) (NotificationPreference, error) {
+	row := q.queryRow(ctx, q.getNotificationPreferencesStmt, getNotificationPreferences, username)
+	var i NotificationPreference
+	err := row.Scan(
+		&i.Username,
+		&i.Mentions,
+		&i.Replies,
+		&i.Reactions,
+	)
+	return i, err
+}
+
+const getNotifications = `-- name: GetNotifications :many
+SELECT
+  notifications.id, notifications.recipient, notifications.kind, notifications.message_id, notifications.actor, notifications.created_at, notifications.read_at,
+  messages.body,
+  CAST(COALESCE(messages.parent_id, messages.id) AS INTEGER) AS root_id,
+  channels.slug AS channel_slug
+FROM notifications
+JOIN messages ON messages.id = notifications.message_id
+JOIN channels ON channels.id = messages.channel_id
+WHERE notifications.recipient = ? AND messages.deleted_at IS NULL
+ORDER BY notifications.created_at DESC, notifications.id DESC
+LIMIT ?
+`
+
+type GetNotificationsParams struct {
+	Recipient string `json:"recipient"`
+	Limit     int64  `json:"limit"`
+}
+
+type GetNotificationsRow struct {
+	Notification Notification `json:"notification"`
+	Body         string       `json:"body"`
+	RootID       int64        `json:"root_id"`
+	ChannelSlug  string       `json:"channel_slug"`
+}
+
+func (q *Queries) GetNotifications(ctx context.Context, arg GetNotificationsParams) ([]GetNotificationsRow, error) {
+	rows, err := q.query(ctx, q.getNotificationsStmt, getNotifications, arg.Recipient, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetNotificationsRow{}
+	for rows.Next() {
+		var i GetNotificationsRow
+		if err := rows.Scan(
+			&i.Notification.ID,
+			&i.Notification.Recipient,
+			&i.Notification.Kind,
+			&i.Notification.MessageID,
+			&i.Notification.Actor,
+			&i.Notification.CreatedAt,
+			&i.Notification.ReadAt,
+			&i.Body,
+			&i.RootID,
+			&i.ChannelSlug,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getReactionCounts = `-- name: GetReactionCounts :many
+SELECT
+  message_id,
+  emoji,
+  COUNT(*) AS count,
+  CAST(MAX(username = ?1) AS BOOLEAN) AS reacted
+FROM reactions
+WHERE message_id IN (/*SLICE:message_ids*/?)
+GROUP BY message_id, emoji
+ORDER BY message_id, MIN(created_at)
+`
+
+type GetReactionCountsParams struct {
+	Username   string  `json:"username"`
+	MessageIds []int64 `json:"message_ids"`
+}
+
+type GetReactionCountsRow struct {
+	MessageID int64  `json:"message_id"`
+	Emoji     string `json:"emoji"`
+	Count     int64  `json:"count"`
+	Reacted   bool   `json:"reacted"`
+}
+
+func (q *Queries) GetReactionCounts(ctx context.Context, arg GetReactionCountsParams) ([]GetReactionCountsRow, error) {
+	query := getReactionCounts
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.Username)
+	if len(arg.MessageIds) > 0 {
+		for _, v := range arg.MessageIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:message_ids*/?", strings.Repeat(",?", len(arg.MessageIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:message_ids*/?", "NULL", 1)
+	}
+	rows, err := q.query(ctx, nil, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetReactionCountsRow{}
+	for rows.Next() {
+		var i GetReactionCountsRow
+		if err := rows.Scan(
+			&i.MessageID,
+			&i.Emoji,
+			&i.Count,
+			&i.Reacted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getReplies = `-- name: GetReplies :many
+SELECT id, body, created_at, updated_at, author, editor, deleted_at, deleted_by, parent_id, channel_id FROM messages WHERE parent_id = CAST(?1 AS INTEGER) AND deleted_at IS NULL ORDER BY created_at ASC
 `
 
-func (q *Queries) GetMessages(ctx context.Context) ([]Message, error) {
-	rows, err := q.query(ctx, q.getMessagesStmt, getMessages)
+func (q *Queries) GetReplies(ctx context.Context, parentID int64) ([]Message, error) {
+	rows, err := q.query(ctx, q.getRepliesStmt, getReplies, parentID)
 	if err != nil {
 		return nil, err
 	}
@@ -36,6 +1287,512 @@ func (q *Queries) GetMessages(ctx context.Context) ([]Message, error) {
 			&i.Body,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Author,
+			&i.Editor,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.ParentID,
+			&i.ChannelID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTagCounts = `-- name: GetTagCounts :many
+SELECT tags.name, COUNT(*) AS count
+FROM tags
+JOIN message_tags ON message_tags.tag_id = tags.id
+JOIN messages ON messages.id = message_tags.message_id
+WHERE messages.deleted_at IS NULL
+GROUP BY tags.id
+ORDER BY count DESC, tags.name
+LIMIT ?
+`
+
+type GetTagCountsRow struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+func (q *Queries) GetTagCounts(ctx context.Context, limit int64) ([]GetTagCountsRow, error) {
+	rows, err := q.query(ctx, q.getTagCountsStmt, getTagCounts, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetTagCountsRow{}
+	for rows.Next() {
+		var i GetTagCountsRow
+		if err := rows.Scan(&i.Name, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWebhook = `-- name: GetWebhook :one
+SELECT id, url, secret, events, created_by, created_at, updated_at FROM webhooks WHERE id = ?
+`
+
+func (q *Queries) GetWebhook(ctx context.Context, id int64) (Webhook, error) {
+	row := q.queryRow(ctx, q.getWebhookStmt, getWebhook, id)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.Secret,
+		&i.Events,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getWebhookAttempts = `-- name: GetWebhookAttempts :many
+SELECT id, delivery_id, response_code, error, duration_ms, created_at FROM webhook_attempts WHERE delivery_id IN (/*SLICE:delivery_ids*/?) ORDER BY id
+`
+
+func (q *Queries) GetWebhookAttempts(ctx context.Context, deliveryIds []int64) ([]WebhookAttempt, error) {
+	query := getWebhookAttempts
+	var queryParams []interface{}
+	if len(deliveryIds) > 0 {
+		for _, v := range deliveryIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:delivery_ids*/?", strings.Repeat(",?", len(deliveryIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:delivery_ids*/?", "NULL", 1)
+	}
+	rows, err := q.query(ctx, nil, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookAttempt{}
+	for rows.Next() {
+		var i WebhookAttempt
+		if err := rows.Scan(
+			&i.ID,
+			&i.DeliveryID,
+			&i.ResponseCode,
+			&i.Error,
+			&i.DurationMs,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWebhookDeliveries = `-- name: GetWebhookDeliveries :many
+SELECT id, webhook_id, event, payload, status, attempts, response_code, created_at, updated_at FROM webhook_deliveries WHERE webhook_id = ? ORDER BY id DESC LIMIT ?
+`
+
+type GetWebhookDeliveriesParams struct {
+	WebhookID int64 `json:"webhook_id"`
+	Limit     int64 `json:"limit"`
+}
+
+func (q *Queries) GetWebhookDeliveries(ctx context.Context, arg GetWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	rows, err := q.query(ctx, q.getWebhookDeliveriesStmt, getWebhookDeliveries, arg.WebhookID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookDelivery{}
+	for rows.Next() {
+		var i WebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.WebhookID,
+			&i.Event,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.ResponseCode,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWebhookDelivery = `-- name: GetWebhookDelivery :one
+SELECT webhook_deliveries.id, webhook_deliveries.webhook_id, webhook_deliveries.event, webhook_deliveries.payload, webhook_deliveries.status, webhook_deliveries.attempts, webhook_deliveries.response_code, webhook_deliveries.created_at, webhook_deliveries.updated_at, webhooks.url, webhooks.secret
+FROM webhook_deliveries
+JOIN webhooks ON webhooks.id = webhook_deliveries.webhook_id
+WHERE webhook_deliveries.id = ?
+`
+
+type GetWebhookDeliveryRow struct {
+	WebhookDelivery WebhookDelivery `json:"webhook_delivery"`
+	Url             string          `json:"url"`
+	Secret          string          `json:"secret"`
+}
+
+func (q *Queries) GetWebhookDelivery(ctx context.Context, id int64) (GetWebhookDeliveryRow, error) {
+	row := q.queryRow(ctx, q.getWebhookDeliveryStmt, getWebhookDelivery, id)
+	var i GetWebhookDeliveryRow
+	err := row.Scan(
+		&i.WebhookDelivery.ID,
+		&i.WebhookDelivery.WebhookID,
+		&i.WebhookDelivery.Event,
+		&i.WebhookDelivery.Payload,
+		&i.WebhookDelivery.Status,
+		&i.WebhookDelivery.Attempts,
+		&i.WebhookDelivery.ResponseCode,
+		&i.WebhookDelivery.CreatedAt,
+		&i.WebhookDelivery.UpdatedAt,
+		&i.Url,
+		&i.Secret,
+	)
+	return i, err
+}
+
+const getWebhooks = `-- name: GetWebhooks :many
+SELECT id, url, secret, events, created_by, created_at, updated_at FROM webhooks ORDER BY id
+`
+
+func (q *Queries) GetWebhooks(ctx context.Context) ([]Webhook, error) {
+	rows, err := q.query(ctx, q.getWebhooksStmt, getWebhooks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Webhook{}
+	for rows.Next() {
+		var i Webhook
+		if err := rows.Scan(
+			&i.ID,
+			&i.Url,
+			&i.Secret,
+			&i.Events,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const importMessage = `-- name: ImportMessage :one
+INSERT INTO messages (body, author, editor, parent_id, channel_id, created_at, updated_at)
+VALUES (?1, ?2, ?2, ?3, ?4, CAST(?5 AS TEXT), CAST(?6 AS TEXT))
+RETURNING id, body, created_at, updated_at, author, editor, deleted_at, deleted_by, parent_id, channel_id
+`
+
+type ImportMessageParams struct {
+	Body      string `json:"body"`
+	Author    string `json:"author"`
+	ParentID  *int64 `json:"parent_id"`
+	ChannelID int64  `json:"channel_id"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// Timestamps are passed as text in the format of CURRENT_TIMESTAMP, so
+// imported messages sort among the others whichever driver wrote them.
+func (q *Queries) ImportMessage(ctx context.Context, arg ImportMessageParams) (Message, error) {
+	row := q.queryRow(ctx, q.importMessageStmt, importMessage,
+		arg.Body,
+		arg.Author,
+		arg.ParentID,
+		arg.ChannelID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+	)
+	var i Message
+	err := row.Scan(
+		&i.ID,
+		&i.Body,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Author,
+		&i.Editor,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.ParentID,
+		&i.ChannelID,
+	)
+	return i, err
+}
+
+const markAllNotificationsRead = `-- name: MarkAllNotificationsRead :exec
+UPDATE notifications SET read_at = CURRENT_TIMESTAMP WHERE recipient = ? AND read_at IS NULL
+`
+
+func (q *Queries) MarkAllNotificationsRead(ctx context.Context, recipient string) error {
+	_, err := q.exec(ctx, q.markAllNotificationsReadStmt, markAllNotificationsRead, recipient)
+	return err
+}
+
+const markNotificationRead = `-- name: MarkNotificationRead :execrows
+UPDATE notifications SET read_at = COALESCE(read_at, CURRENT_TIMESTAMP) WHERE id = ? AND recipient = ?
+`
+
+type MarkNotificationReadParams struct {
+	ID        int64  `json:"id"`
+	Recipient string `json:"recipient"`
+}
+
+func (q *Queries) MarkNotificationRead(ctx context.Context, arg MarkNotificationReadParams) (int64, error) {
+	result, err := q.exec(ctx, q.markNotificationReadStmt, markNotificationRead, arg.ID, arg.Recipient)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const moveChannelMessages = `-- name: MoveChannelMessages :exec
+UPDATE messages SET channel_id = ?1 WHERE channel_id = ?2
+`
+
+type MoveChannelMessagesParams struct {
+	ToChannelID   int64 `json:"to_channel_id"`
+	FromChannelID int64 `json:"from_channel_id"`
+}
+
+func (q *Queries) MoveChannelMessages(ctx context.Context, arg MoveChannelMessagesParams) error {
+	_, err := q.exec(ctx, q.moveChannelMessagesStmt, moveChannelMessages, arg.ToChannelID, arg.FromChannelID)
+	return err
+}
+
+const releaseJob = `-- name: ReleaseJob :execrows
+UPDATE jobs SET
+  status = 'queued',
+  attempts = attempts - 1,
+  lease_id = NULL,
+  lease_expires_at = NULL,
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?1 AND lease_id = ?2
+`
+
+type ReleaseJobParams struct {
+	ID      int64   `json:"id"`
+	LeaseID *string `json:"lease_id"`
+}
+
+// Gives a job back without counting the attempt, as it was interrupted.
+func (q *Queries) ReleaseJob(ctx context.Context, arg ReleaseJobParams) (int64, error) {
+	result, err := q.exec(ctx, q.releaseJobStmt, releaseJob, arg.ID, arg.LeaseID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const removeReaction = `-- name: RemoveReaction :execrows
+DELETE FROM reactions WHERE message_id = ? AND username = ? AND emoji = ?
+`
+
+type RemoveReactionParams struct {
+	MessageID int64  `json:"message_id"`
+	Username  string `json:"username"`
+	Emoji     string `json:"emoji"`
+}
+
+func (q *Queries) RemoveReaction(ctx context.Context, arg RemoveReactionParams) (int64, error) {
+	result, err := q.exec(ctx, q.removeReactionStmt, removeReaction, arg.MessageID, arg.Username, arg.Emoji)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const requeueDeadJob = `-- name: RequeueDeadJob :one
+UPDATE jobs SET
+  status = 'queued',
+  attempts = 0,
+  run_at = CURRENT_TIMESTAMP,
+  updated_at = CURRENT_TIMESTAMP,
+  finished_at = NULL
+WHERE id = ? AND status = 'dead'
+RETURNING id, type, payload, status, attempts, max_attempts, run_at, lease_id, lease_expires_at, last_error, created_at, updated_at, finished_at
+`
+
+func (q *Queries) RequeueDeadJob(ctx context.Context, id int64) (Job, error) {
+	row := q.queryRow(ctx, q.requeueDeadJobStmt, requeueDeadJob, id)
+	var i Job
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.RunAt,
+		&i.LeaseID,
+		&i.LeaseExpiresAt,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
+
+const resetWebhookDelivery = `-- name: ResetWebhookDelivery :one
+UPDATE webhook_deliveries SET status = 'pending', updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND webhook_id = ?
+RETURNING id, webhook_id, event, payload, status, attempts, response_code, created_at, updated_at
+`
+
+type ResetWebhookDeliveryParams struct {
+	ID        int64 `json:"id"`
+	WebhookID int64 `json:"webhook_id"`
+}
+
+func (q *Queries) ResetWebhookDelivery(ctx context.Context, arg ResetWebhookDeliveryParams) (WebhookDelivery, error) {
+	row := q.queryRow(ctx, q.resetWebhookDeliveryStmt, resetWebhookDelivery, arg.ID, arg.WebhookID)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.WebhookID,
+		&i.Event,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.ResponseCode,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const restoreMessage = `-- name: RestoreMessage :execrows
+UPDATE messages SET deleted_at = NULL, deleted_by = NULL WHERE id = ? AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) RestoreMessage(ctx context.Context, id int64) (int64, error) {
+	result, err := q.exec(ctx, q.restoreMessageStmt, restoreMessage, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const retryJob = `-- name: RetryJob :execrows
+UPDATE jobs SET
+  status = 'queued',
+  run_at = datetime('now', CAST(?1 AS INTEGER) || ' seconds'),
+  last_error = ?2,
+  lease_id = NULL,
+  lease_expires_at = NULL,
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?3 AND lease_id = ?4
+`
+
+type RetryJobParams struct {
+	DelaySeconds int64   `json:"delay_seconds"`
+	LastError    *string `json:"last_error"`
+	ID           int64   `json:"id"`
+	LeaseID      *string `json:"lease_id"`
+}
+
+func (q *Queries) RetryJob(ctx context.Context, arg RetryJobParams) (int64, error) {
+	result, err := q.exec(ctx, q.retryJobStmt, retryJob,
+		arg.DelaySeconds,
+		arg.LastError,
+		arg.ID,
+		arg.LeaseID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const searchMessages = `-- name: SearchMessages :many
+SELECT
+  messages.id, messages.body, messages.created_at, messages.updated_at, messages.author, messages.editor, messages.deleted_at, messages.deleted_by, messages.parent_id, messages.channel_id,
+  (SELECT COUNT(*) FROM messages AS replies WHERE replies.parent_id = messages.id AND replies.deleted_at IS NULL) AS reply_count,
+  CAST(snippet(messages_fts, 0, char(2), char(3), char(8230), 24) AS TEXT) AS snippet
+FROM messages_fts
+JOIN messages ON messages.id = messages_fts.rowid
+WHERE messages_fts.body MATCH ?1
+  AND messages.channel_id = ?2
+  AND messages.deleted_at IS NULL
+ORDER BY bm25(messages_fts), messages.created_at DESC
+LIMIT ?3
+`
+
+type SearchMessagesParams struct {
+	Query     string `json:"query"`
+	ChannelID int64  `json:"channel_id"`
+	Limit     int64  `json:"limit"`
+}
+
+type SearchMessagesRow struct {
+	Message    Message `json:"message"`
+	ReplyCount int64   `json:"reply_count"`
+	Snippet    string  `json:"snippet"`
+}
+
+func (q *Queries) SearchMessages(ctx context.Context, arg SearchMessagesParams) ([]SearchMessagesRow, error) {
+	rows, err := q.query(ctx, q.searchMessagesStmt, searchMessages, arg.Query, arg.ChannelID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SearchMessagesRow{}
+	for rows.Next() {
+		var i SearchMessagesRow
+		if err := rows.Scan(
+			&i.Message.ID,
+			&i.Message.Body,
+			&i.Message.CreatedAt,
+			&i.Message.UpdatedAt,
+			&i.Message.Author,
+			&i.Message.Editor,
+			&i.Message.DeletedAt,
+			&i.Message.DeletedBy,
+			&i.Message.ParentID,
+			&i.Message.ChannelID,
+			&i.ReplyCount,
+			&i.Snippet,
 		); err != nil {
 			return nil, err
 		}
@@ -49,3 +1806,168 @@ func (q *Queries) GetMessages(ctx context.Context) ([]Message, error) {
 	}
 	return items, nil
 }
+
+const setAttachmentThumbnail = `-- name: SetAttachmentThumbnail :exec
+UPDATE attachments SET thumbnail_key = ? WHERE id = ?
+`
+
+type SetAttachmentThumbnailParams struct {
+	ThumbnailKey *string `json:"thumbnail_key"`
+	ID           int64   `json:"id"`
+}
+
+func (q *Queries) SetAttachmentThumbnail(ctx context.Context, arg SetAttachmentThumbnailParams) error {
+	_, err := q.exec(ctx, q.setAttachmentThumbnailStmt, setAttachmentThumbnail, arg.ThumbnailKey, arg.ID)
+	return err
+}
+
+const setNotificationPreferences = `-- name: SetNotificationPreferences :one
+INSERT INTO notification_preferences (username, mentions, replies, reactions)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (username) DO UPDATE SET
+  mentions = excluded.mentions,
+  replies = excluded.replies,
+  reactions = excluded.reactions
+RETURNING username, mentions, replies, reactions
+`
+
+type SetNotificationPreferencesParams struct {
+	Username  string `json:"username"`
+	Mentions  bool   `json:"mentions"`
+	Replies   bool   `json:"replies"`
+	Reactions bool   `json:"reactions"`
+}
+
+func (q *Queries) SetNotificationPreferences(ctx context.Context, arg SetNotificationPreferencesParams) (NotificationPreference, error) {
+	row := q.queryRow(ctx, q.setNotificationPreferencesStmt, setNotificationPreferences,
+		arg.Username,
+		arg.Mentions,
+		arg.Replies,
+		arg.Reactions,
+	)
+	var i NotificationPreference
+	err := row.Scan(
+		&i.Username,
+		&i.Mentions,
+		&i.Replies,
+		&i.Reactions,
+	)
+	return i, err
+}
+
+const setWebhookDeliveryResult = `-- name: SetWebhookDeliveryResult :exec
+UPDATE webhook_deliveries SET
+  status = ?,
+  response_code = ?,
+  attempts = attempts + 1,
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type SetWebhookDeliveryResultParams struct {
+	Status       string `json:"status"`
+	ResponseCode *int64 `json:"response_code"`
+	ID           int64  `json:"id"`
+}
+
+func (q *Queries) SetWebhookDeliveryResult(ctx context.Context, arg SetWebhookDeliveryResultParams) error {
+	_, err := q.exec(ctx, q.setWebhookDeliveryResultStmt, setWebhookDeliveryResult, arg.Status, arg.ResponseCode, arg.ID)
+	return err
+}
+
+const updateChannel = `-- name: UpdateChannel :one
+UPDATE channels SET name = ?, description = ? WHERE id = ? RETURNING id, slug, name, description, created_by, created_at
+`
+
+type UpdateChannelParams struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	ID          int64  `json:"id"`
+}
+
+func (q *Queries) UpdateChannel(ctx context.Context, arg UpdateChannelParams) (Channel, error) {
+	row := q.queryRow(ctx, q.updateChannelStmt, updateChannel, arg.Name, arg.Description, arg.ID)
+	var i Channel
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.Name,
+		&i.Description,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const updateMessage = `-- name: UpdateMessage :one
+UPDATE messages
+SET body = ?1, editor = ?2, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?3 AND deleted_at IS NULL
+RETURNING id, body, created_at, updated_at, author, editor, deleted_at, deleted_by, parent_id, channel_id
+`
+
+type UpdateMessageParams struct {
+	Body   string `json:"body"`
+	Editor string `json:"editor"`
+	ID     int64  `json:"id"`
+}
+
+func (q *Queries) UpdateMessage(ctx context.Context, arg UpdateMessageParams) (Message, error) {
+	row := q.queryRow(ctx, q.updateMessageStmt, updateMessage, arg.Body, arg.Editor, arg.ID)
+	var i Message
+	err := row.Scan(
+		&i.ID,
+		&i.Body,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Author,
+		&i.Editor,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.ParentID,
+		&i.ChannelID,
+	)
+	return i, err
+}
+
+const updateWebhook = `-- name: UpdateWebhook :one
+UPDATE webhooks SET url = ?, secret = ?, events = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING id, url, secret, events, created_by, created_at, updated_at
+`
+
+type UpdateWebhookParams struct {
+	Url    string `json:"url"`
+	Secret string `json:"secret"`
+	Events string `json:"events"`
+	ID     int64  `json:"id"`
+}
+
+func (q *Queries) UpdateWebhook(ctx context.Context, arg UpdateWebhookParams) (Webhook, error) {
+	row := q.queryRow(ctx, q.updateWebhookStmt, updateWebhook,
+		arg.Url,
+		arg.Secret,
+		arg.Events,
+		arg.ID,
+	)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.Secret,
+		&i.Events,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertTag = `-- name: UpsertTag :one
+INSERT INTO tags (name) VALUES (?) ON CONFLICT (name) DO UPDATE SET name = excluded.name RETURNING id
+`
+
+func (q *Queries) UpsertTag(ctx context.Context, name string) (int64, error) {
+	row := q.queryRow(ctx, q.upsertTagStmt, upsertTag, name)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
@@ -0,0 +1,215 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQLite driver names as registered with database/sql.
+const (
+	// DriverCGO is github.com/mattn/go-sqlite3. It is only compiled in when
+	// CGO is enabled, the sqlite_fts5 build tag is set and puresqlite is not,
+	// as it lacks the FTS5 extension message search needs without that tag.
+	DriverCGO = "sqlite3"
+	// DriverPureGo is modernc.org/sqlite, which is always available.
+	DriverPureGo = "sqlite"
+)
+
+// pragmaParams maps the connection parameters understood by
+// github.com/mattn/go-sqlite3 to the PRAGMA they set. modernc.org/sqlite
+// expresses the same settings as repeated _pragma=name(value) parameters.
+var pragmaParams = map[string]string{
+	"_auto_vacuum":              "auto_vacuum",
+	"_vacuum":                   "auto_vacuum",
+	"_busy_timeout":             "busy_timeout",
+	"_timeout":                  "busy_timeout",
+	"_cache_size":               "cache_size",
+	"_case_sensitive_like":      "case_sensitive_like",
+	"_cslike":                   "case_sensitive_like",
+	"_defer_foreign_keys":       "defer_foreign_keys",
+	"_defer_fk":                 "defer_foreign_keys",
+	"_foreign_keys":             "foreign_keys",
+	"_fk":                       "foreign_keys",
+	"_ignore_check_constraints": "ignore_check_constraints",
+	"_journal_mode":             "journal_mode",
+	"_journal":                  "journal_mode",
+	"_locking_mode":             "locking_mode",
+	"_locking":                  "locking_mode",
+	"_query_only":               "query_only",
+	"_recursive_triggers":       "recursive_triggers",
+	"_rt":                       "recursive_triggers",
+	"_secure_delete":            "secure_delete",
+	"_synchronous":              "synchronous",
+	"_sync":                     "synchronous",
+}
+
+var pragmaValue = regexp.MustCompile(`^\s*(\w+)\s*\((.*)\)\s*$`)
+
+// defaultParams are the connection parameters Open adds to a dsn that does
+// not set them, in the syntax of github.com/mattn/go-sqlite3. Concurrent
+// writers wait for each other for up to the busy timeout rather than failing
+// with SQLITE_BUSY, and readers do not block writers in WAL mode.
+// Transactions take the write lock when they begin: a deferred transaction
+// that reads before it writes fails at once, without waiting, if another
+// connection wrote in between. Both drivers understand _txlock.
+var defaultParams = [][2]string{
+	{"_busy_timeout", "5000"},
+	{"_journal_mode", "WAL"},
+	{"_txlock", "immediate"},
+}
+
+// Drivers returns the SQLite drivers compiled into this binary, most
+// preferred first.
+func Drivers() []string {
+	if cgoDriverAvailable {
+		return []string{DriverCGO, DriverPureGo}
+	}
+	return []string{DriverPureGo}
+}
+
+// Open opens a SQLite database with the named driver, or the preferred
+// available driver when driver is empty. Pragmas in dsn may be written in
+// either driver's syntax; they are translated before the connection is made.
+// The defaultParams that dsn does not set are added to it.
+func Open(driver, dsn string) (*sql.DB, error) {
+	if driver == "" {
+		driver = Drivers()[0]
+	}
+	if !slices.Contains(Drivers(), driver) {
+		return nil, fmt.Errorf("sqlite driver %q is not available in this build (have %s)", driver, strings.Join(Drivers(), ", "))
+	}
+
+	dsn, err := withDefaults(dsn)
+	if err != nil {
+		return nil, err
+	}
+	dsn, err = TranslateDSN(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// CheckFTS5 returns an error if the SQLite library behind conn lacks the
+// FTS5 extension, which message search and its migration need. Builds only
+// include drivers that have it, so this guards against a SQLite library
+// swapped in by other build tags.
+func CheckFTS5(ctx context.Context, conn *sql.DB) error {
+	// The probe table lives in the temp schema of a single connection.
+	c, err := conn.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if _, err := c.ExecContext(ctx, `CREATE VIRTUAL TABLE temp.fts5_probe USING fts5(body)`); err != nil {
+		return fmt.Errorf("sqlite has no FTS5 support (%w); build with -tags sqlite_fts5, or with -tags puresqlite to use the pure-Go driver", err)
+	}
+	_, err = c.ExecContext(ctx, `DROP TABLE temp.fts5_probe`)
+	return err
+}
+
+// TranslateDSN rewrites the pragma parameters in dsn into the syntax expected
+// by driver. Parameters that are not pragmas are passed through unchanged.
+func TranslateDSN(driver, dsn string) (string, error) {
+	name, rawQuery, found := strings.Cut(dsn, "?")
+	if !found {
+		return dsn, nil
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", fmt.Errorf("parse dsn parameters: %w", err)
+	}
+
+	out := url.Values{}
+	switch driver {
+	case DriverPureGo:
+		for key, values := range query {
+			pragma, ok := pragmaParams[key]
+			if !ok {
+				out[key] = append(out[key], values...)
+				continue
+			}
+			for _, v := range values {
+				out.Add("_pragma", fmt.Sprintf("%s(%s)", pragma, v))
+			}
+		}
+	case DriverCGO:
+		for key, values := range query {
+			if key != "_pragma" {
+				out[key] = append(out[key], values...)
+				continue
+			}
+			for _, v := range values {
+				m := pragmaValue.FindStringSubmatch(v)
+				if m == nil {
+					return "", fmt.Errorf("invalid _pragma parameter %q", v)
+				}
+				param := "_" + strings.ToLower(m[1])
+				if _, ok := pragmaParams[param]; !ok {
+					return "", fmt.Errorf("pragma %q has no %s equivalent", m[1], DriverCGO)
+				}
+				out.Set(param, m[2])
+			}
+		}
+	default:
+		return "", fmt.Errorf("unknown sqlite driver %q", driver)
+	}
+
+	if len(out) == 0 {
+		return name, nil
+	}
+	return name + "?" + out.Encode(), nil
+}
+
+// withDefaults adds to dsn the defaultParams it does not set, in either
+// driver's syntax.
+func withDefaults(dsn string) (string, error) {
+	name, rawQuery, _ := strings.Cut(dsn, "?")
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", fmt.Errorf("parse dsn parameters: %w", err)
+	}
+
+	set := make(map[string]bool)
+	for key := range query {
+		if pragma, ok := pragmaParams[key]; ok {
+			set[pragma] = true
+		} else {
+			set[key] = true
+		}
+	}
+	for _, v := range query["_pragma"] {
+		if m := pragmaValue.FindStringSubmatch(v); m != nil {
+			set[strings.ToLower(m[1])] = true
+		}
+	}
+
+	for _, param := range defaultParams {
+		key := param[0]
+		if pragma, ok := pragmaParams[key]; ok {
+			key = pragma
+		}
+		if !set[key] {
+			query.Add(param[0], param[1])
+		}
+	}
+	return name + "?" + query.Encode(), nil
+}
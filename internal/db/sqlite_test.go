@@ -0,0 +1,240 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dunamismax/go-modern-scaffold/db/migrations"
+	"github.com/pressly/goose/v3"
+)
+
+func TestTranslateDSN(t *testing.T) {
+	tests := []struct {
+		name    string
+		driver  string
+		dsn     string
+		want    string
+		wantErr string
+	}{
+		{"no parameters", DriverPureGo, "app.db", "app.db", ""},
+		{"no parameters cgo", DriverCGO, "file:app.db", "file:app.db", ""},
+		{
+			"to pure go", DriverPureGo,
+			"app.db?_busy_timeout=5000&_journal_mode=WAL",
+			"app.db?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)", "",
+		},
+		{
+			"alias to pure go", DriverPureGo,
+			"app.db?_fk=1&_sync=NORMAL",
+			"app.db?_pragma=foreign_keys(1)&_pragma=synchronous(NORMAL)", "",
+		},
+		{
+			"pure go unchanged", DriverPureGo,
+			"app.db?_pragma=foreign_keys(1)",
+			"app.db?_pragma=foreign_keys(1)", "",
+		},
+		{
+			"to cgo", DriverCGO,
+			"app.db?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)",
+			"app.db?_busy_timeout=5000&_journal_mode=WAL", "",
+		},
+		{
+			"to cgo ignores case and spaces", DriverCGO,
+			"app.db?_pragma= Foreign_Keys (1)",
+			"app.db?_foreign_keys=1", "",
+		},
+		{
+			"cgo unchanged", DriverCGO,
+			"app.db?_foreign_keys=1",
+			"app.db?_foreign_keys=1", "",
+		},
+		{
+			"unknown parameters to pure go", DriverPureGo,
+			"app.db?_txlock=immediate&mode=ro&cache=shared&_busy_timeout=10",
+			"app.db?_pragma=busy_timeout(10)&_txlock=immediate&cache=shared&mode=ro", "",
+		},
+		{
+			"unknown parameters to cgo", DriverCGO,
+			"app.db?_txlock=immediate&mode=ro&_pragma=busy_timeout(10)",
+			"app.db?_busy_timeout=10&_txlock=immediate&mode=ro", "",
+		},
+		{"invalid pragma", DriverCGO, "app.db?_pragma=busy_timeout", "", "invalid _pragma parameter"},
+		{"pragma without cgo equivalent", DriverCGO, "app.db?_pragma=mmap_size(0)", "", "has no sqlite3 equivalent"},
+		{"invalid query", DriverPureGo, "app.db?a=%zz", "", "parse dsn parameters"},
+		{"unknown driver", "postgres", "app.db?_fk=1", "", `unknown sqlite driver "postgres"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TranslateDSN(tt.driver, tt.dsn)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("TranslateDSN(%q, %q) error = %v, want %q", tt.driver, tt.dsn, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("TranslateDSN(%q, %q): %v", tt.driver, tt.dsn, err)
+			}
+			assertSameDSN(t, got, tt.want)
+		})
+	}
+}
+
+func TestWithDefaults(t *testing.T) {
+	tests := []struct {
+		name string
+		dsn  string
+		want string
+	}{
+		{"none set", "app.db", "app.db?_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate"},
+		{"cgo syntax", "app.db?_busy_timeout=100&_journal_mode=DELETE&_txlock=deferred", "app.db?_busy_timeout=100&_journal_mode=DELETE&_txlock=deferred"},
+		{
+			"alias", "app.db?_timeout=100&_journal=DELETE",
+			"app.db?_timeout=100&_journal=DELETE&_txlock=immediate",
+		},
+		{
+			"pure go syntax", "app.db?_pragma=busy_timeout(100)&_pragma=JOURNAL_MODE(MEMORY)",
+			"app.db?_pragma=busy_timeout(100)&_pragma=JOURNAL_MODE(MEMORY)&_txlock=immediate",
+		},
+		{
+			"other parameters kept", "app.db?mode=rwc&_fk=1",
+			"app.db?mode=rwc&_fk=1&_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := withDefaults(tt.dsn)
+			if err != nil {
+				t.Fatalf("withDefaults(%q): %v", tt.dsn, err)
+			}
+			assertSameDSN(t, got, tt.want)
+		})
+	}
+}
+
+// assertSameDSN fails t unless got and want name the same database with the
+// same parameters, in any order.
+func assertSameDSN(t *testing.T, got, want string) {
+	t.Helper()
+	gotName, gotQuery := splitDSN(t, got)
+	wantName, wantQuery := splitDSN(t, want)
+	if gotName != wantName || !slices.Equal(gotQuery, wantQuery) {
+		t.Errorf("got dsn %q, want %q", got, want)
+	}
+}
+
+// splitDSN returns the name of dsn and its parameters as sorted key=value
+// pairs.
+func splitDSN(t *testing.T, dsn string) (string, []string) {
+	t.Helper()
+	name, rawQuery, _ := strings.Cut(dsn, "?")
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		t.Fatalf("parse %q: %v", dsn, err)
+	}
+	var params []string
+	for key, values := range query {
+		for _, v := range values {
+			params = append(params, key+"="+v)
+		}
+	}
+	slices.Sort(params)
+	return name, params
+}
+
+// openMigrated opens a new database in a temporary directory with driver and
+// applies the migrations to it. It fails t if the driver lacks FTS5, which
+// the migrations need.
+func openMigrated(t *testing.T, driver string) *sql.DB {
+	t.Helper()
+	ctx := context.Background()
+	conn, err := Open(driver, filepath.Join(t.TempDir(), "app.db"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	if err := CheckFTS5(ctx, conn); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := goose.NewProvider(goose.DialectSQLite3, conn, migrations.FS)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if _, err := p.Up(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return conn
+}
+
+func TestOpenMigrate(t *testing.T) {
+	for _, driver := range Drivers() {
+		t.Run(driver, func(t *testing.T) {
+			conn := openMigrated(t, driver)
+
+			res, err := conn.Exec(`INSERT INTO messages (body) VALUES (?)`, "hello world")
+			if err != nil {
+				t.Fatalf("insert: %v", err)
+			}
+			id, err := res.LastInsertId()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var body string
+			var createdAt time.Time
+			if err := conn.QueryRow(`SELECT body, created_at FROM messages WHERE id = ?`, id).Scan(&body, &createdAt); err != nil {
+				t.Fatalf("select: %v", err)
+			}
+			if body != "hello world" {
+				t.Errorf("body = %q, want %q", body, "hello world")
+			}
+			if d := time.Since(createdAt); d < -time.Minute || d > time.Minute {
+				t.Errorf("created_at = %v, want about now", createdAt)
+			}
+		})
+	}
+}
+
+func TestInTxConcurrent(t *testing.T) {
+	for _, driver := range Drivers() {
+		t.Run(driver, func(t *testing.T) {
+			ctx := context.Background()
+			store := NewStore(openMigrated(t, driver))
+
+			const writers = 20
+			errs := make(chan error, writers)
+			var wg sync.WaitGroup
+			for range writers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					// Read before writing, which fails at once with
+					// SQLITE_BUSY in a deferred transaction if another
+					// writer committed in between.
+					errs <- store.InTx(ctx, func(q Querier) error {
+						if _, err := q.GetLatestMessageID(ctx, 1); err != nil {
+							return err
+						}
+						time.Sleep(time.Millisecond)
+						_, err := q.CreateMessage(ctx, CreateMessageParams{Body: "hi", Author: "alice", ChannelID: 1})
+						return err
+					})
+				}()
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				if err != nil {
+					t.Errorf("InTx: %v", err)
+				}
+			}
+		})
+	}
+}
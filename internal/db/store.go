@@ -0,0 +1,45 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Store is a Querier that can also run several queries in one transaction.
+type Store interface {
+	Querier
+	// InTx calls fn with a Querier bound to a new transaction, which is
+	// committed if fn returns nil and rolled back otherwise. On connections
+	// made by Open, the transaction holds the write lock from the start, so
+	// concurrent transactions wait for each other rather than fail.
+	InTx(ctx context.Context, fn func(Querier) error) error
+}
+
+// sqlStore implements Store on top of a database connection pool.
+type sqlStore struct {
+	*Queries
+	conn *sql.DB
+}
+
+// NewStore creates a new Store using conn.
+func NewStore(conn *sql.DB) Store {
+	return &sqlStore{Queries: New(conn), conn: conn}
+}
+
+// InTx implements Store.
+func (s *sqlStore) InTx(ctx context.Context, fn func(Querier) error) error {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback() // a no-op once committed
+
+	if err := fn(s.WithTx(tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
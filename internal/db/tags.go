@@ -0,0 +1,21 @@
+package db
+
+import "context"
+
+// SetMessageTags replaces the tags of a message with tags, creating the tags
+// that do not exist yet.
+func SetMessageTags(ctx context.Context, q Querier, messageID int64, tags []string) error {
+	if err := q.ClearMessageTags(ctx, messageID); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		tagID, err := q.UpsertTag(ctx, tag)
+		if err != nil {
+			return err
+		}
+		if err := q.AddMessageTag(ctx, AddMessageTagParams{MessageID: messageID, TagID: tagID}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
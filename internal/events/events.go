@@ -0,0 +1,94 @@
+package events
+
+import (
+	"log/slog"
+	"sync"
+)
+
+// Event types published when messages change.
+const (
+	MessageCreated  = "message.created"
+	MessageUpdated  = "message.updated"
+	MessageDeleted  = "message.deleted"
+	MessageRestored = "message.restored"
+	ReactionChanged = "reaction.changed"
+)
+
+// subscriberBuffer is how many events a slow subscriber may fall behind
+// before further events to it are dropped.
+const subscriberBuffer = 16
+
+// Event describes a change to a message.
+type Event struct {
+	Type      string `json:"type"`
+	MessageID int64  `json:"message_id"`
+	// ParentID is the root message of the thread when MessageID is a reply.
+	ParentID  *int64 `json:"parent_id,omitempty"`
+	ChannelID int64  `json:"channel_id"`
+}
+
+// Broker fans events out to subscribers, such as connected browsers.
+type Broker struct {
+	mu     sync.Mutex
+	subs   map[chan Event]struct{}
+	closed bool
+}
+
+// NewBroker creates a new Broker.
+func NewBroker() *Broker {
+	return &Broker{subs: make(map[chan Event]struct{})}
+}
+
+// Subscribe registers a new subscriber. The returned function unsubscribes
+// and closes the channel; it must be called once the subscriber is done.
+func (b *Broker) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	b.subs[ch] = struct{}{}
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			if _, ok := b.subs[ch]; ok {
+				delete(b.subs, ch)
+				close(ch)
+			}
+		})
+	}
+}
+
+// Close closes the channels of every subscriber, so that event streams end
+// when the server shuts down. Later subscribers get a closed channel.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for ch := range b.subs {
+		delete(b.subs, ch)
+		close(ch)
+	}
+}
+
+// Publish sends e to every subscriber without blocking. Subscribers whose
+// buffer is full miss the event.
+func (b *Broker) Publish(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+			slog.Warn("dropped event for slow subscriber", "type", e.Type, "message_id", e.MessageID)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package form
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/go-playground/locales/en"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entranslations "github.com/go-playground/validator/v10/translations/en"
+	"github.com/labstack/echo/v4"
+)
+
+// Errors maps form field names to a readable validation message.
+type Errors map[string]string
+
+// Error implements the error interface.
+func (e Errors) Error() string {
+	fields := make([]string, 0, len(e))
+	for field := range e {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	msgs := make([]string, 0, len(e))
+	for _, field := range fields {
+		msgs = append(msgs, e[field])
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Validator validates structs using their `validate` tags and reports
+// failures as Errors with English messages. It implements echo.Validator.
+type Validator struct {
+	validate *validator.Validate
+	trans    ut.Translator
+}
+
+// NewValidator creates a new Validator. Messages refer to a field by its
+// `label` tag, falling back to its form name.
+func NewValidator() (*Validator, error) {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	validate.RegisterTagNameFunc(func(f reflect.StructField) string {
+		if label := f.Tag.Get("label"); label != "" {
+			return label
+		}
+		return fieldName(f)
+	})
+
+	locale := en.New()
+	trans, _ := ut.New(locale, locale).GetTranslator("en")
+	if err := entranslations.RegisterDefaultTranslations(validate, trans); err != nil {
+		return nil, err
+	}
+	// The default translations lack http_url.
+	if err := validate.RegisterTranslation("http_url", trans,
+		func(t ut.Translator) error {
+			return t.Add("http_url", "{0} must be an http or https URL", true)
+		},
+		func(t ut.Translator, fe validator.FieldError) string {
+			msg, _ := t.T("http_url", fe.Field())
+			return msg
+		},
+	); err != nil {
+		return nil, err
+	}
+
+	return &Validator{validate: validate, trans: trans}, nil
+}
+
+// Validate implements the echo.Validator interface.
+func (v *Validator) Validate(i interface{}) error {
+	err := v.validate.Struct(i)
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return err
+	}
+
+	t := reflect.TypeOf(i)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	out := make(Errors, len(verrs))
+	for _, fe := range verrs {
+		key := fe.Field()
+		// Elements of slices are reported as Field[i].
+		name, _, _ := strings.Cut(fe.StructField(), "[")
+		if sf, ok := t.FieldByName(name); ok {
+			key = fieldName(sf)
+		}
+		if _, seen := out[key]; !seen {
+			out[key] = fe.Translate(v.trans)
+		}
+	}
+	return out
+}
+
+// Bind binds the request into dst, trims surrounding whitespace from its
+// string fields, and validates it with the Echo instance's validator. A
+// malformed request yields an *echo.HTTPError; failed validation yields Errors.
+func Bind(c echo.Context, dst interface{}) error {
+	if err := c.Bind(dst); err != nil {
+		return err
+	}
+	trimStrings(reflect.ValueOf(dst))
+	return c.Validate(dst)
+}
+
+// trimStrings trims whitespace from every settable string field in v,
+// descending into nested structs.
+func trimStrings(v reflect.Value) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		if !f.CanSet() {
+			continue
+		}
+		switch f.Kind() {
+		case reflect.String:
+			f.SetString(strings.TrimSpace(f.String()))
+		case reflect.Struct, reflect.Pointer:
+			trimStrings(f)
+		}
+	}
+}
+
+// fieldName returns the name a struct field is submitted under: its form
+// tag, json tag or Go name.
+func fieldName(f reflect.StructField) string {
+	for _, tag := range []string{"form", "json"} {
+		name, _, _ := strings.Cut(f.Tag.Get(tag), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return f.Name
+}
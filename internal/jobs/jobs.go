@@ -0,0 +1,113 @@
+// Package jobs runs background work from a queue kept in the database.
+//
+// Work is enqueued as a job of a Kind, in the transaction of the change it
+// follows from if need be, and run by the workers of a Pool with the handler
+// registered for its kind. Failed jobs are retried with growing delays, and
+// dead-lettered once they have been tried as often as their kind allows.
+package jobs
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/db"
+)
+
+// Statuses of a job.
+const (
+	// Queued jobs wait for their run_at, including failed ones that will be
+	// retried.
+	Queued = "queued"
+	// Running jobs are leased by a worker.
+	Running = "running"
+	// Succeeded jobs are done; they are deleted after a while.
+	Succeeded = "succeeded"
+	// Dead jobs failed for good and are kept until an admin retries them.
+	Dead = "dead"
+)
+
+// Statuses lists the statuses of a job in the order jobs go through them.
+var Statuses = []string{Queued, Running, Succeeded, Dead}
+
+// DefaultMaxAttempts is how often jobs are tried unless their kind says
+// otherwise.
+const DefaultMaxAttempts = 5
+
+// Kind is a kind of job whose payloads are of type P, which must encode to
+// JSON. Kinds are best declared as package variables, so that the code
+// enqueuing jobs and the handler running them agree on the payload type.
+type Kind[P any] struct {
+	// Name identifies the kind in the jobs table.
+	Name string
+	// MaxAttempts is how often a job is tried before it is dead-lettered;
+	// 0 means DefaultMaxAttempts.
+	MaxAttempts int
+}
+
+// Enqueue adds a job of kind k with payload, to be run as soon as a worker
+// is free. With a Querier bound to a transaction, the job is only run if the
+// transaction commits.
+func (k Kind[P]) Enqueue(ctx context.Context, q db.Querier, payload P) (db.Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return db.Job{}, fmt.Errorf("encode %s job: %w", k.Name, err)
+	}
+	return q.CreateJob(ctx, db.CreateJobParams{
+		Type:        k.Name,
+		Payload:     string(data),
+		MaxAttempts: int64(cmp.Or(k.MaxAttempts, DefaultMaxAttempts)),
+	})
+}
+
+// Registry maps the names of job kinds to their handlers.
+type Registry struct {
+	handlers map[string]func(ctx context.Context, payload []byte) error
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{handlers: make(map[string]func(context.Context, []byte) error)}
+}
+
+// Register registers fn to run the jobs of kind k. The context passed to fn
+// is canceled when the job's lease runs out or the pool stops waiting for it
+// to finish. Register panics if k has a handler already.
+func Register[P any](r *Registry, k Kind[P], fn func(ctx context.Context, payload P) error) {
+	if _, ok := r.handlers[k.Name]; ok {
+		panic(fmt.Sprintf("jobs: handler for %q registered twice", k.Name))
+	}
+	r.handlers[k.Name] = func(ctx context.Context, data []byte) error {
+		var payload P
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return Permanent(fmt.Errorf("decode payload: %w", err))
+		}
+		return fn(ctx, payload)
+	}
+}
+
+// permanentError is an error retrying a job cannot fix.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as one retrying cannot fix, so that a handler
+// returning it has its job dead-lettered at once. It returns nil if err is
+// nil.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err}
+}
+
+// IsPermanent reports whether err was marked by Permanent.
+func IsPermanent(err error) bool {
+	var pe *permanentError
+	return errors.As(err, &pe)
+}
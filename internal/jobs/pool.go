@@ -0,0 +1,237 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/config"
+	"github.com/dunamismax/go-modern-scaffold/internal/db"
+)
+
+const (
+	// baseBackoff is the delay before the first retry of a failed job; it
+	// doubles with each further attempt up to maxBackoff.
+	baseBackoff = 10 * time.Second
+	maxBackoff  = time.Hour
+	// sweepInterval is how often succeeded jobs past their retention are
+	// deleted.
+	sweepInterval = time.Hour
+	// recordTimeout bounds claiming a job and recording its outcome.
+	recordTimeout = 10 * time.Second
+)
+
+// Pool runs the queued jobs with a fixed number of workers.
+type Pool struct {
+	queries  db.Querier
+	registry *Registry
+	cfg      *config.Jobs
+	log      *slog.Logger
+
+	// stop is closed when the workers are to stop taking jobs.
+	stop chan struct{}
+	// ctx is the context of the running jobs, canceled when they are
+	// interrupted.
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewPool creates a Pool running the jobs in q with the handlers in r.
+func NewPool(q db.Querier, r *Registry, cfg *config.Jobs, log *slog.Logger) *Pool {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Pool{
+		queries:  q,
+		registry: r,
+		cfg:      cfg,
+		log:      log,
+		stop:     make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start starts the workers, along with a sweeper deleting the succeeded jobs
+// older than the retention.
+func (p *Pool) Start() {
+	workers := max(p.cfg.Workers, 1)
+	p.wg.Add(workers + 1)
+	for range workers {
+		go p.work()
+	}
+	go p.sweep()
+	p.log.Info("started job workers", "workers", workers)
+}
+
+// Shutdown stops the workers from taking more jobs and waits for the running
+// ones to finish. If ctx is done first, the running jobs are interrupted and
+// queued again, and Shutdown returns the error of ctx once their workers have
+// stopped.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	close(p.stop)
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	defer p.cancel()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// work runs jobs until the pool stops, looking for due jobs every poll
+// interval while there are none.
+func (p *Pool) work() {
+	defer p.wg.Done()
+	for {
+		select {
+		case <-p.stop:
+			return
+		default:
+		}
+
+		ran, err := p.runNext()
+		if err != nil {
+			p.log.Error("failed to claim job", "error", err)
+		}
+		if ran {
+			continue
+		}
+		select {
+		case <-p.stop:
+			return
+		case <-time.After(p.cfg.PollInterval):
+		}
+	}
+}
+
+// runNext leases the job that is due first and runs it. It reports whether
+// there was one.
+func (p *Pool) runNext() (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), recordTimeout)
+	defer cancel()
+	leaseID := rand.Text()
+	job, err := p.queries.ClaimJob(ctx, db.ClaimJobParams{
+		LeaseID:      &leaseID,
+		LeaseSeconds: max(int64(p.cfg.Lease/time.Second), 1),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	p.run(job)
+	return true, nil
+}
+
+// run runs a leased job and records how it went: it succeeded, is retried
+// later, is dead-lettered, or is queued again as it was interrupted.
+func (p *Pool) run(job db.Job) {
+	log := p.log.With("job_id", job.ID, "job_type", job.Type, "attempt", job.Attempts)
+	start := time.Now()
+	var err error
+	if job.Attempts > job.MaxAttempts {
+		// Only jobs whose lease expired are tried again without having failed.
+		err = Permanent(errors.New("lease expired on every attempt"))
+	} else {
+		err = p.handle(job, log)
+	}
+
+	// The outcome is recorded even if the jobs are being interrupted.
+	ctx, cancel := context.WithTimeout(context.Background(), recordTimeout)
+	defer cancel()
+	var n int64
+	var recErr error
+	switch {
+	case err == nil:
+		n, recErr = p.queries.CompleteJob(ctx, db.CompleteJobParams{ID: job.ID, LeaseID: job.LeaseID})
+		log.Info("job succeeded", "duration", time.Since(start))
+	case p.ctx.Err() != nil:
+		n, recErr = p.queries.ReleaseJob(ctx, db.ReleaseJobParams{ID: job.ID, LeaseID: job.LeaseID})
+		log.Warn("job interrupted, queued again", "error", err)
+	case IsPermanent(err) || job.Attempts >= job.MaxAttempts:
+		msg := err.Error()
+		n, recErr = p.queries.BuryJob(ctx, db.BuryJobParams{ID: job.ID, LeaseID: job.LeaseID, LastError: &msg})
+		log.Error("job failed for good", "error", err)
+	default:
+		msg := err.Error()
+		delay := backoff(int(job.Attempts))
+		n, recErr = p.queries.RetryJob(ctx, db.RetryJobParams{
+			ID:           job.ID,
+			LeaseID:      job.LeaseID,
+			LastError:    &msg,
+			DelaySeconds: int64(delay / time.Second),
+		})
+		log.Warn("job failed, will retry", "error", err, "retry_in", delay)
+	}
+	switch {
+	case recErr != nil:
+		log.Error("failed to record job outcome", "error", recErr)
+	case n == 0:
+		log.Warn("job lease expired before it finished; another worker has taken it over")
+	}
+}
+
+// handle runs job with the handler of its kind, turning a panic into an
+// error.
+func (p *Pool) handle(job db.Job, log *slog.Logger) (err error) {
+	fn, ok := p.registry.handlers[job.Type]
+	if !ok {
+		return Permanent(fmt.Errorf("no handler for jobs of type %q", job.Type))
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error("job panicked", "panic", r, "stack", string(debug.Stack()))
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	ctx, cancel := context.WithTimeout(p.ctx, p.cfg.Lease)
+	defer cancel()
+	return fn(ctx, []byte(job.Payload))
+}
+
+// sweep deletes the succeeded jobs older than the retention every
+// sweepInterval until the pool stops.
+func (p *Pool) sweep() {
+	defer p.wg.Done()
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+	for {
+		n, err := p.queries.DeleteSucceededJobs(p.ctx, -int64(p.cfg.Retention/time.Second))
+		switch {
+		case err != nil && p.ctx.Err() == nil:
+			p.log.Error("failed to delete succeeded jobs", "error", err)
+		case n > 0:
+			p.log.Info("deleted succeeded jobs", "count", n)
+		}
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// backoff returns the delay before retrying a job that failed its nth
+// attempt.
+func backoff(n int) time.Duration {
+	d := baseBackoff
+	for i := 1; i < n && d < maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxBackoff)
+}
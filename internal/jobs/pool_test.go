@@ -0,0 +1,140 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/config"
+	"github.com/dunamismax/go-modern-scaffold/internal/db"
+	"github.com/dunamismax/go-modern-scaffold/internal/db/dbtest"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{3, 40 * time.Second},
+		{4, 80 * time.Second},
+		{9, 2560 * time.Second},
+		{10, time.Hour},
+		{100, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := backoff(tt.attempt); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+// testKind is the kind of job run by the pool tests.
+var testKind = Kind[string]{Name: "test", MaxAttempts: 3}
+
+// newTestPool returns a pool over a new database, whose testKind jobs are
+// run by fn.
+func newTestPool(t *testing.T, fn func(context.Context, string) error) (*Pool, *sql.DB) {
+	t.Helper()
+	conn := dbtest.Open(t)
+	r := NewRegistry()
+	Register(r, testKind, fn)
+	cfg := &config.Jobs{Workers: 1, PollInterval: time.Second, Lease: time.Minute, Retention: time.Hour}
+	return NewPool(db.New(conn), r, cfg, slog.New(slog.DiscardHandler)), conn
+}
+
+// getJob returns the job with id.
+func getJob(t *testing.T, q db.Querier, id int64) db.Job {
+	t.Helper()
+	jobs, err := q.GetJobs(context.Background(), db.GetJobsParams{Limit: 100})
+	if err != nil {
+		t.Fatalf("GetJobs: %v", err)
+	}
+	for _, job := range jobs {
+		if job.ID == id {
+			return job
+		}
+	}
+	t.Fatalf("job %d not found", id)
+	return db.Job{}
+}
+
+// runDue makes all queued jobs due and runs the next one.
+func runDue(t *testing.T, p *Pool, conn *sql.DB) {
+	t.Helper()
+	if _, err := conn.Exec(`UPDATE jobs SET run_at = datetime('now') WHERE status = 'queued'`); err != nil {
+		t.Fatal(err)
+	}
+	ran, err := p.runNext()
+	if err != nil {
+		t.Fatalf("runNext: %v", err)
+	}
+	if !ran {
+		t.Fatal("runNext found no job")
+	}
+}
+
+func TestPoolRetriesWithBackoff(t *testing.T) {
+	var calls int
+	p, conn := newTestPool(t, func(context.Context, string) error {
+		calls++
+		return errors.New("receiver down")
+	})
+	job, err := testKind.Enqueue(context.Background(), p.queries, "payload")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for attempt := 1; attempt < testKind.MaxAttempts; attempt++ {
+		runDue(t, p, conn)
+		got := getJob(t, p.queries, job.ID)
+		if got.Status != Queued || got.Attempts != int64(attempt) {
+			t.Fatalf("after attempt %d: status %s, attempts %d; want queued, %d", attempt, got.Status, got.Attempts, attempt)
+		}
+		if got.LastError == nil || *got.LastError != "receiver down" {
+			t.Errorf("after attempt %d: last error %v, want the handler's", attempt, got.LastError)
+		}
+		// run_at has a resolution of one second.
+		wait := time.Until(got.RunAt)
+		if want := backoff(attempt); wait < want-2*time.Second || wait > want+time.Second {
+			t.Errorf("after attempt %d: retried in %v, want %v", attempt, wait, want)
+		}
+	}
+
+	runDue(t, p, conn)
+	got := getJob(t, p.queries, job.ID)
+	if got.Status != Dead || got.Attempts != int64(testKind.MaxAttempts) {
+		t.Errorf("after the last attempt: status %s, attempts %d; want dead, %d", got.Status, got.Attempts, testKind.MaxAttempts)
+	}
+	if calls != testKind.MaxAttempts {
+		t.Errorf("handler called %d times, want %d", calls, testKind.MaxAttempts)
+	}
+}
+
+func TestPoolOutcomes(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus string
+	}{
+		{"success", nil, Succeeded},
+		{"permanent error", Permanent(errors.New("bad payload")), Dead},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, conn := newTestPool(t, func(context.Context, string) error { return tt.err })
+			job, err := testKind.Enqueue(context.Background(), p.queries, "payload")
+			if err != nil {
+				t.Fatal(err)
+			}
+			runDue(t, p, conn)
+			if got := getJob(t, p.queries, job.ID); got.Status != tt.wantStatus || got.Attempts != 1 {
+				t.Errorf("status %s, attempts %d; want %s, 1", got.Status, got.Attempts, tt.wantStatus)
+			}
+		})
+	}
+}
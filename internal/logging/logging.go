@@ -0,0 +1,73 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/config"
+)
+
+// New creates a logger from the logging configuration. The returned closer
+// releases the log file when LOG_OUTPUT names one; it is a no-op otherwise.
+func New(cfg *config.Log) (*slog.Logger, io.Closer, error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out, closer, err := openOutput(cfg.Output)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "", "json":
+		handler = slog.NewJSONHandler(out, opts)
+	case "text":
+		handler = slog.NewTextHandler(out, opts)
+	case "pretty":
+		handler = NewPrettyHandler(out, opts)
+	default:
+		closer.Close()
+		return nil, nil, fmt.Errorf("unknown log format %q (want json, text or pretty)", cfg.Format)
+	}
+
+	return slog.New(handler), closer, nil
+}
+
+// ParseLevel parses a level name such as "debug" or "WARN".
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if s == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("invalid log level %q: %w", s, err)
+	}
+	return level, nil
+}
+
+// openOutput resolves LOG_OUTPUT to a writer.
+func openOutput(output string) (io.Writer, io.Closer, error) {
+	switch strings.ToLower(output) {
+	case "", "stdout":
+		return os.Stdout, nopCloser{}, nil
+	case "stderr":
+		return os.Stderr, nopCloser{}, nil
+	}
+
+	f, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open log file: %w", err)
+	}
+	return f, f, nil
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
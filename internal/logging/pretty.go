@@ -0,0 +1,154 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ANSI escape codes used by PrettyHandler.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiFaint  = "\x1b[2m"
+	ansiRed    = "\x1b[31m"
+	ansiYellow = "\x1b[33m"
+	ansiBlue   = "\x1b[34m"
+	ansiCyan   = "\x1b[36m"
+)
+
+// PrettyHandler is a slog.Handler that writes colored, human-readable lines
+// for local development:
+//
+//	15:04:05.000 INF starting server address=:3000
+type PrettyHandler struct {
+	opts   slog.HandlerOptions
+	prefix string // group prefix for attributes added later
+	attrs  string // preformatted attributes from WithAttrs
+	mu     *sync.Mutex
+	out    io.Writer
+}
+
+// NewPrettyHandler creates a PrettyHandler writing to out.
+func NewPrettyHandler(out io.Writer, opts *slog.HandlerOptions) *PrettyHandler {
+	h := &PrettyHandler{out: out, mu: &sync.Mutex{}}
+	if opts != nil {
+		h.opts = *opts
+	}
+	return h
+}
+
+// Enabled implements slog.Handler.
+func (h *PrettyHandler) Enabled(_ context.Context, level slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.opts.Level != nil {
+		minLevel = h.opts.Level.Level()
+	}
+	return level >= minLevel
+}
+
+// Handle implements slog.Handler.
+func (h *PrettyHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+
+	if !r.Time.IsZero() {
+		b.WriteString(ansiFaint + r.Time.Format("15:04:05.000") + ansiReset + " ")
+	}
+	b.WriteString(levelColor(r.Level) + levelLabel(r.Level) + ansiReset + " ")
+	b.WriteString(r.Message)
+	b.WriteString(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		appendAttr(&b, h.prefix, a)
+		return true
+	})
+	b.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.out, b.String())
+	return err
+}
+
+// WithAttrs implements slog.Handler.
+func (h *PrettyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var b strings.Builder
+	for _, a := range attrs {
+		appendAttr(&b, h.prefix, a)
+	}
+	h2 := *h
+	h2.attrs += b.String()
+	return &h2
+}
+
+// WithGroup implements slog.Handler.
+func (h *PrettyHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.prefix += name + "."
+	return &h2
+}
+
+func appendAttr(b *strings.Builder, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			appendAttr(b, prefix, ga)
+		}
+		return
+	}
+
+	b.WriteString(" " + ansiCyan + prefix + a.Key + "=" + ansiReset)
+	var v string
+	switch a.Value.Kind() {
+	case slog.KindTime:
+		v = a.Value.Time().Format(time.RFC3339)
+	case slog.KindDuration:
+		v = a.Value.Duration().String()
+	default:
+		v = a.Value.String()
+	}
+	if strings.ContainsAny(v, " \t\n\"=") {
+		v = strconv.Quote(v)
+	}
+	if _, isErr := a.Value.Any().(error); isErr {
+		v = ansiRed + v + ansiReset
+	}
+	b.WriteString(v)
+}
+
+func levelLabel(l slog.Level) string {
+	switch {
+	case l >= slog.LevelError:
+		return "ERR"
+	case l >= slog.LevelWarn:
+		return "WRN"
+	case l >= slog.LevelInfo:
+		return "INF"
+	default:
+		return "DBG"
+	}
+}
+
+func levelColor(l slog.Level) string {
+	switch {
+	case l >= slog.LevelError:
+		return ansiRed
+	case l >= slog.LevelWarn:
+		return ansiYellow
+	case l >= slog.LevelInfo:
+		return ansiBlue
+	default:
+		return ansiFaint
+	}
+}
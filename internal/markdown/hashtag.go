@@ -0,0 +1,102 @@
+package markdown
+
+import (
+	"regexp"
+	"unicode"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// maxTagLength is the longest hashtag recognized, in bytes, not counting the
+// leading #.
+const maxTagLength = 50
+
+// hashtagPattern matches a hashtag at the start of the input.
+var hashtagPattern = regexp.MustCompile(`^#[\p{L}\p{N}_][\p{L}\p{N}_-]*`)
+
+// KindHashtag is the ast.NodeKind of Hashtag.
+var KindHashtag = ast.NewNodeKind("Hashtag")
+
+// Hashtag is an inline node for a #hashtag.
+type Hashtag struct {
+	ast.BaseInline
+	// Value is the hashtag as written, including the #.
+	Value []byte
+	// Tag is the normalized name of the hashtag.
+	Tag string
+}
+
+// Kind implements ast.Node.
+func (n *Hashtag) Kind() ast.NodeKind { return KindHashtag }
+
+// Dump implements ast.Node.
+func (n *Hashtag) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Tag": n.Tag}, nil)
+}
+
+// hashtagExtension adds hashtag parsing and rendering to goldmark.
+type hashtagExtension struct {
+	tagURL func(tag string) string
+}
+
+// Extend implements goldmark.Extender.
+func (e *hashtagExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithInlineParsers(
+		util.Prioritized(hashtagParser{}, 999),
+	))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(&hashtagRenderer{tagURL: e.tagURL}, 999),
+	))
+}
+
+// hashtagParser parses hashtags. A # only starts a hashtag at the start of a
+// line or after a character that cannot be part of a word, so "page#anchor"
+// and "&#39;" are not hashtags.
+type hashtagParser struct{}
+
+// Trigger implements parser.InlineParser.
+func (hashtagParser) Trigger() []byte { return []byte{'#'} }
+
+// Parse implements parser.InlineParser.
+func (hashtagParser) Parse(_ ast.Node, block text.Reader, _ parser.Context) ast.Node {
+	prev := block.PrecendingCharacter()
+	if unicode.IsLetter(prev) || unicode.IsNumber(prev) || prev == '_' || prev == '&' || prev == '#' || prev == '/' {
+		return nil
+	}
+
+	line, _ := block.PeekLine()
+	m := hashtagPattern.Find(line)
+	if m == nil || len(m)-1 > maxTagLength {
+		return nil
+	}
+	block.Advance(len(m))
+	return &Hashtag{Value: m, Tag: NormalizeTag(string(m))}
+}
+
+// hashtagRenderer renders hashtags as links.
+type hashtagRenderer struct {
+	tagURL func(tag string) string
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.
+func (r *hashtagRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindHashtag, r.render)
+}
+
+func (r *hashtagRenderer) render(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*Hashtag)
+	_, _ = w.WriteString(`<a href="`)
+	_, _ = w.Write(util.EscapeHTML(util.URLEscape([]byte(r.tagURL(n.Tag)), false)))
+	_, _ = w.WriteString(`" class="hashtag">`)
+	_, _ = w.Write(util.EscapeHTML(n.Value))
+	_, _ = w.WriteString("</a>")
+	return ast.WalkContinue, nil
+}
@@ -0,0 +1,125 @@
+package markdown
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/text"
+)
+
+// highlightStyle is the Chroma style used for fenced code blocks. It matches
+// the app's DaisyUI theme.
+const highlightStyle = "dracula"
+
+// classPattern matches the class attributes the sanitizer keeps: the Chroma
+// token classes, the language-* class of code blocks and the hashtag and
+// mention classes.
+var classPattern = regexp.MustCompile(`^[\w -]+$`)
+
+// Renderer converts message bodies written in Markdown to safe HTML.
+//
+// It supports CommonMark plus strikethrough, turns bare URLs into links and
+// #hashtags into links to tagURL, marks @mentions, and highlights fenced code
+// blocks with CSS classes from HighlightCSS. Raw HTML in the input is
+// dropped, and the output is sanitized against an allowlist of elements and
+// attributes. Links to other sites get rel="nofollow noopener" and open in a
+// new tab.
+type Renderer struct {
+	md     goldmark.Markdown
+	policy *bluemonday.Policy
+}
+
+// New creates a new Renderer. tagURL returns the URL a hashtag links to,
+// given its normalized name.
+func New(tagURL func(tag string) string) *Renderer {
+	md := goldmark.New(
+		goldmark.WithExtensions(
+			extension.Linkify,
+			extension.Strikethrough,
+			&hashtagExtension{tagURL: tagURL},
+			mentionExtension{},
+			highlighting.NewHighlighting(
+				highlighting.WithStyle(highlightStyle),
+				highlighting.WithFormatOptions(chromahtml.WithClasses(true)),
+			),
+		),
+	)
+
+	policy := bluemonday.UGCPolicy()
+	policy.AllowAttrs("class").Matching(classPattern).OnElements("a", "code", "pre", "span")
+	policy.RequireNoFollowOnLinks(false)
+	policy.RequireNoFollowOnFullyQualifiedLinks(true)
+	policy.AddTargetBlankToFullyQualifiedLinks(true)
+
+	return &Renderer{md: md, policy: policy}
+}
+
+// Render converts body to sanitized HTML.
+func (r *Renderer) Render(body string) (string, error) {
+	var buf bytes.Buffer
+	if err := r.md.Convert([]byte(body), &buf); err != nil {
+		return "", err
+	}
+	return r.policy.SanitizeReader(&buf).String(), nil
+}
+
+// Tags returns the normalized hashtags in body, in order of first appearance
+// and without duplicates. Hashtags in code are not counted.
+func (r *Renderer) Tags(body string) []string {
+	src := []byte(body)
+	doc := r.md.Parser().Parse(text.NewReader(src))
+
+	var tags []string
+	seen := make(map[string]bool)
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if tag, ok := n.(*Hashtag); ok && entering && !seen[tag.Tag] {
+			seen[tag.Tag] = true
+			tags = append(tags, tag.Tag)
+		}
+		return ast.WalkContinue, nil
+	})
+	return tags
+}
+
+// Mentions returns the usernames mentioned in body, in order of first
+// appearance and without duplicates. Mentions in code are not counted.
+func (r *Renderer) Mentions(body string) []string {
+	src := []byte(body)
+	doc := r.md.Parser().Parse(text.NewReader(src))
+
+	var users []string
+	seen := make(map[string]bool)
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if m, ok := n.(*Mention); ok && entering && !seen[m.Username] {
+			seen[m.Username] = true
+			users = append(users, m.Username)
+		}
+		return ast.WalkContinue, nil
+	})
+	return users
+}
+
+// NormalizeTag returns the stored form of a tag: lower case, without a
+// leading #.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(tag, "#"))
+}
+
+// HighlightCSS returns the stylesheet for the CSS classes of highlighted code
+// blocks.
+func HighlightCSS() ([]byte, error) {
+	var buf bytes.Buffer
+	formatter := chromahtml.New(chromahtml.WithClasses(true))
+	if err := formatter.WriteCSS(&buf, styles.Get(highlightStyle)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,96 @@
+package markdown
+
+import (
+	"regexp"
+	"unicode"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// maxUsernameLength is the longest username recognized in a mention, in
+// bytes, not counting the leading @.
+const maxUsernameLength = 64
+
+// mentionPattern matches a mention at the start of the input. Usernames may
+// contain dots and hyphens, but not end with them, so "@bob." at the end of
+// a sentence mentions bob.
+var mentionPattern = regexp.MustCompile(`^@[\p{L}\p{N}_](?:[\p{L}\p{N}_.-]*[\p{L}\p{N}_])?`)
+
+// KindMention is the ast.NodeKind of Mention.
+var KindMention = ast.NewNodeKind("Mention")
+
+// Mention is an inline node for an @mention.
+type Mention struct {
+	ast.BaseInline
+	// Username is the mentioned username, without the @.
+	Username string
+}
+
+// Kind implements ast.Node.
+func (n *Mention) Kind() ast.NodeKind { return KindMention }
+
+// Dump implements ast.Node.
+func (n *Mention) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Username": n.Username}, nil)
+}
+
+// mentionExtension adds mention parsing and rendering to goldmark.
+type mentionExtension struct{}
+
+// Extend implements goldmark.Extender.
+func (mentionExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithInlineParsers(
+		util.Prioritized(mentionParser{}, 999),
+	))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(mentionRenderer{}, 999),
+	))
+}
+
+// mentionParser parses mentions. An @ only starts a mention at the start of a
+// line or after a character that cannot be part of a word, so e-mail
+// addresses are not mentions.
+type mentionParser struct{}
+
+// Trigger implements parser.InlineParser.
+func (mentionParser) Trigger() []byte { return []byte{'@'} }
+
+// Parse implements parser.InlineParser.
+func (mentionParser) Parse(_ ast.Node, block text.Reader, _ parser.Context) ast.Node {
+	prev := block.PrecendingCharacter()
+	if unicode.IsLetter(prev) || unicode.IsNumber(prev) || prev == '_' || prev == '.' || prev == '-' || prev == '@' || prev == '/' {
+		return nil
+	}
+
+	line, _ := block.PeekLine()
+	m := mentionPattern.Find(line)
+	if m == nil || len(m)-1 > maxUsernameLength {
+		return nil
+	}
+	block.Advance(len(m))
+	return &Mention{Username: string(m[1:])}
+}
+
+// mentionRenderer renders mentions as highlighted text.
+type mentionRenderer struct{}
+
+// RegisterFuncs implements renderer.NodeRenderer.
+func (r mentionRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindMention, r.render)
+}
+
+func (mentionRenderer) render(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*Mention)
+	_, _ = w.WriteString(`<span class="mention">@`)
+	_, _ = w.Write(util.EscapeHTML([]byte(n.Username)))
+	_, _ = w.WriteString("</span>")
+	return ast.WalkContinue, nil
+}
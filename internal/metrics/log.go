@@ -0,0 +1,141 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// maxErrors is the number of errors a Recorder keeps.
+	maxErrors = 50
+	// maxAttrLen is the length attribute values are cut to, in bytes.
+	maxAttrLen = 200
+)
+
+// LogRecord is a record the server logged.
+type LogRecord struct {
+	Time    time.Time         `json:"time"`
+	Level   string            `json:"level"`
+	Message string            `json:"message"`
+	Attrs   map[string]string `json:"attrs,omitempty"`
+}
+
+// Errors returns the latest records logged at error level or above, newest
+// first.
+func (r *Recorder) Errors() []LogRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	records := make([]LogRecord, 0, len(r.errors))
+	for i := range len(r.errors) {
+		records = append(records, r.errors[(r.next-1-i+len(r.errors))%len(r.errors)])
+	}
+	return records
+}
+
+// addError keeps rec as the latest error, dropping the oldest if there are
+// maxErrors already.
+func (r *Recorder) addError(rec LogRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.errors) < maxErrors {
+		r.errors = append(r.errors, rec)
+	} else {
+		r.errors[r.next] = rec
+	}
+	r.next = (r.next + 1) % maxErrors
+}
+
+// Handler returns a slog.Handler that passes records on to next and keeps
+// those at error level or above for Errors.
+func (r *Recorder) Handler(next slog.Handler) slog.Handler {
+	return &errorHandler{next: next, rec: r}
+}
+
+// errorHandler is the slog.Handler of Recorder.Handler.
+type errorHandler struct {
+	next slog.Handler
+	rec  *Recorder
+	// attrs are the attributes added with WithAttrs, and prefix the groups
+	// opened with WithGroup, as in "request.".
+	attrs  map[string]string
+	prefix string
+}
+
+func (h *errorHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= slog.LevelError || h.next.Enabled(ctx, level)
+}
+
+func (h *errorHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= slog.LevelError {
+		attrs := maps.Clone(h.attrs)
+		r.Attrs(func(a slog.Attr) bool {
+			attrs = addAttr(attrs, h.prefix, a)
+			return true
+		})
+		h.rec.addError(LogRecord{
+			Time:    r.Time,
+			Level:   r.Level.String(),
+			Message: r.Message,
+			Attrs:   attrs,
+		})
+	}
+	if !h.next.Enabled(ctx, r.Level) {
+		return nil
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *errorHandler) WithAttrs(as []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.next = h.next.WithAttrs(as)
+	h2.attrs = maps.Clone(h.attrs)
+	for _, a := range as {
+		h2.attrs = addAttr(h2.attrs, h.prefix, a)
+	}
+	return &h2
+}
+
+func (h *errorHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.next = h.next.WithGroup(name)
+	h2.prefix += name + "."
+	return &h2
+}
+
+// addAttr adds a to attrs, flattening groups into dotted keys. Stack traces
+// are left out: they are too long to be of use cut short, and the log has
+// them.
+func addAttr(attrs map[string]string, prefix string, a slog.Attr) map[string]string {
+	a.Value = a.Value.Resolve()
+	switch {
+	case a.Equal(slog.Attr{}), a.Key == "stack":
+		return attrs
+	case a.Value.Kind() == slog.KindGroup:
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			attrs = addAttr(attrs, prefix, ga)
+		}
+		return attrs
+	}
+	if attrs == nil {
+		attrs = make(map[string]string)
+	}
+	v := a.Value.String()
+	if len(v) > maxAttrLen {
+		n := maxAttrLen
+		for !utf8.RuneStart(v[n]) {
+			n--
+		}
+		v = v[:n] + "…"
+	}
+	attrs[prefix+a.Key] = v
+	return attrs
+}
@@ -0,0 +1,265 @@
+// Package metrics keeps the operational metrics of the server: counts and
+// latencies of the requests it served, the state of its cache and database
+// connection pool, and the errors it logged last. The operations dashboard
+// of the CLI polls them.
+package metrics
+
+import (
+	"database/sql"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dgraph-io/ristretto"
+)
+
+// latencyBounds are the upper bounds, in seconds, of the latency buckets.
+var latencyBounds = [...]float64{
+	0.0001, 0.00025, 0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10,
+}
+
+// Recorder records the metrics of the requests a server serves and the
+// errors it logs. It is safe for concurrent use.
+type Recorder struct {
+	started time.Time
+
+	total        atomic.Int64
+	clientErrors atomic.Int64
+	serverErrors atomic.Int64
+	timed        atomic.Int64
+	latency      atomic.Int64 // in nanoseconds
+	buckets      [len(latencyBounds) + 1]atomic.Int64
+
+	mu     sync.Mutex
+	errors []LogRecord // a ring of the latest errors
+	next   int         // where the next error goes in errors
+}
+
+// NewRecorder creates a Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{started: time.Now()}
+}
+
+// Observe records a request that completed with status after d. Requests that
+// are not timed, such as event streams that last as long as the client
+// stays, count towards the totals only.
+func (r *Recorder) Observe(status int, d time.Duration, timed bool) {
+	r.total.Add(1)
+	switch {
+	case status >= 500:
+		r.serverErrors.Add(1)
+	case status >= 400:
+		r.clientErrors.Add(1)
+	}
+	if !timed {
+		return
+	}
+	r.timed.Add(1)
+	r.latency.Add(int64(d))
+	i := 0
+	for i < len(latencyBounds) && d.Seconds() > latencyBounds[i] {
+		i++
+	}
+	r.buckets[i].Add(1)
+}
+
+// Report is a snapshot of the metrics of a server. Its counters grow from
+// when the server started, so rates are found by comparing two reports.
+type Report struct {
+	Started  time.Time   `json:"started"`
+	Time     time.Time   `json:"time"`
+	Requests Requests    `json:"requests"`
+	Cache    Cache       `json:"cache"`
+	DB       DB          `json:"db"`
+	Runtime  Runtime     `json:"runtime"`
+	Errors   []LogRecord `json:"errors"`
+}
+
+// Requests counts the requests served.
+type Requests struct {
+	Total        int64 `json:"total"`
+	ClientErrors int64 `json:"client_errors"`
+	ServerErrors int64 `json:"server_errors"`
+	// Timed is the number of requests whose latency is recorded, and
+	// LatencySeconds the sum of their latencies.
+	Timed          int64   `json:"timed"`
+	LatencySeconds float64 `json:"latency_seconds"`
+	// LatencyBuckets counts the timed requests by latency: bucket i holds
+	// those that took longer than bound i-1 but at most bound i of
+	// LatencyBounds, and the last bucket those slower than every bound.
+	LatencyBuckets []int64   `json:"latency_buckets"`
+	LatencyBounds  []float64 `json:"latency_bounds"`
+}
+
+// Cache describes the use of the in-memory cache.
+type Cache struct {
+	Hits        uint64 `json:"hits"`
+	Misses      uint64 `json:"misses"`
+	KeysAdded   uint64 `json:"keys_added"`
+	KeysEvicted uint64 `json:"keys_evicted"`
+	CostAdded   uint64 `json:"cost_added"`
+	CostEvicted uint64 `json:"cost_evicted"`
+}
+
+// DB describes the database connection pool.
+type DB struct {
+	MaxOpen     int     `json:"max_open"`
+	Open        int     `json:"open"`
+	InUse       int     `json:"in_use"`
+	Idle        int     `json:"idle"`
+	WaitCount   int64   `json:"wait_count"`
+	WaitSeconds float64 `json:"wait_seconds"`
+	// Connections closed for being idle too long or too many, or for
+	// reaching their maximum lifetime.
+	MaxIdleClosed     int64 `json:"max_idle_closed"`
+	MaxIdleTimeClosed int64 `json:"max_idle_time_closed"`
+	MaxLifetimeClosed int64 `json:"max_lifetime_closed"`
+}
+
+// Runtime describes the Go runtime of the server.
+type Runtime struct {
+	Goroutines int    `json:"goroutines"`
+	HeapBytes  uint64 `json:"heap_bytes"`
+	GCs        uint32 `json:"gcs"`
+}
+
+// Report returns the current metrics, taking those of the cache from
+// cache, which may be nil if the cache keeps none, and those of the
+// connection pool from db.
+func (r *Recorder) Report(cache *ristretto.Metrics, db sql.DBStats) Report {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	return Report{
+		Started:  r.started,
+		Time:     time.Now(),
+		Requests: r.requests(),
+		Cache: Cache{
+			Hits:        cache.Hits(),
+			Misses:      cache.Misses(),
+			KeysAdded:   cache.KeysAdded(),
+			KeysEvicted: cache.KeysEvicted(),
+			CostAdded:   cache.CostAdded(),
+			CostEvicted: cache.CostEvicted(),
+		},
+		DB: DB{
+			MaxOpen:           db.MaxOpenConnections,
+			Open:              db.OpenConnections,
+			InUse:             db.InUse,
+			Idle:              db.Idle,
+			WaitCount:         db.WaitCount,
+			WaitSeconds:       db.WaitDuration.Seconds(),
+			MaxIdleClosed:     db.MaxIdleClosed,
+			MaxIdleTimeClosed: db.MaxIdleTimeClosed,
+			MaxLifetimeClosed: db.MaxLifetimeClosed,
+		},
+		Runtime: Runtime{
+			Goroutines: runtime.NumGoroutine(),
+			HeapBytes:  mem.HeapAlloc,
+			GCs:        mem.NumGC,
+		},
+		Errors: r.Errors(),
+	}
+}
+
+// requests returns the request counters.
+func (r *Recorder) requests() Requests {
+	req := Requests{
+		Total:          r.total.Load(),
+		ClientErrors:   r.clientErrors.Load(),
+		ServerErrors:   r.serverErrors.Load(),
+		Timed:          r.timed.Load(),
+		LatencySeconds: time.Duration(r.latency.Load()).Seconds(),
+		LatencyBuckets: make([]int64, len(r.buckets)),
+		LatencyBounds:  latencyBounds[:],
+	}
+	for i := range r.buckets {
+		req.LatencyBuckets[i] = r.buckets[i].Load()
+	}
+	return req
+}
+
+// Sub returns the requests counted since prev, an earlier snapshot of the
+// same counters.
+func (r Requests) Sub(prev Requests) Requests {
+	d := Requests{
+		Total:          r.Total - prev.Total,
+		ClientErrors:   r.ClientErrors - prev.ClientErrors,
+		ServerErrors:   r.ServerErrors - prev.ServerErrors,
+		Timed:          r.Timed - prev.Timed,
+		LatencySeconds: r.LatencySeconds - prev.LatencySeconds,
+		LatencyBuckets: make([]int64, len(r.LatencyBuckets)),
+		LatencyBounds:  r.LatencyBounds,
+	}
+	for i, n := range r.LatencyBuckets {
+		if i < len(prev.LatencyBuckets) {
+			n -= prev.LatencyBuckets[i]
+		}
+		d.LatencyBuckets[i] = n
+	}
+	return d
+}
+
+// MeanLatency returns the mean latency of the timed requests, in seconds, or
+// 0 if there are none.
+func (r Requests) MeanLatency() float64 {
+	if r.Timed == 0 {
+		return 0
+	}
+	return r.LatencySeconds / float64(r.Timed)
+}
+
+// Quantile estimates the latency, in seconds, that the fraction q of the
+// timed requests completed within, assuming latencies spread evenly within
+// each bucket. Requests slower than every bound count as taking the largest
+// one. It returns 0 if there are no timed requests.
+func (r Requests) Quantile(q float64) float64 {
+	var total int64
+	for _, n := range r.LatencyBuckets {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+	rank := q * float64(total)
+	var seen int64
+	for i, n := range r.LatencyBuckets {
+		if i >= len(r.LatencyBounds) {
+			break
+		}
+		if n > 0 && float64(seen+n) >= rank {
+			lower := 0.0
+			if i > 0 {
+				lower = r.LatencyBounds[i-1]
+			}
+			return lower + (r.LatencyBounds[i]-lower)*(rank-float64(seen))/float64(n)
+		}
+		seen += n
+	}
+	if len(r.LatencyBounds) == 0 {
+		return 0
+	}
+	return r.LatencyBounds[len(r.LatencyBounds)-1]
+}
+
+// Ratio returns the fraction of cache lookups that found a value, or 0 if
+// there were none.
+func (c Cache) Ratio() float64 {
+	if c.Hits+c.Misses == 0 {
+		return 0
+	}
+	return float64(c.Hits) / float64(c.Hits+c.Misses)
+}
+
+// Sub returns the cache use since prev, an earlier snapshot of the same
+// counters.
+func (c Cache) Sub(prev Cache) Cache {
+	return Cache{
+		Hits:        c.Hits - prev.Hits,
+		Misses:      c.Misses - prev.Misses,
+		KeysAdded:   c.KeysAdded - prev.KeysAdded,
+		KeysEvicted: c.KeysEvicted - prev.KeysEvicted,
+		CostAdded:   c.CostAdded - prev.CostAdded,
+		CostEvicted: c.CostEvicted - prev.CostEvicted,
+	}
+}
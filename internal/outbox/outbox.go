@@ -0,0 +1,274 @@
+// Package outbox keeps messages that could not be posted yet in a local
+// SQLite database and posts them once the server can be reached again.
+package outbox
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/db"
+)
+
+// Statuses of an entry.
+const (
+	// Queued entries are posted by the next flush.
+	Queued = "queued"
+	// Failed entries were refused by the server and are left for the user
+	// to look at.
+	Failed = "failed"
+)
+
+// Retry delays. After the nth failed attempt an entry is due again after
+// baseBackoff * 2^(n-1), up to maxBackoff.
+const (
+	baseBackoff = 30 * time.Second
+	maxBackoff  = time.Hour
+)
+
+// schema creates the tables of the outbox. Times are stored as Unix seconds,
+// which every SQLite driver reads back the same way.
+const schema = `
+CREATE TABLE IF NOT EXISTS entries (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  server TEXT NOT NULL,
+  user TEXT NOT NULL,
+  channel TEXT NOT NULL,
+  body TEXT NOT NULL,
+  idempotency_key TEXT NOT NULL UNIQUE,
+  status TEXT NOT NULL DEFAULT 'queued',
+  attempts INTEGER NOT NULL DEFAULT 0,
+  next_attempt_at INTEGER NOT NULL,
+  last_error TEXT NOT NULL DEFAULT '',
+  created_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS entries_server ON entries (server, user, id);
+`
+
+// Entry is a message waiting to be posted.
+type Entry struct {
+	ID int64 `json:"id"`
+	// Server is the URL of the server the message is for, and User the user
+	// posting it.
+	Server  string `json:"server"`
+	User    string `json:"user"`
+	Channel string `json:"channel"`
+	Body    string `json:"body"`
+	// Key is sent with every attempt, so the server posts the message once
+	// however many attempts reach it.
+	Key           string    `json:"idempotency_key"`
+	Status        string    `json:"status"`
+	Attempts      int       `json:"attempts"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	LastError     string    `json:"last_error"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Outbox is a local queue of messages.
+type Outbox struct {
+	db *sql.DB
+}
+
+// Open opens the outbox database at path, creating it if needed.
+func Open(path string) (*Outbox, error) {
+	conn, err := db.Open("", path+"?_busy_timeout=5000&_journal_mode=WAL")
+	if err != nil {
+		return nil, fmt.Errorf("open outbox: %w", err)
+	}
+	if _, err := conn.Exec(schema); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("create outbox: %w", err)
+	}
+	return &Outbox{db: conn}, nil
+}
+
+// Close closes the database.
+func (o *Outbox) Close() error {
+	return o.db.Close()
+}
+
+// Add queues a message for posting right away and returns its entry.
+func (o *Outbox) Add(ctx context.Context, server, user, channel, body string) (Entry, error) {
+	now := time.Now()
+	res, err := o.db.ExecContext(ctx, `
+		INSERT INTO entries (server, user, channel, body, idempotency_key, next_attempt_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		server, user, channel, body, rand.Text(), now.Unix(), now.Unix())
+	if err != nil {
+		return Entry{}, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return Entry{}, err
+	}
+	return o.Get(ctx, id)
+}
+
+// Get returns an entry. It returns sql.ErrNoRows if there is none with id.
+func (o *Outbox) Get(ctx context.Context, id int64) (Entry, error) {
+	entries, err := o.query(ctx, `WHERE id = ?`, id)
+	if err != nil {
+		return Entry{}, err
+	}
+	if len(entries) == 0 {
+		return Entry{}, sql.ErrNoRows
+	}
+	return entries[0], nil
+}
+
+// List returns every entry, oldest first.
+func (o *Outbox) List(ctx context.Context) ([]Entry, error) {
+	return o.query(ctx, `ORDER BY id`)
+}
+
+// Counts returns the number of queued and failed entries for user on server.
+func (o *Outbox) Counts(ctx context.Context, server, user string) (queued, failed int, err error) {
+	err = o.db.QueryRowContext(ctx, `
+		SELECT
+		  COALESCE(SUM(status = 'queued'), 0),
+		  COALESCE(SUM(status = 'failed'), 0)
+		FROM entries WHERE server = ? AND user = ?`, server, user).Scan(&queued, &failed)
+	return queued, failed, err
+}
+
+// Drop deletes the entries with ids and returns how many there were.
+func (o *Outbox) Drop(ctx context.Context, ids ...int64) (int, error) {
+	n := 0
+	for _, id := range ids {
+		res, err := o.db.ExecContext(ctx, `DELETE FROM entries WHERE id = ?`, id)
+		if err != nil {
+			return n, err
+		}
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return n, err
+		}
+		n += int(rows)
+	}
+	return n, nil
+}
+
+// DropStatus deletes every entry with status, or every entry when status is
+// empty, and returns how many there were.
+func (o *Outbox) DropStatus(ctx context.Context, status string) (int, error) {
+	res, err := o.db.ExecContext(ctx, `DELETE FROM entries WHERE ? = '' OR status = ?`, status, status)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// FlushOptions selects the entries Flush posts and how.
+type FlushOptions struct {
+	// Server and User select the entries of one user on one server.
+	Server, User string
+	// Due leaves the entries whose retry delay has not passed yet for later.
+	Due bool
+	// Failed retries failed entries too.
+	Failed bool
+	// Retryable reports whether an error of send is worth retrying, such as
+	// a network error, rather than a refusal of the message.
+	Retryable func(error) bool
+}
+
+// FlushResult reports what Flush did.
+type FlushResult struct {
+	// Sent and Failed count the entries posted and refused by this flush.
+	Sent, Failed int
+	// Remaining is the number of entries still queued.
+	Remaining int
+	// Err is the retryable error that stopped the flush, if any.
+	Err error
+}
+
+// Flush posts the selected entries with send, oldest first, deleting those
+// posted. Refused entries are marked failed. A retryable error leaves the
+// entry queued with a longer delay and stops the flush, so that messages are
+// posted in order and an unreachable server is not tried once per entry.
+func (o *Outbox) Flush(ctx context.Context, opts FlushOptions, send func(context.Context, Entry) error) (FlushResult, error) {
+	var res FlushResult
+	statuses := "status = 'queued'"
+	if opts.Failed {
+		statuses = "status IN ('queued', 'failed')"
+	}
+	entries, err := o.query(ctx, `WHERE server = ? AND user = ? AND `+statuses+` ORDER BY id`, opts.Server, opts.User)
+	if err != nil {
+		return res, err
+	}
+
+	now := time.Now()
+	for i, e := range entries {
+		if opts.Due && e.NextAttemptAt.After(now) {
+			res.Remaining = len(entries) - i
+			break
+		}
+
+		err := send(ctx, e)
+		if err == nil {
+			if _, err := o.db.ExecContext(ctx, `DELETE FROM entries WHERE id = ?`, e.ID); err != nil {
+				return res, err
+			}
+			res.Sent++
+			continue
+		}
+		if ctx.Err() != nil {
+			return res, ctx.Err()
+		}
+
+		attempts := e.Attempts + 1
+		if opts.Retryable != nil && opts.Retryable(err) {
+			next := time.Now().Add(backoff(attempts))
+			if _, err := o.db.ExecContext(ctx, `
+				UPDATE entries SET status = 'queued', attempts = ?, next_attempt_at = ?, last_error = ? WHERE id = ?`,
+				attempts, next.Unix(), err.Error(), e.ID); err != nil {
+				return res, err
+			}
+			res.Remaining = len(entries) - i
+			res.Err = err
+			break
+		}
+		if _, err := o.db.ExecContext(ctx, `
+			UPDATE entries SET status = 'failed', attempts = ?, last_error = ? WHERE id = ?`,
+			attempts, err.Error(), e.ID); err != nil {
+			return res, err
+		}
+		res.Failed++
+	}
+	return res, nil
+}
+
+// query returns the entries matching the SQL clauses that follow FROM.
+func (o *Outbox) query(ctx context.Context, clauses string, args ...any) ([]Entry, error) {
+	rows, err := o.db.QueryContext(ctx, `
+		SELECT id, server, user, channel, body, idempotency_key, status, attempts, next_attempt_at, last_error, created_at
+		FROM entries `+clauses, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		var e Entry
+		var next, created int64
+		if err := rows.Scan(&e.ID, &e.Server, &e.User, &e.Channel, &e.Body, &e.Key, &e.Status,
+			&e.Attempts, &next, &e.LastError, &created); err != nil {
+			return nil, err
+		}
+		e.NextAttemptAt, e.CreatedAt = time.Unix(next, 0), time.Unix(created, 0)
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// backoff returns how long to wait before the attempt after the nth.
+func backoff(n int) time.Duration {
+	d := baseBackoff
+	for i := 1; i < n && d < maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxBackoff)
+}
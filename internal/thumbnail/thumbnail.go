@@ -0,0 +1,84 @@
+package thumbnail
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	// Register the decoders for the image formats thumbnails are made of.
+	_ "image/gif"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// maxPixels is the largest image, in pixels, a thumbnail is made of. It
+// guards against images that decompress to huge bitmaps.
+const maxPixels = 50_000_000
+
+// ErrTooLarge is returned for images with more than maxPixels pixels.
+var ErrTooLarge = errors.New("image too large for a thumbnail")
+
+// Thumbnail is an encoded thumbnail image.
+type Thumbnail struct {
+	Data        []byte
+	ContentType string
+}
+
+// Supported reports whether thumbnails can be made of images of contentType.
+func Supported(contentType string) bool {
+	switch contentType {
+	case "image/png", "image/jpeg", "image/gif", "image/webp":
+		return true
+	}
+	return false
+}
+
+// Make decodes the image read from r and scales it down to fit in a square
+// of size pixels, keeping its aspect ratio. Images that are already small
+// enough keep their size. Opaque images are encoded as JPEG and others as
+// PNG, to keep their transparency.
+func Make(r io.ReadSeeker, size int) (Thumbnail, error) {
+	cfg, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return Thumbnail{}, err
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return Thumbnail{}, ErrTooLarge
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return Thumbnail{}, err
+	}
+
+	src, _, err := image.Decode(r)
+	if err != nil {
+		return Thumbnail{}, err
+	}
+
+	dst := image.NewRGBA(fit(src.Bounds(), size))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+
+	var buf bytes.Buffer
+	if dst.Opaque() {
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85})
+		return Thumbnail{Data: buf.Bytes(), ContentType: "image/jpeg"}, err
+	}
+	err = png.Encode(&buf, dst)
+	return Thumbnail{Data: buf.Bytes(), ContentType: "image/png"}, err
+}
+
+// fit returns the bounds of an image of b's aspect ratio fitting in a square
+// of size pixels, without scaling up.
+func fit(b image.Rectangle, size int) image.Rectangle {
+	w, h := b.Dx(), b.Dy()
+	if w <= size && h <= size {
+		return image.Rect(0, 0, w, h)
+	}
+	if w >= h {
+		return image.Rect(0, 0, size, max(1, h*size/w))
+	}
+	return image.Rect(0, 0, max(1, w*size/h), size)
+}
@@ -0,0 +1,161 @@
+package transfer
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/db"
+)
+
+// exportBatchSize is the number of messages read from the database at once.
+const exportBatchSize = 500
+
+// endOfTime stands in for an open upper bound of a Filter. SQLite's date
+// functions only handle years up to 9999.
+var endOfTime = time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)
+
+// Export writes the messages matching filter to w in format, oldest first,
+// and returns how many it wrote. Deleted messages are left out. Messages are
+// read in batches, so a reply is always written after its root.
+func Export(ctx context.Context, q db.Querier, w io.Writer, format Format, filter Filter) (int, error) {
+	until := filter.Until
+	if until.IsZero() {
+		until = endOfTime
+	}
+
+	bw := bufio.NewWriter(w)
+	enc := newEncoder(bw, format)
+	n := 0
+	var afterID int64
+	for {
+		rows, err := q.ExportMessages(ctx, db.ExportMessagesParams{
+			AfterID: afterID,
+			Since:   sqliteTime(filter.Since),
+			Until:   sqliteTime(until),
+			Limit:   exportBatchSize,
+		})
+		if err != nil {
+			return n, err
+		}
+		for _, row := range rows {
+			if err := enc.Encode(Record(row)); err != nil {
+				return n, err
+			}
+			n++
+		}
+		if len(rows) < exportBatchSize {
+			break
+		}
+		afterID = rows[len(rows)-1].ID
+	}
+
+	if err := enc.Close(); err != nil {
+		return n, err
+	}
+	return n, bw.Flush()
+}
+
+// encoder writes records in one format.
+type encoder interface {
+	Encode(Record) error
+	// Close writes whatever ends the file. It does not close the writer.
+	Close() error
+}
+
+// newEncoder returns an encoder writing format to w.
+func newEncoder(w io.Writer, format Format) encoder {
+	switch format {
+	case CSV:
+		return &csvEncoder{w: csv.NewWriter(w)}
+	case JSON:
+		return &jsonEncoder{w: w}
+	}
+	return ndjsonEncoder{enc: json.NewEncoder(w)}
+}
+
+// ndjsonEncoder writes one JSON object per line.
+type ndjsonEncoder struct {
+	enc *json.Encoder
+}
+
+func (e ndjsonEncoder) Encode(r Record) error { return e.enc.Encode(r) }
+
+func (e ndjsonEncoder) Close() error { return nil }
+
+// jsonEncoder writes a JSON array with one element per line.
+type jsonEncoder struct {
+	w     io.Writer
+	count int
+}
+
+func (e *jsonEncoder) Encode(r Record) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	sep := ",\n"
+	if e.count == 0 {
+		sep = "[\n"
+	}
+	e.count++
+	if _, err := io.WriteString(e.w, sep); err != nil {
+		return err
+	}
+	_, err = e.w.Write(data)
+	return err
+}
+
+func (e *jsonEncoder) Close() error {
+	end := "\n]\n"
+	if e.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(e.w, end)
+	return err
+}
+
+// csvEncoder writes a header row followed by one row per record.
+type csvEncoder struct {
+	w      *csv.Writer
+	header bool
+}
+
+func (e *csvEncoder) Encode(r Record) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	parentID := ""
+	if r.ParentID != nil {
+		parentID = strconv.FormatInt(*r.ParentID, 10)
+	}
+	return e.w.Write([]string{
+		strconv.FormatInt(r.ID, 10),
+		r.Channel,
+		parentID,
+		r.Author,
+		r.Body,
+		r.CreatedAt.UTC().Format(time.RFC3339),
+		r.UpdatedAt.UTC().Format(time.RFC3339),
+	})
+}
+
+func (e *csvEncoder) Close() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvEncoder) writeHeader() error {
+	if e.header {
+		return nil
+	}
+	e.header = true
+	return e.w.Write(csvColumns)
+}
@@ -0,0 +1,410 @@
+package transfer
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/db"
+)
+
+// DefaultBatchSize is the number of records an Importer writes per
+// transaction when BatchSize is not set.
+const DefaultBatchSize = 500
+
+// maxLineLength is the longest NDJSON line read, in bytes.
+const maxLineLength = 1 << 20
+
+// Validator validates a Record using its struct tags. form.Validator
+// implements it.
+type Validator interface {
+	Validate(i interface{}) error
+}
+
+// Importer creates messages from exported files.
+type Importer struct {
+	Store     db.Store
+	Validator Validator
+	// Tags returns the hashtags of a message body, to tag imported messages
+	// with. Imported messages notify no one.
+	Tags func(body string) []string
+	// BatchSize is the number of records written per transaction.
+	BatchSize int
+}
+
+// LineError is the reason a record was not imported.
+type LineError struct {
+	// Line is the line of the record in NDJSON and CSV files, or its
+	// position in the array of a JSON file, counting from 1.
+	Line int    `json:"line"`
+	Err  string `json:"error"`
+}
+
+// Error implements the error interface.
+func (e LineError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+}
+
+// Report is the outcome of an import.
+type Report struct {
+	Imported int         `json:"imported"`
+	Errors   []LineError `json:"errors"`
+}
+
+// Import reads records in format from r and creates a message for each
+// valid one, committing every BatchSize records. Records that are malformed,
+// fail validation, name an unknown channel or reply to a message not
+// imported before them are skipped and listed in the report. An error is
+// only returned when reading r or writing to the database fails; the batches
+// committed before it stay imported.
+func (im *Importer) Import(ctx context.Context, r io.Reader, format Format) (Report, error) {
+	dec, err := newDecoder(r, format)
+	if err != nil {
+		return Report{}, err
+	}
+	batchSize := im.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	report := Report{Errors: []LineError{}}
+	run := &importRun{
+		Importer: im,
+		report:   &report,
+		channels: make(map[string]int64),
+		ids:      make(map[int64]int64),
+	}
+	var batch []numberedRecord
+	for {
+		rec, line, err := dec.Decode()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var lineErr LineError
+		if errors.As(err, &lineErr) {
+			report.Errors = append(report.Errors, lineErr)
+			continue
+		}
+		if err != nil {
+			return report, err
+		}
+
+		batch = append(batch, numberedRecord{Record: rec, line: line})
+		if len(batch) == batchSize {
+			if err := run.write(ctx, batch); err != nil {
+				return report, err
+			}
+			batch = batch[:0]
+		}
+	}
+	err = run.write(ctx, batch)
+
+	// Malformed records are reported as they are read, the others as their
+	// batch is written.
+	slices.SortStableFunc(report.Errors, func(a, b LineError) int { return cmp.Compare(a.Line, b.Line) })
+	return report, err
+}
+
+// numberedRecord is a record with the line it was read from.
+type numberedRecord struct {
+	Record
+	line int
+}
+
+// importRun holds the state of one import across its batches.
+type importRun struct {
+	*Importer
+	report *Report
+	// channels maps channel slugs to IDs.
+	channels map[string]int64
+	// ids maps the IDs of imported records to the IDs of their messages.
+	ids map[int64]int64
+}
+
+// write imports batch in one transaction.
+func (run *importRun) write(ctx context.Context, batch []numberedRecord) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	var imported int
+	var lineErrs []LineError
+	ids := make(map[int64]int64)
+	err := run.Store.InTx(ctx, func(q db.Querier) error {
+		for _, rec := range batch {
+			msg, err := run.create(ctx, q, rec.Record, ids)
+			if errors.As(err, new(recordError)) {
+				lineErrs = append(lineErrs, LineError{Line: rec.line, Err: err.Error()})
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("line %d: %w", rec.line, err)
+			}
+			if rec.ID != 0 {
+				ids[rec.ID] = msg.ID
+			}
+			imported++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Only count the batch once it is committed.
+	for oldID, newID := range ids {
+		run.ids[oldID] = newID
+	}
+	run.report.Imported += imported
+	run.report.Errors = append(run.report.Errors, lineErrs...)
+	return nil
+}
+
+// recordError is a problem with a record, as opposed to the database.
+type recordError string
+
+func (e recordError) Error() string { return string(e) }
+
+// create validates rec and creates its message. batchIDs holds the IDs
+// mapped by the uncommitted records of the current batch.
+func (run *importRun) create(ctx context.Context, q db.Querier, rec Record, batchIDs map[int64]int64) (db.Message, error) {
+	rec.Channel = strings.TrimSpace(rec.Channel)
+	rec.Author = strings.TrimSpace(rec.Author)
+	rec.Body = strings.TrimSpace(rec.Body)
+	if err := run.Validator.Validate(rec); err != nil {
+		return db.Message{}, recordError(err.Error())
+	}
+
+	channelID, err := run.channel(ctx, q, rec.Channel)
+	if err != nil {
+		return db.Message{}, err
+	}
+
+	var parentID *int64
+	if rec.ParentID != nil {
+		id, ok := batchIDs[*rec.ParentID]
+		if !ok {
+			id, ok = run.ids[*rec.ParentID]
+		}
+		if !ok {
+			return db.Message{}, recordError(fmt.Sprintf("parent message %d was not imported before this reply", *rec.ParentID))
+		}
+		parentID = &id
+	}
+
+	createdAt := rec.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	updatedAt := rec.UpdatedAt
+	if updatedAt.IsZero() {
+		updatedAt = createdAt
+	}
+
+	msg, err := q.ImportMessage(ctx, db.ImportMessageParams{
+		Body:      rec.Body,
+		Author:    rec.Author,
+		ParentID:  parentID,
+		ChannelID: channelID,
+		CreatedAt: sqliteTime(createdAt),
+		UpdatedAt: sqliteTime(updatedAt),
+	})
+	if err != nil {
+		return db.Message{}, err
+	}
+	if run.Tags != nil {
+		if err := db.SetMessageTags(ctx, q, msg.ID, run.Tags(msg.Body)); err != nil {
+			return db.Message{}, err
+		}
+	}
+	return msg, nil
+}
+
+// channel returns the ID of the channel with slug.
+func (run *importRun) channel(ctx context.Context, q db.Querier, slug string) (int64, error) {
+	if id, ok := run.channels[slug]; ok {
+		return id, nil
+	}
+	channel, err := q.GetChannel(ctx, slug)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, recordError(fmt.Sprintf("channel %q does not exist", slug))
+	}
+	if err != nil {
+		return 0, err
+	}
+	run.channels[slug] = channel.ID
+	return channel.ID, nil
+}
+
+// decoder reads records in one format.
+type decoder interface {
+	// Decode returns the next record and its line. A malformed record
+	// yields a LineError, and the end of the input io.EOF.
+	Decode() (Record, int, error)
+}
+
+// newDecoder returns a decoder reading format from r.
+func newDecoder(r io.Reader, format Format) (decoder, error) {
+	switch format {
+	case CSV:
+		return newCSVDecoder(r)
+	case JSON:
+		return newJSONDecoder(r)
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineLength)
+	return &ndjsonDecoder{scanner: scanner}, nil
+}
+
+// ndjsonDecoder reads one JSON object per line, skipping blank lines.
+type ndjsonDecoder struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (d *ndjsonDecoder) Decode() (Record, int, error) {
+	for d.scanner.Scan() {
+		d.line++
+		line := d.scanner.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return Record{}, d.line, LineError{Line: d.line, Err: "invalid JSON: " + err.Error()}
+		}
+		return rec, d.line, nil
+	}
+	if err := d.scanner.Err(); err != nil {
+		return Record{}, d.line, err
+	}
+	return Record{}, d.line, io.EOF
+}
+
+// jsonDecoder reads the elements of a JSON array one at a time.
+type jsonDecoder struct {
+	dec   *json.Decoder
+	index int
+}
+
+func newJSONDecoder(r io.Reader) (*jsonDecoder, error) {
+	dec := json.NewDecoder(r)
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("read JSON: %w", err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return nil, errors.New("read JSON: input is not an array")
+	}
+	return &jsonDecoder{dec: dec}, nil
+}
+
+func (d *jsonDecoder) Decode() (Record, int, error) {
+	if !d.dec.More() {
+		return Record{}, d.index, io.EOF
+	}
+	d.index++
+	var raw json.RawMessage
+	if err := d.dec.Decode(&raw); err != nil {
+		// The array itself is broken, so the rest cannot be read.
+		return Record{}, d.index, fmt.Errorf("read JSON element %d: %w", d.index, err)
+	}
+	var rec Record
+	if err := json.Unmarshal(raw, &rec); err != nil {
+		return Record{}, d.index, LineError{Line: d.index, Err: "invalid record: " + err.Error()}
+	}
+	return rec, d.index, nil
+}
+
+// csvDecoder reads rows after a header row naming their columns. Columns may
+// come in any order, and only channel, author and body are required.
+type csvDecoder struct {
+	r       *csv.Reader
+	columns map[string]int
+}
+
+func newCSVDecoder(r io.Reader) (*csvDecoder, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read CSV header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, name := range []string{"channel", "author", "body"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("read CSV header: missing column %q", name)
+		}
+	}
+	return &csvDecoder{r: cr, columns: columns}, nil
+}
+
+func (d *csvDecoder) Decode() (Record, int, error) {
+	row, err := d.r.Read()
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return Record{}, parseErr.StartLine, LineError{Line: parseErr.StartLine, Err: "invalid CSV: " + parseErr.Err.Error()}
+	}
+	if err != nil {
+		return Record{}, 0, err
+	}
+	line, _ := d.r.FieldPos(0)
+
+	field := func(name string) string {
+		if i, ok := d.columns[name]; ok && i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+	rec := Record{Channel: field("channel"), Author: field("author"), Body: field("body")}
+	if rec.ID, err = parseOptionalInt(field("id")); err != nil {
+		return Record{}, line, LineError{Line: line, Err: "invalid id: " + err.Error()}
+	}
+	if s := strings.TrimSpace(field("parent_id")); s != "" {
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return Record{}, line, LineError{Line: line, Err: "invalid parent_id: " + err.Error()}
+		}
+		rec.ParentID = &id
+	}
+	if rec.CreatedAt, err = parseOptionalTime(field("created_at")); err != nil {
+		return Record{}, line, LineError{Line: line, Err: "invalid created_at: " + err.Error()}
+	}
+	if rec.UpdatedAt, err = parseOptionalTime(field("updated_at")); err != nil {
+		return Record{}, line, LineError{Line: line, Err: "invalid updated_at: " + err.Error()}
+	}
+	return rec, line, nil
+}
+
+// parseOptionalInt parses s as an integer, with an empty s meaning zero.
+func parseOptionalInt(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+// parseOptionalTime parses s as an RFC 3339 timestamp, with an empty s
+// meaning the zero time.
+func parseOptionalTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
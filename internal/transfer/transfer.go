@@ -0,0 +1,105 @@
+// Package transfer exports messages to files and imports them back, in
+// NDJSON, CSV or JSON. Both directions stream, so the size of a file is not
+// limited by memory.
+package transfer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Format is a file format messages are exported to and imported from.
+type Format string
+
+// The supported formats.
+const (
+	// NDJSON is one JSON object per line.
+	NDJSON Format = "ndjson"
+	// CSV is comma-separated values with a header row naming the columns.
+	CSV Format = "csv"
+	// JSON is a single JSON array of objects.
+	JSON Format = "json"
+)
+
+// Formats are the supported formats, the default first.
+var Formats = []Format{NDJSON, CSV, JSON}
+
+// ParseFormat returns the Format named s, case-insensitively. An empty s
+// selects NDJSON.
+func ParseFormat(s string) (Format, error) {
+	if s == "" {
+		return NDJSON, nil
+	}
+	for _, f := range Formats {
+		if strings.EqualFold(s, string(f)) {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown format %q (want ndjson, csv or json)", s)
+}
+
+// ContentType returns the MIME type of files in f.
+func (f Format) ContentType() string {
+	switch f {
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case JSON:
+		return "application/json"
+	}
+	return "application/x-ndjson"
+}
+
+// FormatOf returns the Format of a MIME type, and false if there is none.
+func FormatOf(contentType string) (Format, bool) {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	switch strings.TrimSpace(strings.ToLower(mediaType)) {
+	case "application/x-ndjson", "application/ndjson", "application/jsonl":
+		return NDJSON, true
+	case "text/csv":
+		return CSV, true
+	case "application/json":
+		return JSON, true
+	}
+	return "", false
+}
+
+// Record is a message as it is exported and imported. ID and ParentID only
+// link replies to their root within a file; imported messages get new IDs.
+type Record struct {
+	ID        int64     `json:"id"`
+	Channel   string    `json:"channel" label:"Channel" validate:"required"`
+	ParentID  *int64    `json:"parent_id"`
+	Author    string    `json:"author" label:"Author" validate:"required,max=100"`
+	Body      string    `json:"body" label:"Message" validate:"required,max=2000"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// csvColumns are the columns of CSV files, in the order they are exported.
+var csvColumns = []string{"id", "channel", "parent_id", "author", "body", "created_at", "updated_at"}
+
+// Filter limits the messages exported to those created in [Since, Until).
+// A zero time leaves that end of the range open.
+type Filter struct {
+	Since time.Time
+	Until time.Time
+}
+
+// ParseTime parses the bound of a date range, given either as an RFC 3339
+// timestamp or as a date, which stands for midnight UTC.
+func ParseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q (want YYYY-MM-DD or RFC 3339)", s)
+	}
+	return t, nil
+}
+
+// sqliteTime formats t the way SQLite's CURRENT_TIMESTAMP does.
+func sqliteTime(t time.Time) string {
+	return t.UTC().Format(time.DateTime)
+}
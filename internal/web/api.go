@@ -0,0 +1,186 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/db"
+	"github.com/dunamismax/go-modern-scaffold/internal/events"
+	"github.com/dunamismax/go-modern-scaffold/internal/form"
+	"github.com/dunamismax/go-modern-scaffold/internal/markdown"
+	"github.com/labstack/echo/v4"
+)
+
+// apiThread is the JSON representation of a thread.
+type apiThread struct {
+	Message db.Message   `json:"message"`
+	Replies []db.Message `json:"replies"`
+}
+
+// apiSearchResult is the JSON representation of a search result: the
+// message as listed without a search, with a snippet of the matching text.
+type apiSearchResult struct {
+	MessageView
+	Snippet string `json:"snippet"`
+}
+
+// APIListMessages returns a page of the root messages of a channel as JSON,
+// newest first. The channel is named by the :slug route parameter or the
+// "channel" query parameter and defaults to the general channel. The page
+// holds up to "limit" messages (50 by default, at most 200) with IDs below
+// the "before" query parameter, so the ID of the last message of a page
+// gives the next one. When the "q" query
+// parameter is set it returns ranked search results instead, each with an
+// HTML "snippet" of the matching text in which matched terms are wrapped in
+// <mark> elements. When the "tag" query parameter is set it returns the
+// messages and replies in any channel tagged with it, newest first.
+func (h *Handlers) APIListMessages(c echo.Context) error {
+	if tag := c.QueryParam("tag"); tag != "" {
+		messages, err := h.taggedMessages(c, markdown.NormalizeTag(tag))
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, messages)
+	}
+
+	channel, err := h.channel(c)
+	if err != nil {
+		return err
+	}
+
+	if q := c.QueryParam("q"); q != "" {
+		query := ftsQuery(q)
+		if query == "" {
+			return c.JSON(http.StatusOK, []apiSearchResult{})
+		}
+		rows, err := h.queries.SearchMessages(c.Request().Context(), db.SearchMessagesParams{
+			Query:     query,
+			ChannelID: channel.ID,
+			Limit:     searchResultLimit,
+		})
+		if err != nil {
+			return internalError(err, "Failed to search messages")
+		}
+		views := make([]MessageView, len(rows))
+		for i, row := range rows {
+			views[i] = MessageView{Message: row.Message, ReplyCount: row.ReplyCount}
+		}
+		if views, err = h.fillViews(c, views); err != nil {
+			return err
+		}
+		results := make([]apiSearchResult, len(rows))
+		for i, row := range rows {
+			results[i] = apiSearchResult{MessageView: views[i], Snippet: snippetHTML(row.Snippet)}
+		}
+		return c.JSON(http.StatusOK, results)
+	}
+
+	page, err := parseMessagePage(c)
+	if err != nil {
+		return err
+	}
+	messages, err := h.messageList(c, channel.ID, page)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, messages)
+}
+
+// APIGetMessage returns a single message as JSON.
+func (h *Handlers) APIGetMessage(c echo.Context) error {
+	msg, err := h.message(c)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, msg)
+}
+
+// APICreateMessage creates a message from a JSON or form body and returns it.
+// The channel is chosen as for APIListMessages. Files in the "files" field of
+// a multipart form are attached to the message. A request repeating the
+// Idempotency-Key header of an earlier one returns the message that created
+// with status 200 instead.
+func (h *Handlers) APICreateMessage(c echo.Context) error {
+	channel, err := h.channel(c)
+	if err != nil {
+		return err
+	}
+
+	var input MessageInput
+	if err := form.Bind(c, &input); err != nil {
+		return err
+	}
+	uploads, err := h.uploads(c)
+	if err != nil {
+		return err
+	}
+
+	msg, created, err := h.createAPIMessage(c, db.CreateMessageParams{
+		Body:      input.Body,
+		Author:    currentUser(c),
+		ChannelID: channel.ID,
+	}, uploads, "Failed to create message")
+	if err != nil {
+		return err
+	}
+	if !created {
+		return c.JSON(http.StatusOK, msg)
+	}
+
+	h.messageChanged(events.MessageCreated, msg)
+	return c.JSON(http.StatusCreated, msg)
+}
+
+// APIUpdateMessage replaces the body of a message and returns it.
+func (h *Handlers) APIUpdateMessage(c echo.Context) error {
+	msg, err := h.editableMessage(c)
+	if err != nil {
+		return err
+	}
+
+	var input MessageInput
+	if err := form.Bind(c, &input); err != nil {
+		return err
+	}
+
+	msg, err = h.updateMessage(c, db.UpdateMessageParams{
+		ID:     msg.ID,
+		Body:   input.Body,
+		Editor: currentUser(c),
+	})
+	if err != nil {
+		return internalError(err, "Failed to update message")
+	}
+
+	h.messageChanged(events.MessageUpdated, msg)
+	return c.JSON(http.StatusOK, msg)
+}
+
+// APIDeleteMessage soft-deletes a message.
+func (h *Handlers) APIDeleteMessage(c echo.Context) error {
+	msg, err := h.editableMessage(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.deleteMessage(c, msg); err != nil {
+		return err
+	}
+
+	h.messageChanged(events.MessageDeleted, msg)
+	return c.NoContent(http.StatusNoContent)
+}
+
+// APIMessageRevisions returns the previous revisions of a message as JSON,
+// newest first.
+func (h *Handlers) APIMessageRevisions(c echo.Context) error {
+	msg, err := h.message(c)
+	if err != nil {
+		return err
+	}
+
+	revisions, err := h.queries.GetMessageRevisions(c.Request().Context(), msg.ID)
+	if err != nil {
+		return internalError(err, "Failed to get message history")
+	}
+	return c.JSON(http.StatusOK, revisions)
+}
@@ -0,0 +1,361 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/blob"
+	"github.com/dunamismax/go-modern-scaffold/internal/config"
+	"github.com/dunamismax/go-modern-scaffold/internal/db"
+	"github.com/dunamismax/go-modern-scaffold/internal/events"
+	"github.com/dunamismax/go-modern-scaffold/internal/form"
+	"github.com/dunamismax/go-modern-scaffold/internal/thumbnail"
+	"github.com/dustin/go-humanize"
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/labstack/echo/v4"
+)
+
+// thumbnailSize is the width and height, in pixels, thumbnails fit in.
+const thumbnailSize = 320
+
+// attachmentCacheControl is the Cache-Control header of attachments. Blobs
+// never change once stored, so browsers may keep them for good.
+const attachmentCacheControl = "private, max-age=31536000, immutable"
+
+// upload is a file submitted with a message, checked against the upload
+// limits.
+type upload struct {
+	header      *multipart.FileHeader
+	contentType string
+}
+
+// storedUpload is an upload saved to blob storage, ready to be attached to a
+// message.
+type storedUpload struct {
+	upload
+	blob blob.Info
+}
+
+// ServeAttachment serves the file of an attachment.
+func (h *Handlers) ServeAttachment(c echo.Context) error {
+	att, err := h.attachment(c)
+	if err != nil {
+		return err
+	}
+
+	disposition := "attachment"
+	if thumbnail.Supported(att.ContentType) {
+		disposition = "inline"
+	}
+	return h.serveBlob(c, att.BlobKey, func(header http.Header) {
+		header.Set(echo.HeaderContentType, att.ContentType)
+		header.Set(echo.HeaderContentDisposition,
+			mime.FormatMediaType(disposition, map[string]string{"filename": att.Filename}))
+	})
+}
+
+// ServeThumbnail serves the thumbnail of an image attachment.
+func (h *Handlers) ServeThumbnail(c echo.Context) error {
+	att, err := h.attachment(c)
+	if err != nil {
+		return err
+	}
+	if att.ThumbnailKey == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Attachment has no thumbnail")
+	}
+	return h.serveBlob(c, *att.ThumbnailKey, nil)
+}
+
+// CleanupBlobs deletes the blobs no attachment refers to, such as the files
+// of uploads whose message could not be saved. Blobs modified within grace
+// are kept, since they may belong to an upload still in progress. It returns
+// the number of blobs deleted.
+func (h *Handlers) CleanupBlobs(ctx context.Context, grace time.Duration) (int, error) {
+	keys, err := h.queries.GetAttachmentBlobKeys(ctx)
+	if err != nil {
+		return 0, err
+	}
+	referenced := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		referenced[key] = true
+	}
+
+	var orphans []string
+	cutoff := time.Now().Add(-grace)
+	err = h.blobs.Walk(ctx, func(info blob.Info) error {
+		if !referenced[info.Key] && info.ModTime.Before(cutoff) {
+			orphans = append(orphans, info.Key)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for i, key := range orphans {
+		if err := h.blobs.Delete(ctx, key); err != nil {
+			return i, err
+		}
+		slog.Info("deleted orphaned blob", "key", key)
+	}
+	return len(orphans), nil
+}
+
+// uploads returns the files submitted in the "files" field of a multipart
+// form, after checking them against the upload limits. Files that break a
+// limit yield form.Errors.
+func (h *Handlers) uploads(c echo.Context) ([]upload, error) {
+	if !strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+		return nil, nil
+	}
+	mf, err := c.MultipartForm()
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "Invalid multipart form").SetInternal(err)
+	}
+
+	var uploads []upload
+	for _, fh := range mf.File["files"] {
+		if fh.Filename == "" && fh.Size == 0 {
+			continue // an empty file input
+		}
+		if len(uploads) == h.storage.MaxUploadFiles {
+			return nil, form.Errors{"files": fmt.Sprintf("At most %d files can be attached", h.storage.MaxUploadFiles)}
+		}
+		if fh.Size > h.storage.MaxUploadSize {
+			return nil, form.Errors{"files": fmt.Sprintf("%s is larger than %s", fh.Filename, humanize.Bytes(uint64(h.storage.MaxUploadSize)))}
+		}
+
+		contentType, err := detectContentType(fh)
+		if err != nil {
+			return nil, internalError(err, "Failed to read upload")
+		}
+		if !h.allowedType(contentType) {
+			return nil, form.Errors{"files": fmt.Sprintf("%s is not an allowed file type", fh.Filename)}
+		}
+		uploads = append(uploads, upload{header: fh, contentType: contentType})
+	}
+	return uploads, nil
+}
+
+// storeUploads saves uploads to blob storage.
+func (h *Handlers) storeUploads(ctx context.Context, uploads []upload) ([]storedUpload, error) {
+	stored := make([]storedUpload, len(uploads))
+	for i, u := range uploads {
+		var err error
+		if stored[i], err = h.storeUpload(ctx, u); err != nil {
+			return nil, err
+		}
+	}
+	return stored, nil
+}
+
+// storeUpload saves an upload to blob storage.
+func (h *Handlers) storeUpload(ctx context.Context, u upload) (storedUpload, error) {
+	f, err := u.header.Open()
+	if err != nil {
+		return storedUpload{}, err
+	}
+	defer f.Close()
+
+	info, err := h.blobs.Put(ctx, f)
+	if err != nil {
+		return storedUpload{}, err
+	}
+	return storedUpload{upload: u, blob: info}, nil
+}
+
+// attachUploads records stored uploads as attachments of a message, and
+// queues making the thumbnails of images.
+func attachUploads(ctx context.Context, q db.Querier, msg db.Message, stored []storedUpload) error {
+	for _, s := range stored {
+		att, err := q.CreateAttachment(ctx, db.CreateAttachmentParams{
+			MessageID:   msg.ID,
+			BlobKey:     s.blob.Key,
+			Filename:    s.header.Filename,
+			ContentType: s.contentType,
+			Size:        s.blob.Size,
+		})
+		if err != nil {
+			return err
+		}
+		if thumbnail.Supported(s.contentType) {
+			if _, err := thumbnailJob.Enqueue(ctx, q, thumbnailPayload{AttachmentID: att.ID}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// makeThumbnail makes the thumbnail of an image attachment and shows it to
+// connected clients. Images that cannot be decoded get no thumbnail.
+func (h *Handlers) makeThumbnail(ctx context.Context, p thumbnailPayload) error {
+	att, err := h.queries.GetAttachment(ctx, p.AttachmentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil // the message was deleted
+	}
+	if err != nil || att.ThumbnailKey != nil {
+		return err
+	}
+
+	f, _, err := h.blobs.Open(ctx, att.BlobKey)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	thumb, err := thumbnail.Make(f, thumbnailSize)
+	if err != nil {
+		slog.Warn("failed to make thumbnail", "attachment_id", att.ID, "filename", att.Filename, "error", err)
+		return nil
+	}
+	info, err := h.blobs.Put(ctx, bytes.NewReader(thumb.Data))
+	if err != nil {
+		return err
+	}
+	if err := h.queries.SetAttachmentThumbnail(ctx, db.SetAttachmentThumbnailParams{
+		ThumbnailKey: &info.Key,
+		ID:           att.ID,
+	}); err != nil {
+		return err
+	}
+
+	msg, err := h.queries.GetMessage(ctx, att.MessageID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	h.messageChanged(events.MessageUpdated, msg)
+	return nil
+}
+
+// withAttachments fills in the attachments of views with a query per
+// maxLookupIDs views.
+func (h *Handlers) withAttachments(c echo.Context, views []MessageView) ([]MessageView, error) {
+	if len(views) == 0 {
+		return views, nil
+	}
+
+	ids := make([]int64, len(views))
+	byID := make(map[int64]*MessageView, len(views))
+	for i := range views {
+		ids[i] = views[i].ID
+		byID[views[i].ID] = &views[i]
+		views[i].Attachments = []db.Attachment{}
+	}
+
+	for chunk := range slices.Chunk(ids, maxLookupIDs) {
+		attachments, err := h.queries.GetAttachments(c.Request().Context(), chunk)
+		if err != nil {
+			return nil, internalError(err, "Failed to get attachments")
+		}
+		for _, att := range attachments {
+			if view, ok := byID[att.MessageID]; ok {
+				view.Attachments = append(view.Attachments, att)
+			}
+		}
+	}
+	return views, nil
+}
+
+// attachment loads the attachment named by the :id route parameter.
+func (h *Handlers) attachment(c echo.Context) (db.Attachment, error) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return db.Attachment{}, echo.NewHTTPError(http.StatusBadRequest, "Invalid attachment ID")
+	}
+
+	att, err := h.queries.GetAttachment(c.Request().Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return db.Attachment{}, echo.NewHTTPError(http.StatusNotFound, "Attachment not found")
+	}
+	if err != nil {
+		return db.Attachment{}, internalError(err, "Failed to get attachment")
+	}
+	return att, nil
+}
+
+// serveBlob writes the blob stored under key, after setHeaders, if not nil,
+// has set the response headers describing it. Conditional and range requests
+// are answered by http.ServeContent using the key as ETag.
+func (h *Handlers) serveBlob(c echo.Context, key string, setHeaders func(http.Header)) error {
+	f, info, err := h.blobs.Open(c.Request().Context(), key)
+	if errors.Is(err, blob.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "File not found")
+	}
+	if err != nil {
+		return internalError(err, "Failed to open file")
+	}
+	defer f.Close()
+
+	header := c.Response().Header()
+	if setHeaders != nil {
+		setHeaders(header)
+	}
+	header.Set(echo.HeaderCacheControl, attachmentCacheControl)
+	header.Set("ETag", `"`+key+`"`)
+	header.Set(echo.HeaderXContentTypeOptions, "nosniff")
+	header.Set(echo.HeaderContentSecurityPolicy, "sandbox")
+	http.ServeContent(c.Response(), c.Request(), "", info.ModTime, f)
+	return nil
+}
+
+// UploadLimit returns middleware that rejects request bodies too large to
+// carry a message with the most and largest attachments cfg allows.
+func UploadLimit(cfg *config.Storage) echo.MiddlewareFunc {
+	limit := cfg.MaxUploadSize*int64(cfg.MaxUploadFiles) + 1<<20 // leave room for the other fields
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if req.ContentLength > limit {
+				return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "Upload is too large")
+			}
+			req.Body = http.MaxBytesReader(c.Response(), req.Body, limit)
+			return next(c)
+		}
+	}
+}
+
+// attachmentURL returns the URL an attachment's file is served at.
+func attachmentURL(id int64) string {
+	return "/attachments/" + strconv.FormatInt(id, 10)
+}
+
+// allowedType reports whether uploads of contentType are accepted.
+func (h *Handlers) allowedType(contentType string) bool {
+	for _, allowed := range h.storage.AllowedTypes {
+		if strings.EqualFold(contentType, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// detectContentType returns the MIME type of an uploaded file, detected from
+// its content, without parameters.
+func detectContentType(fh *multipart.FileHeader) (string, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	mtype, err := mimetype.DetectReader(f)
+	if err != nil {
+		return "", err
+	}
+	contentType, _, _ := strings.Cut(mtype.String(), ";")
+	return contentType, nil
+}
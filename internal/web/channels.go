@@ -0,0 +1,276 @@
+package web
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/db"
+	"github.com/dunamismax/go-modern-scaffold/internal/form"
+	"github.com/labstack/echo/v4"
+)
+
+// defaultChannelSlug is the slug of the channel shown on the index page. It
+// is created by the migrations and cannot be deleted.
+const defaultChannelSlug = "general"
+
+// maxSlugLength is the longest channel slug derived from a name, in bytes.
+const maxSlugLength = 40
+
+// RenderNewChannel renders the form for creating a channel.
+func (h *Handlers) RenderNewChannel(c echo.Context) error {
+	return renderComponent(c, ChannelPage(nil, ChannelInput{}, nil))
+}
+
+// CreateChannel creates a channel and redirects to it.
+func (h *Handlers) CreateChannel(c echo.Context) error {
+	var input ChannelInput
+	channel, err := h.createChannel(c, &input)
+	if err != nil {
+		var errs form.Errors
+		if errors.As(err, &errs) {
+			return renderInvalidForm(c, errs, "#channel-form", ChannelForm(nil, input, errs))
+		}
+		return err
+	}
+	return redirect(c, channelURL(channel.Slug))
+}
+
+// EditChannel renders the form for changing a channel.
+func (h *Handlers) EditChannel(c echo.Context) error {
+	channel, err := h.editableChannel(c)
+	if err != nil {
+		return err
+	}
+	input := ChannelInput{Name: channel.Name, Description: channel.Description}
+	return renderComponent(c, ChannelPage(&channel, input, nil))
+}
+
+// UpdateChannel saves the name and description of a channel and redirects to
+// it. The slug of a channel never changes, so links to it keep working.
+func (h *Handlers) UpdateChannel(c echo.Context) error {
+	var input ChannelInput
+	channel, err := h.updateChannel(c, &input)
+	if err != nil {
+		var errs form.Errors
+		if errors.As(err, &errs) {
+			return renderInvalidForm(c, errs, "#channel-form", ChannelForm(&channel, input, errs))
+		}
+		return err
+	}
+	return redirect(c, channelURL(channel.Slug))
+}
+
+// DeleteChannel deletes a channel and redirects to the index page.
+func (h *Handlers) DeleteChannel(c echo.Context) error {
+	if err := h.deleteChannel(c); err != nil {
+		return err
+	}
+	return redirect(c, "/")
+}
+
+// APIListChannels returns all channels as JSON, ordered by name.
+func (h *Handlers) APIListChannels(c echo.Context) error {
+	channels, err := h.queries.GetChannels(c.Request().Context())
+	if err != nil {
+		return internalError(err, "Failed to get channels")
+	}
+	return c.JSON(http.StatusOK, channels)
+}
+
+// APIGetChannel returns a single channel as JSON.
+func (h *Handlers) APIGetChannel(c echo.Context) error {
+	channel, err := h.channel(c)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, channel)
+}
+
+// APICreateChannel creates a channel and returns it.
+func (h *Handlers) APICreateChannel(c echo.Context) error {
+	var input ChannelInput
+	channel, err := h.createChannel(c, &input)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, channel)
+}
+
+// APIUpdateChannel changes the name and description of a channel and
+// returns it.
+func (h *Handlers) APIUpdateChannel(c echo.Context) error {
+	var input ChannelInput
+	channel, err := h.updateChannel(c, &input)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, channel)
+}
+
+// APIDeleteChannel deletes a channel.
+func (h *Handlers) APIDeleteChannel(c echo.Context) error {
+	if err := h.deleteChannel(c); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// createChannel binds input and creates a channel from it, with a slug
+// derived from its name.
+func (h *Handlers) createChannel(c echo.Context, input *ChannelInput) (db.Channel, error) {
+	if err := form.Bind(c, input); err != nil {
+		return db.Channel{}, err
+	}
+	slug := slugify(input.Name)
+	if slug == "" {
+		return db.Channel{}, form.Errors{"name": "Name must contain letters or digits"}
+	}
+
+	ctx := c.Request().Context()
+	if _, err := h.queries.GetChannel(ctx, slug); err == nil {
+		return db.Channel{}, form.Errors{"name": "A channel with this name already exists"}
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return db.Channel{}, internalError(err, "Failed to get channel")
+	}
+
+	channel, err := h.queries.CreateChannel(ctx, db.CreateChannelParams{
+		Slug:        slug,
+		Name:        input.Name,
+		Description: input.Description,
+		CreatedBy:   currentUser(c),
+	})
+	if err != nil {
+		return db.Channel{}, internalError(err, "Failed to create channel")
+	}
+	return channel, nil
+}
+
+// updateChannel binds input and saves it to the channel named by the :slug
+// route parameter.
+func (h *Handlers) updateChannel(c echo.Context, input *ChannelInput) (db.Channel, error) {
+	channel, err := h.editableChannel(c)
+	if err != nil {
+		return db.Channel{}, err
+	}
+	if err := form.Bind(c, input); err != nil {
+		return channel, err
+	}
+
+	channel, err = h.queries.UpdateChannel(c.Request().Context(), db.UpdateChannelParams{
+		ID:          channel.ID,
+		Name:        input.Name,
+		Description: input.Description,
+	})
+	if err != nil {
+		return db.Channel{}, internalError(err, "Failed to update channel")
+	}
+	return channel, nil
+}
+
+// deleteChannel deletes the channel named by the :slug route parameter. Its
+// messages move to the default channel.
+func (h *Handlers) deleteChannel(c echo.Context) error {
+	channel, err := h.editableChannel(c)
+	if err != nil {
+		return err
+	}
+	if channel.Slug == defaultChannelSlug {
+		return echo.NewHTTPError(http.StatusConflict, "The default channel cannot be deleted")
+	}
+
+	ctx := c.Request().Context()
+	general, err := h.queries.GetChannel(ctx, defaultChannelSlug)
+	if err != nil {
+		return internalError(err, "Failed to get default channel")
+	}
+	err = h.queries.InTx(ctx, func(q db.Querier) error {
+		if err := q.MoveChannelMessages(ctx, db.MoveChannelMessagesParams{
+			ToChannelID:   general.ID,
+			FromChannelID: channel.ID,
+		}); err != nil {
+			return err
+		}
+		return q.DeleteChannel(ctx, channel.ID)
+	})
+	if err != nil {
+		return internalError(err, "Failed to delete channel")
+	}
+
+	h.cache.Del(messagesCacheKey(channel.ID))
+	h.cache.Del(messagesCacheKey(general.ID))
+	return nil
+}
+
+// channel loads the channel named by the :slug route parameter or, outside
+// channel routes, the "channel" query parameter. Without either it loads the
+// default channel.
+func (h *Handlers) channel(c echo.Context) (db.Channel, error) {
+	slug := c.Param("slug")
+	if slug == "" {
+		slug = c.QueryParam("channel")
+	}
+	if slug == "" {
+		slug = defaultChannelSlug
+	}
+
+	channel, err := h.queries.GetChannel(c.Request().Context(), slug)
+	if errors.Is(err, sql.ErrNoRows) {
+		return db.Channel{}, echo.NewHTTPError(http.StatusNotFound, "Channel not found")
+	}
+	if err != nil {
+		return db.Channel{}, internalError(err, "Failed to get channel")
+	}
+	return channel, nil
+}
+
+// editableChannel loads the channel named by the :slug route parameter and
+// checks that the requesting user is allowed to change it.
+func (h *Handlers) editableChannel(c echo.Context) (db.Channel, error) {
+	channel, err := h.channel(c)
+	if err != nil {
+		return db.Channel{}, err
+	}
+	if !mayChange(c, channel.CreatedBy) {
+		return db.Channel{}, echo.NewHTTPError(http.StatusForbidden, "You can only change your own channels")
+	}
+	return channel, nil
+}
+
+// slugify derives a channel slug from its name: lower case ASCII letters and
+// digits, with hyphens in place of anything else.
+func slugify(name string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z' || r >= '0' && r <= '9':
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			hyphen = false
+			b.WriteRune(r)
+		default:
+			hyphen = true
+		}
+		if b.Len() >= maxSlugLength {
+			break
+		}
+	}
+	return b.String()
+}
+
+// channelURL returns the URL of the page showing a channel.
+func channelURL(slug string) string {
+	return "/c/" + slug
+}
+
+// redirect sends the browser to url, with HX-Redirect for HTMX requests.
+func redirect(c echo.Context, url string) error {
+	if c.Request().Header.Get("HX-Request") == "true" {
+		c.Response().Header().Set("HX-Redirect", url)
+		return c.NoContent(http.StatusOK)
+	}
+	return c.Redirect(http.StatusSeeOther, url)
+}
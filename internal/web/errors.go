@@ -0,0 +1,147 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/form"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// toastTarget is the element in Layout that error toasts are appended to.
+const toastTarget = "#toasts"
+
+// problem is an RFC 7807 problem details object.
+type problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Errors holds per-field messages when validation failed.
+	Errors form.Errors `json:"errors,omitempty"`
+}
+
+// stackError is an error annotated with the stack of the goroutine that
+// raised it.
+type stackError struct {
+	err   error
+	stack []byte
+}
+
+func (e *stackError) Error() string { return e.err.Error() }
+func (e *stackError) Unwrap() error { return e.err }
+
+// internalError returns a 500 error that shows msg to the client. err and the
+// current stack are attached for the error handler to log.
+func internalError(err error, msg string) *echo.HTTPError {
+	return echo.NewHTTPError(http.StatusInternalServerError, msg).
+		SetInternal(&stackError{err: err, stack: debug.Stack()})
+}
+
+// RecoverConfig returns the panic recovery configuration. Recovered panics are
+// returned up the middleware chain with their stack attached, so they are
+// access-logged and reported by the error handler like any other error.
+func RecoverConfig() middleware.RecoverConfig {
+	cfg := middleware.DefaultRecoverConfig
+	cfg.DisableStackAll = true
+	cfg.DisableErrorHandler = true
+	cfg.LogErrorFunc = func(c echo.Context, err error, stack []byte) error {
+		return &stackError{err: err, stack: stack}
+	}
+	return cfg
+}
+
+// ErrorHandler returns an echo.HTTPErrorHandler that answers in the format
+// the request expects: an error toast fragment for HTMX requests, RFC 7807
+// problem details for API requests, and a full error page otherwise. Server
+// errors are logged along with their stack trace when one is available.
+func ErrorHandler(logger *slog.Logger) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		if c.Response().Committed {
+			return
+		}
+
+		code := http.StatusInternalServerError
+		message := http.StatusText(code)
+		cause := err
+		var he *echo.HTTPError
+		var fieldErrs form.Errors
+		if errors.As(err, &fieldErrs) {
+			code = http.StatusUnprocessableEntity
+			message = fieldErrs.Error()
+		} else if errors.As(err, &he) {
+			code = he.Code
+			if m, ok := he.Message.(string); ok {
+				message = m
+			} else if he.Message != nil {
+				message = fmt.Sprint(he.Message)
+			}
+			if he.Internal != nil {
+				cause = he.Internal
+			}
+		}
+
+		if code >= http.StatusInternalServerError {
+			attrs := []any{"error", cause, "method", c.Request().Method, "uri", c.Request().RequestURI}
+			var se *stackError
+			if errors.As(cause, &se) {
+				attrs = append(attrs, "stack", string(se.stack))
+			}
+			logger.Error("internal server error", attrs...)
+		}
+
+		if c.Request().Method == http.MethodHead {
+			err = c.NoContent(code)
+		} else {
+			err = writeError(c, code, message, fieldErrs)
+		}
+		if err != nil {
+			logger.Error("failed to write error response", "error", err)
+		}
+	}
+}
+
+// writeError writes the error response in the format the request expects.
+func writeError(c echo.Context, code int, message string, fieldErrs form.Errors) error {
+	req := c.Request()
+	switch {
+	case req.Header.Get("HX-Request") == "true":
+		// htmx does not swap error responses by default; Layout opts in for
+		// responses that name a target with HX-Retarget.
+		c.Response().Header().Set("HX-Retarget", toastTarget)
+		c.Response().Header().Set("HX-Reswap", "beforeend")
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+		c.Response().WriteHeader(code)
+		return renderComponent(c, ErrorToast(message))
+	case isAPIRequest(req):
+		c.Response().Header().Set(echo.HeaderContentType, "application/problem+json")
+		return c.JSON(code, problem{
+			Type:     "about:blank",
+			Title:    http.StatusText(code),
+			Status:   code,
+			Detail:   message,
+			Instance: req.URL.Path,
+			Errors:   fieldErrs,
+		})
+	default:
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+		c.Response().WriteHeader(code)
+		return renderComponent(c, ErrorPage(code, http.StatusText(code), message))
+	}
+}
+
+// isAPIRequest reports whether req targets the JSON API or prefers JSON over
+// HTML.
+func isAPIRequest(req *http.Request) bool {
+	if strings.HasPrefix(req.URL.Path, "/api/") {
+		return true
+	}
+	accept := req.Header.Get(echo.HeaderAccept)
+	return strings.Contains(accept, echo.MIMEApplicationJSON) && !strings.Contains(accept, echo.MIMETextHTML)
+}
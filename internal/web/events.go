@@ -0,0 +1,188 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/db"
+	"github.com/dunamismax/go-modern-scaffold/internal/events"
+	"github.com/labstack/echo/v4"
+)
+
+// sseKeepAlive is how often an idle event stream sends a comment so proxies
+// do not close the connection.
+const sseKeepAlive = 30 * time.Second
+
+// streamBatchSize is the number of messages APIStreamMessages reads from the
+// database at once.
+const streamBatchSize = 500
+
+// Events streams message changes to the browser as server-sent events. Each
+// event's data is the JSON encoded events.Event; its name tells the page
+// which part to reload: "channel-<id>" for the message list of a channel and
+// "reaction-<id>" for the reaction bar of a message.
+func (h *Handlers) Events(c echo.Context) error {
+	sub, unsubscribe := h.broker.Subscribe()
+	defer unsubscribe()
+
+	res := c.Response()
+	startEventStream(res)
+
+	ticker := time.NewTicker(sseKeepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case <-ticker.C:
+			if _, err := fmt.Fprint(res, ": keep-alive\n\n"); err != nil {
+				return nil
+			}
+		case e, ok := <-sub:
+			if !ok {
+				return nil
+			}
+			if err := writeEvent(res, "", sseEventName(e), e); err != nil {
+				return nil
+			}
+		}
+		res.Flush()
+	}
+}
+
+// APIStreamMessages streams the new messages and replies of a channel as
+// server-sent events named "message", each with the JSON encoded message as
+// data and its ID as event ID. The channel is chosen as for APIListMessages.
+//
+// A client resuming a stream passes the ID of the last message it saw in the
+// Last-Event-ID header or the "after" query parameter and first gets the
+// messages it missed. Once caught up, the stream sends a "ready" event whose
+// ID is that of the latest message, so a client reconnecting before any new
+// message arrives resumes from there.
+func (h *Handlers) APIStreamMessages(c echo.Context) error {
+	channel, err := h.channel(c)
+	if err != nil {
+		return err
+	}
+	after := c.Request().Header.Get("Last-Event-ID")
+	if after == "" {
+		after = c.QueryParam("after")
+	}
+	var lastID int64
+	if after != "" {
+		if lastID, err = strconv.ParseInt(after, 10, 64); err != nil || lastID < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid last event ID")
+		}
+	}
+
+	// Subscribe before catching up, so no message falls between the two.
+	sub, unsubscribe := h.broker.Subscribe()
+	defer unsubscribe()
+
+	ctx := c.Request().Context()
+	if after == "" {
+		if lastID, err = h.queries.GetLatestMessageID(ctx, channel.ID); err != nil {
+			return internalError(err, "Failed to get messages")
+		}
+	}
+
+	res := c.Response()
+	startEventStream(res)
+
+	// send writes the messages after lastID. It reads them from the database
+	// rather than trusting the events, which a slow subscriber may miss.
+	send := func() error {
+		for {
+			messages, err := h.queries.GetMessagesAfter(ctx, db.GetMessagesAfterParams{
+				ChannelID: channel.ID,
+				AfterID:   lastID,
+				Limit:     streamBatchSize,
+			})
+			if err != nil {
+				if ctx.Err() == nil {
+					slog.Error("failed to stream messages", "channel_id", channel.ID, "error", err)
+				}
+				return err
+			}
+			for _, msg := range messages {
+				if err := writeEvent(res, strconv.FormatInt(msg.ID, 10), "message", msg); err != nil {
+					return err
+				}
+				lastID = msg.ID
+			}
+			if len(messages) < streamBatchSize {
+				return nil
+			}
+		}
+	}
+	if err := send(); err != nil {
+		return nil
+	}
+	if err := writeEvent(res, strconv.FormatInt(lastID, 10), "ready", map[string]int64{"last_id": lastID}); err != nil {
+		return nil
+	}
+	res.Flush()
+
+	ticker := time.NewTicker(sseKeepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if _, err := fmt.Fprint(res, ": keep-alive\n\n"); err != nil {
+				return nil
+			}
+		case e, ok := <-sub:
+			if !ok {
+				return nil
+			}
+			if e.Type != events.MessageCreated || e.ChannelID != channel.ID || e.MessageID <= lastID {
+				continue
+			}
+			if err := send(); err != nil {
+				return nil
+			}
+		}
+		res.Flush()
+	}
+}
+
+// startEventStream writes the headers of a server-sent event stream.
+func startEventStream(res *echo.Response) {
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+}
+
+// writeEvent writes a server-sent event with v, encoded as JSON, as data. The
+// event ID is left out when id is empty.
+func writeEvent(res *echo.Response, id, name string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		if _, err := fmt.Fprintf(res, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(res, "event: %s\ndata: %s\n\n", name, data)
+	return err
+}
+
+// sseEventName returns the name e is sent under by Events.
+func sseEventName(e events.Event) string {
+	if e.Type == events.ReactionChanged {
+		return "reaction-" + strconv.FormatInt(e.MessageID, 10)
+	}
+	return "channel-" + strconv.FormatInt(e.ChannelID, 10)
+}
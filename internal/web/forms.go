@@ -0,0 +1,56 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/a-h/templ"
+	"github.com/dunamismax/go-modern-scaffold/internal/form"
+	"github.com/labstack/echo/v4"
+)
+
+// maxMessageLength is the longest message body accepted, in characters. It
+// must match the max rule on MessageInput.Body.
+const maxMessageLength = 2000
+
+// MessageInput is the data submitted by MessageForm.
+type MessageInput struct {
+	Body string `form:"body" json:"body" label:"Message" validate:"required,max=2000"`
+}
+
+// ChannelInput is the data submitted by ChannelForm.
+type ChannelInput struct {
+	Name        string `form:"name" json:"name" label:"Name" validate:"required,max=50"`
+	Description string `form:"description" json:"description" label:"Description" validate:"max=200"`
+}
+
+// renderInvalidForm responds to a submission that failed validation. HTMX
+// requests get component, the form re-rendered with its field errors, swapped
+// in place of target. Other requests are answered by the error handler.
+func renderInvalidForm(c echo.Context, errs form.Errors, target string, component templ.Component) error {
+	if c.Request().Header.Get("HX-Request") != "true" {
+		return errs
+	}
+
+	c.Response().Header().Set("HX-Retarget", target)
+	c.Response().Header().Set("HX-Reswap", "outerHTML")
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	c.Response().WriteHeader(http.StatusUnprocessableEntity)
+	return renderComponent(c, component)
+}
+
+// NotificationPreferencesInput is the data submitted by
+// NotificationPreferencesForm. Each field enables one kind of notification.
+type NotificationPreferencesInput struct {
+	Mentions  bool `form:"mentions" json:"mentions"`
+	Replies   bool `form:"replies" json:"replies"`
+	Reactions bool `form:"reactions" json:"reactions"`
+}
+
+// WebhookInput is the data submitted by WebhookForm. Events lists the event
+// types sent to the webhook, all of them if it is empty; see webhookEvents.
+// An empty Secret is generated on creation and left as it is on update.
+type WebhookInput struct {
+	URL    string   `form:"url" json:"url" label:"URL" validate:"required,http_url,max=2000"`
+	Secret string   `form:"secret" json:"secret" label:"Secret" validate:"max=200"`
+	Events []string `form:"events" json:"events" label:"Events" validate:"dive,oneof=message.created message.updated message.deleted message.restored"`
+}
@@ -2,80 +2,408 @@ package web
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/a-h/templ"
+	"github.com/dunamismax/go-modern-scaffold/internal/blob"
 	"github.com/dunamismax/go-modern-scaffold/internal/cache"
+	"github.com/dunamismax/go-modern-scaffold/internal/config"
 	"github.com/dunamismax/go-modern-scaffold/internal/db"
+	"github.com/dunamismax/go-modern-scaffold/internal/events"
+	"github.com/dunamismax/go-modern-scaffold/internal/form"
+	"github.com/dunamismax/go-modern-scaffold/internal/markdown"
 	"github.com/labstack/echo/v4"
 )
 
-const messagesCacheKey = "messages"
-
 // Handlers holds the dependencies for the web handlers.
 type Handlers struct {
-	queries db.Querier
-	cache   *cache.Cache
+	queries  db.Store
+	cache    *cache.Cache
+	broker   *events.Broker
+	blobs    blob.Store
+	storage  *config.Storage
+	markdown *markdown.Renderer
 }
 
 // NewHandlers creates a new Handlers instance.
-func NewHandlers(queries db.Querier, cache *cache.Cache) *Handlers {
-	return &Handlers{queries: queries, cache: cache}
+func NewHandlers(queries db.Store, cache *cache.Cache, broker *events.Broker, blobs blob.Store, storage *config.Storage) *Handlers {
+	return &Handlers{
+		queries:  queries,
+		cache:    cache,
+		broker:   broker,
+		blobs:    blobs,
+		storage:  storage,
+		markdown: markdown.New(tagURL),
+	}
 }
 
-// RenderIndex renders the main index page.
+// RenderIndex renders the main index page, showing the messages of a channel.
 func (h *Handlers) RenderIndex(c echo.Context) error {
-	// Try to get messages from cache first
-	if cachedMessages, found := h.cache.Get(messagesCacheKey); found {
-		if messages, ok := cachedMessages.([]db.Message); ok {
-			slog.Info("cache hit for messages")
-			return renderComponent(c, Index(messages))
-		}
+	channel, err := h.channel(c)
+	if err != nil {
+		return err
+	}
+	channels, err := h.queries.GetChannels(c.Request().Context())
+	if err != nil {
+		return internalError(err, "Failed to get channels")
+	}
+	messages, err := h.messageList(c, channel.ID, firstPage)
+	if err != nil {
+		return err
+	}
+	tags, err := h.queries.GetTagCounts(c.Request().Context(), tagCloudLimit)
+	if err != nil {
+		return internalError(err, "Failed to get tags")
 	}
 
-	// If not in cache, get from DB
-	slog.Info("cache miss for messages")
-	messages, err := h.queries.GetMessages(context.Background())
+	return renderComponent(c, Index(channel, channels, messages, tags))
+}
+
+// RenderMessages renders the page of a channel's message list selected by the
+// "before" and "limit" query parameters, for the list to load older messages.
+func (h *Handlers) RenderMessages(c echo.Context) error {
+	channel, err := h.channel(c)
+	if err != nil {
+		return err
+	}
+	page, err := parseMessagePage(c)
 	if err != nil {
-		slog.Error("failed to get messages", "error", err)
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get messages")
+		return err
 	}
+	messages, err := h.messageList(c, channel.ID, page)
+	if err != nil {
+		return err
+	}
+	return renderComponent(c, ChannelMessages(channel.Slug, messages))
+}
 
-	// Set messages in cache
-	h.cache.Set(messagesCacheKey, messages, 1)
+// SearchMessages renders the messages of a channel matching the "q" query
+// parameter into the message list. An empty query renders the full list again.
+func (h *Handlers) SearchMessages(c echo.Context) error {
+	channel, err := h.channel(c)
+	if err != nil {
+		return err
+	}
 
-	return renderComponent(c, Index(messages))
+	query := ftsQuery(c.QueryParam("q"))
+	if query == "" {
+		messages, err := h.messageList(c, channel.ID, firstPage)
+		if err != nil {
+			return err
+		}
+		return renderComponent(c, ChannelMessages(channel.Slug, messages))
+	}
+
+	results, err := h.queries.SearchMessages(c.Request().Context(), db.SearchMessagesParams{
+		Query:     query,
+		ChannelID: channel.ID,
+		Limit:     searchResultLimit,
+	})
+	if err != nil {
+		return internalError(err, "Failed to search messages")
+	}
+
+	return renderComponent(c, SearchResults(results))
 }
 
-// CreateMessage handles the creation of a new message.
+// CreateMessage handles the creation of a new message in a channel.
 func (h *Handlers) CreateMessage(c echo.Context) error {
-	body := c.FormValue("body")
-	if body == "" {
-		return echo.NewHTTPError(http.StatusBadRequest, "Message body cannot be empty")
+	channel, err := h.channel(c)
+	if err != nil {
+		return err
 	}
 
-	err := h.queries.CreateMessage(context.Background(), body)
+	var input MessageInput
+	err = form.Bind(c, &input)
+	var uploads []upload
+	if err == nil {
+		uploads, err = h.uploads(c)
+	}
 	if err != nil {
-		slog.Error("failed to create message", "error", err)
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create message")
+		var errs form.Errors
+		if errors.As(err, &errs) {
+			return renderInvalidForm(c, errs, "#message-form", MessageForm(channel, input, errs))
+		}
+		return err
 	}
 
-	// Invalidate cache
-	h.cache.Del(messagesCacheKey)
-	slog.Info("cache invalidated for messages")
+	msg, err := h.createMessage(c, db.CreateMessageParams{
+		Body:      input.Body,
+		Author:    currentUser(c),
+		ChannelID: channel.ID,
+	}, uploads, "")
+	if err != nil {
+		return internalError(err, "Failed to create message")
+	}
 
-	messages, err := h.queries.GetMessages(context.Background())
+	h.messageChanged(events.MessageCreated, msg)
+
+	messages, err := h.messageList(c, channel.ID, firstPage)
 	if err != nil {
-		slog.Error("failed to get messages after creating new one", "error", err)
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get messages")
+		return err
 	}
 
 	// This is where HTMX shines. We just render the component that needs updating.
-	return renderComponent(c, MessageList(messages))
+	return renderComponent(c, ChannelMessages(channel.Slug, messages))
+}
+
+// RenderMessage renders a single message, e.g. to cancel an edit.
+func (h *Handlers) RenderMessage(c echo.Context) error {
+	msg, err := h.message(c)
+	if err != nil {
+		return err
+	}
+	return h.renderMessageItem(c, msg)
+}
+
+// EditMessage renders the edit form for a message in place of the message.
+func (h *Handlers) EditMessage(c echo.Context) error {
+	msg, err := h.editableMessage(c)
+	if err != nil {
+		return err
+	}
+	return renderComponent(c, MessageEditForm(msg, MessageInput{Body: msg.Body}, nil))
+}
+
+// UpdateMessage saves an edited message body. The previous body is kept in
+// the message's revision history.
+func (h *Handlers) UpdateMessage(c echo.Context) error {
+	msg, err := h.editableMessage(c)
+	if err != nil {
+		return err
+	}
+
+	var input MessageInput
+	if err := form.Bind(c, &input); err != nil {
+		var errs form.Errors
+		if errors.As(err, &errs) {
+			return renderInvalidForm(c, errs, messageTarget(msg.ID), MessageEditForm(msg, input, errs))
+		}
+		return err
+	}
+
+	msg, err = h.updateMessage(c, db.UpdateMessageParams{
+		ID:     msg.ID,
+		Body:   input.Body,
+		Editor: currentUser(c),
+	})
+	if err != nil {
+		return internalError(err, "Failed to update message")
+	}
+
+	h.messageChanged(events.MessageUpdated, msg)
+	return h.renderMessageItem(c, msg)
+}
+
+// DeleteMessage soft-deletes a message. The response is empty so HTMX
+// removes the message from the list.
+func (h *Handlers) DeleteMessage(c echo.Context) error {
+	msg, err := h.editableMessage(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.deleteMessage(c, msg); err != nil {
+		return err
+	}
+
+	h.messageChanged(events.MessageDeleted, msg)
+	return c.NoContent(http.StatusOK)
+}
+
+// message loads the message named by the :id route parameter.
+func (h *Handlers) message(c echo.Context) (db.Message, error) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return db.Message{}, echo.NewHTTPError(http.StatusBadRequest, "Invalid message ID")
+	}
+
+	msg, err := h.queries.GetMessage(c.Request().Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return db.Message{}, echo.NewHTTPError(http.StatusNotFound, "Message not found")
+	}
+	if err != nil {
+		return db.Message{}, internalError(err, "Failed to get message")
+	}
+	return msg, nil
+}
+
+// editableMessage loads the message named by the :id route parameter and
+// checks that the requesting user is allowed to change it.
+func (h *Handlers) editableMessage(c echo.Context) (db.Message, error) {
+	msg, err := h.message(c)
+	if err != nil {
+		return db.Message{}, err
+	}
+	if !mayChange(c, msg.Author) {
+		return db.Message{}, echo.NewHTTPError(http.StatusForbidden, "You can only change your own messages")
+	}
+	return msg, nil
+}
+
+// renderMessageItem renders a single message along with its replies count
+// and reactions.
+func (h *Handlers) renderMessageItem(c echo.Context, msg db.Message) error {
+	replies, err := h.queries.CountReplies(c.Request().Context(), msg.ID)
+	if err != nil {
+		return internalError(err, "Failed to count replies")
+	}
+	views, err := h.fillViews(c, []MessageView{{Message: msg, ReplyCount: replies}})
+	if err != nil {
+		return err
+	}
+	return renderComponent(c, MessageItem(views[0]))
+}
+
+// createMessage creates a message with uploads attached, tags it with the
+// hashtags in its body, and notifies the users and webhooks it concerns. A
+// non-empty idempotency key is recorded with it; see createAPIMessage.
+func (h *Handlers) createMessage(c echo.Context, arg db.CreateMessageParams, uploads []upload, idempotencyKey string) (db.Message, error) {
+	ctx := c.Request().Context()
+	stored, err := h.storeUploads(ctx, uploads)
+	if err != nil {
+		return db.Message{}, err
+	}
+
+	var msg db.Message
+	err = h.queries.InTx(ctx, func(q db.Querier) error {
+		var err error
+		if msg, err = q.CreateMessage(ctx, arg); err != nil {
+			return err
+		}
+		if idempotencyKey != "" {
+			if err := q.CreateIdempotencyKey(ctx, db.CreateIdempotencyKeyParams{
+				Author:    msg.Author,
+				Key:       idempotencyKey,
+				MessageID: msg.ID,
+			}); err != nil {
+				return err
+			}
+		}
+		if err := attachUploads(ctx, q, msg, stored); err != nil {
+			return err
+		}
+		if err := db.SetMessageTags(ctx, q, msg.ID, h.markdown.Tags(msg.Body)); err != nil {
+			return err
+		}
+		if err := h.notifyMessage(ctx, q, msg); err != nil {
+			return err
+		}
+		return queueWebhooks(ctx, q, events.MessageCreated, msg)
+	})
+	return msg, err
+}
+
+// updateMessage changes the body of a message, retags it and notifies the
+// webhooks subscribed to updates.
+func (h *Handlers) updateMessage(c echo.Context, arg db.UpdateMessageParams) (db.Message, error) {
+	ctx := c.Request().Context()
+	var msg db.Message
+	err := h.queries.InTx(ctx, func(q db.Querier) error {
+		var err error
+		if msg, err = q.UpdateMessage(ctx, arg); err != nil {
+			return err
+		}
+		if err := db.SetMessageTags(ctx, q, msg.ID, h.markdown.Tags(msg.Body)); err != nil {
+			return err
+		}
+		return queueWebhooks(ctx, q, events.MessageUpdated, msg)
+	})
+	return msg, err
+}
+
+// deleteMessage soft-deletes a message on behalf of the current user and
+// notifies the webhooks subscribed to deletions.
+func (h *Handlers) deleteMessage(c echo.Context, msg db.Message) error {
+	ctx := c.Request().Context()
+	user := currentUser(c)
+	err := h.queries.InTx(ctx, func(q db.Querier) error {
+		if _, err := q.DeleteMessage(ctx, db.DeleteMessageParams{
+			ID:        msg.ID,
+			DeletedBy: &user,
+		}); err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		msg.DeletedAt, msg.DeletedBy = &now, &user
+		return queueWebhooks(ctx, q, events.MessageDeleted, msg)
+	})
+	if err != nil {
+		return internalError(err, "Failed to delete message")
+	}
+	return nil
+}
+
+// messageChanged drops the cached first page of the message's channel
+// after a change and tells connected clients about it.
+func (h *Handlers) messageChanged(eventType string, msg db.Message) {
+	h.cache.Del(messagesCacheKey(msg.ChannelID))
+	slog.Info("cache invalidated for messages", "channel_id", msg.ChannelID)
+	h.broker.Publish(events.Event{
+		Type:      eventType,
+		MessageID: msg.ID,
+		ParentID:  msg.ParentID,
+		ChannelID: msg.ChannelID,
+	})
+}
+
+// messages returns a page of the root messages of a channel with their reply
+// counts, newest first. Only the first page is cached: it is read on every
+// visit, while older pages are read rarely.
+func (h *Handlers) messages(ctx context.Context, channelID int64, page messagePage) ([]db.GetMessagesRow, error) {
+	params := db.GetMessagesParams{ChannelID: channelID, BeforeID: page.BeforeID, Limit: page.Limit}
+	if page.BeforeID == 0 {
+		params.BeforeID = math.MaxInt64
+	}
+	if page != firstPage {
+		return h.queries.GetMessages(ctx, params)
+	}
+
+	key := messagesCacheKey(channelID)
+
+	// Try to get messages from cache first
+	if cachedMessages, found := h.cache.Get(key); found {
+		if messages, ok := cachedMessages.([]db.GetMessagesRow); ok {
+			slog.Info("cache hit for messages", "channel_id", channelID)
+			return messages, nil
+		}
+	}
+
+	// If not in cache, get from DB
+	slog.Info("cache miss for messages", "channel_id", channelID)
+	messages, err := h.queries.GetMessages(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set messages in cache
+	h.cache.Set(key, messages, 1)
+
+	return messages, nil
+}
+
+// messagesCacheKey returns the cache key of the message list of a channel.
+func messagesCacheKey(channelID int64) string {
+	return "messages:" + strconv.FormatInt(channelID, 10)
+}
+
+// messageElementID returns the DOM id of a rendered message.
+func messageElementID(id int64) string {
+	return "message-" + strconv.FormatInt(id, 10)
+}
+
+// messageTarget returns a CSS selector for a rendered message.
+func messageTarget(id int64) string {
+	return "#" + messageElementID(id)
 }
 
 // renderComponent is a helper to render a templ component.
 func renderComponent(c echo.Context, component templ.Component) error {
-	return component.Render(c.Request().Context(), c.Response().Writer)
+	return component.Render(c.Request().Context(), c.Response())
 }
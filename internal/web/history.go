@@ -0,0 +1,66 @@
+package web
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/db"
+	"github.com/dunamismax/go-modern-scaffold/internal/events"
+	"github.com/labstack/echo/v4"
+)
+
+// RenderMessageHistory renders a message together with all of its previous
+// revisions, newest first.
+func (h *Handlers) RenderMessageHistory(c echo.Context) error {
+	msg, err := h.message(c)
+	if err != nil {
+		return err
+	}
+
+	revisions, err := h.queries.GetMessageRevisions(c.Request().Context(), msg.ID)
+	if err != nil {
+		return internalError(err, "Failed to get message history")
+	}
+
+	return renderComponent(c, MessageHistory(msg, revisions))
+}
+
+// RenderDeletedMessages renders the admin list of soft-deleted messages.
+func (h *Handlers) RenderDeletedMessages(c echo.Context) error {
+	messages, err := h.queries.GetDeletedMessages(c.Request().Context())
+	if err != nil {
+		return internalError(err, "Failed to get deleted messages")
+	}
+
+	return renderComponent(c, DeletedMessages(messages))
+}
+
+// RestoreMessage undeletes a soft-deleted message. The response is empty so
+// HTMX removes the message from the deleted list.
+func (h *Handlers) RestoreMessage(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid message ID")
+	}
+
+	ctx := c.Request().Context()
+	var msg db.Message
+	err = h.queries.InTx(ctx, func(q db.Querier) error {
+		n, err := q.RestoreMessage(ctx, id)
+		if err != nil || n == 0 {
+			return err
+		}
+		if msg, err = q.GetMessage(ctx, id); err != nil {
+			return err
+		}
+		return queueWebhooks(ctx, q, events.MessageRestored, msg)
+	})
+	if err != nil {
+		return internalError(err, "Failed to restore message")
+	}
+	if msg.ID == 0 {
+		return echo.NewHTTPError(http.StatusNotFound, "Deleted message not found")
+	}
+	h.messageChanged(events.MessageRestored, msg)
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,64 @@
+package web
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/db"
+	"github.com/labstack/echo/v4"
+)
+
+// idempotencyKeyHeader is the header API clients send a unique key in with a
+// new message, so that retrying the request cannot post the message twice.
+// Keys are scoped to the requesting user.
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength is the length, in bytes, of the longest key taken.
+const maxIdempotencyKeyLength = 255
+
+// createAPIMessage creates a message like createMessage, unless the request
+// carries the idempotency key of an earlier one. Then it returns the message
+// the earlier request created instead, and false. Errors are ready to be
+// returned by the handler; errMsg describes a failure to create.
+func (h *Handlers) createAPIMessage(c echo.Context, arg db.CreateMessageParams, uploads []upload, errMsg string) (db.Message, bool, error) {
+	key := strings.TrimSpace(c.Request().Header.Get(idempotencyKeyHeader))
+	if len(key) > maxIdempotencyKeyLength {
+		return db.Message{}, false, echo.NewHTTPError(http.StatusBadRequest, "Idempotency key too long")
+	}
+	if key != "" {
+		if msg, ok, err := h.replayedMessage(c, key); err != nil || ok {
+			return msg, false, err
+		}
+	}
+
+	msg, err := h.createMessage(c, arg, uploads, key)
+	if err != nil {
+		// A concurrent request with the same key may have got there first.
+		if key != "" {
+			if msg, ok, rerr := h.replayedMessage(c, key); rerr == nil && ok {
+				return msg, false, nil
+			}
+		}
+		return db.Message{}, false, internalError(err, errMsg)
+	}
+	return msg, true, nil
+}
+
+// replayedMessage returns the message the requesting user created with key,
+// and whether there is one. It marks the response as a replay if so.
+func (h *Handlers) replayedMessage(c echo.Context, key string) (db.Message, bool, error) {
+	msg, err := h.queries.GetIdempotentMessage(c.Request().Context(), db.GetIdempotentMessageParams{
+		Author: currentUser(c),
+		Key:    key,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return db.Message{}, false, nil
+	}
+	if err != nil {
+		return db.Message{}, false, internalError(err, "Failed to check idempotency key")
+	}
+	c.Response().Header().Set("Idempotent-Replayed", "true")
+	return msg, true, nil
+}
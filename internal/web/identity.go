@@ -0,0 +1,149 @@
+package web
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/config"
+	"github.com/labstack/echo/v4"
+)
+
+// anonymousUser is the username of requests that carry no identity.
+const anonymousUser = "anonymous"
+
+// ProxySecretHeader is the header in which proxies send the configured proxy
+// secret.
+const ProxySecretHeader = "X-Proxy-Secret"
+
+// Keys for the identity values stored on the echo.Context.
+const (
+	userContextKey  = "user"
+	adminContextKey = "admin"
+)
+
+// Identify returns middleware that records the requesting user, as passed by
+// the authenticating proxy in the configured header, on the context. The
+// header is only believed on requests that come straight from a trusted proxy
+// or carry the proxy secret; anyone else could set it to whatever they like.
+// Without trusted proxies or a secret configured, all requests are anonymous.
+func Identify(cfg *config.Auth) (echo.MiddlewareFunc, error) {
+	proxies, err := parsePrefixes(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	fromProxy := func(r *http.Request) bool {
+		if cfg.ProxySecret != "" &&
+			subtle.ConstantTimeCompare([]byte(r.Header.Get(ProxySecretHeader)), []byte(cfg.ProxySecret)) == 1 {
+			return true
+		}
+		addr, err := netip.ParseAddrPort(r.RemoteAddr)
+		if err != nil {
+			return false
+		}
+		return slices.ContainsFunc(proxies, func(p netip.Prefix) bool { return p.Contains(addr.Addr().Unmap()) })
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			user := ""
+			if fromProxy(c.Request()) {
+				user = strings.TrimSpace(c.Request().Header.Get(cfg.UserHeader))
+			}
+			if user == "" {
+				user = anonymousUser
+			}
+			c.Set(userContextKey, user)
+			c.Set(adminContextKey, user != anonymousUser && slices.Contains(cfg.Admins, user))
+			return next(c)
+		}
+	}, nil
+}
+
+// parsePrefixes parses addresses and CIDR ranges, taking an address as the
+// range of just itself.
+func parsePrefixes(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if strings.Contains(v, "/") {
+			p, err := netip.ParsePrefix(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", v, err)
+			}
+			prefixes = append(prefixes, p.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", v, err)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// RequireAdmin is middleware that rejects requests from non-admin users.
+func RequireAdmin(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if !isAdmin(c) {
+			return echo.NewHTTPError(http.StatusForbidden, "Only admins can do that")
+		}
+		return next(c)
+	}
+}
+
+// RequireUser is middleware that rejects anonymous requests, for what belongs
+// to a user: all anonymous requests would share the anonymous user's.
+func RequireUser(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if currentUser(c) == anonymousUser {
+			return echo.NewHTTPError(http.StatusForbidden, "Sign in to do that")
+		}
+		return next(c)
+	}
+}
+
+// currentUser returns the username of the requesting user.
+func currentUser(c echo.Context) string {
+	if user, ok := c.Get(userContextKey).(string); ok {
+		return user
+	}
+	return anonymousUser
+}
+
+// isAdmin reports whether the requesting user is an admin.
+func isAdmin(c echo.Context) bool {
+	admin, _ := c.Get(adminContextKey).(bool)
+	return admin
+}
+
+// mayChange reports whether the requesting user may change something owned
+// by owner: admins may change anything, and other users what they own.
+// Requests without an identity own nothing, even what was made anonymously.
+func mayChange(c echo.Context, owner string) bool {
+	if isAdmin(c) {
+		return true
+	}
+	user := currentUser(c)
+	return user != anonymousUser && user == owner
+}
+
+// Identity is the requesting user as the server sees them.
+type Identity struct {
+	User  string `json:"user"`
+	Admin bool   `json:"admin"`
+}
+
+// APIIdentity returns the requesting user as JSON, so API clients can check
+// whom their requests are made as.
+func APIIdentity(c echo.Context) error {
+	return c.JSON(http.StatusOK, Identity{User: currentUser(c), Admin: isAdmin(c)})
+}
@@ -0,0 +1,162 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/config"
+	"github.com/labstack/echo/v4"
+)
+
+func TestMayChange(t *testing.T) {
+	// httptest requests come from 192.0.2.1.
+	cfg := &config.Auth{UserHeader: "X-Remote-User", Admins: []string{"root"}, TrustedProxies: []string{"192.0.2.1"}}
+	tests := []struct {
+		name  string
+		user  string // sent in the header; empty sends none
+		owner string
+		want  bool
+	}{
+		{"owner", "alice", "alice", true},
+		{"other user", "bob", "alice", false},
+		{"admin", "root", "alice", true},
+		{"admin on anonymous", "root", anonymousUser, true},
+		{"anonymous on anonymous", "", anonymousUser, false},
+		{"anonymous on owned", "", "alice", false},
+		{"claiming to be anonymous", anonymousUser, anonymousUser, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/", nil)
+			if tt.user != "" {
+				req.Header.Set(cfg.UserHeader, tt.user)
+			}
+			c := echo.New().NewContext(req, httptest.NewRecorder())
+
+			identify, err := Identify(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got bool
+			handler := identify(func(c echo.Context) error {
+				got = mayChange(c, tt.owner)
+				return nil
+			})
+			if err := handler(c); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("mayChange(%q) as %q = %v, want %v", tt.owner, tt.user, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIdentify(t *testing.T) {
+	tests := []struct {
+		name       string
+		proxies    []string
+		secret     string
+		remoteAddr string
+		header     http.Header
+		wantUser   string
+		wantAdmin  bool
+	}{
+		{
+			"nothing trusted", nil, "", "127.0.0.1:1234",
+			http.Header{"X-Remote-User": {"root"}}, anonymousUser, false,
+		},
+		{
+			"trusted address", []string{"127.0.0.1"}, "", "127.0.0.1:1234",
+			http.Header{"X-Remote-User": {"root"}}, "root", true,
+		},
+		{
+			"untrusted address", []string{"127.0.0.1"}, "", "203.0.113.7:1234",
+			http.Header{"X-Remote-User": {"root"}}, anonymousUser, false,
+		},
+		{
+			"trusted range", []string{"10.0.0.0/8"}, "", "10.1.2.3:1234",
+			http.Header{"X-Remote-User": {"alice"}}, "alice", false,
+		},
+		{
+			"trusted IPv6 address", []string{"::1"}, "", "[::1]:1234",
+			http.Header{"X-Remote-User": {"alice"}}, "alice", false,
+		},
+		{
+			"IPv4-mapped address", []string{"127.0.0.1"}, "", "[::ffff:127.0.0.1]:1234",
+			http.Header{"X-Remote-User": {"alice"}}, "alice", false,
+		},
+		{
+			"trusted without header", []string{"127.0.0.1"}, "", "127.0.0.1:1234",
+			http.Header{}, anonymousUser, false,
+		},
+		{
+			"proxy secret", nil, "s3cr3t", "203.0.113.7:1234",
+			http.Header{"X-Remote-User": {"root"}, ProxySecretHeader: {"s3cr3t"}}, "root", true,
+		},
+		{
+			"wrong proxy secret", nil, "s3cr3t", "203.0.113.7:1234",
+			http.Header{"X-Remote-User": {"root"}, ProxySecretHeader: {"guess"}}, anonymousUser, false,
+		},
+		{
+			"no proxy secret", nil, "s3cr3t", "203.0.113.7:1234",
+			http.Header{"X-Remote-User": {"root"}}, anonymousUser, false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Auth{UserHeader: "X-Remote-User", Admins: []string{"root"}, TrustedProxies: tt.proxies, ProxySecret: tt.secret}
+			identify, err := Identify(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header = tt.header
+			c := echo.New().NewContext(req, httptest.NewRecorder())
+
+			var gotUser string
+			var gotAdmin bool
+			if err := identify(func(c echo.Context) error {
+				gotUser, gotAdmin = currentUser(c), isAdmin(c)
+				return nil
+			})(c); err != nil {
+				t.Fatal(err)
+			}
+			if gotUser != tt.wantUser || gotAdmin != tt.wantAdmin {
+				t.Errorf("user %q, admin %v; want %q, %v", gotUser, gotAdmin, tt.wantUser, tt.wantAdmin)
+			}
+		})
+	}
+}
+
+func TestIdentifyInvalidProxy(t *testing.T) {
+	for _, proxy := range []string{"localhost", "10.0.0.0/33", "300.1.1.1"} {
+		if _, err := Identify(&config.Auth{TrustedProxies: []string{proxy}}); err == nil {
+			t.Errorf("Identify with trusted proxy %q returned no error", proxy)
+		}
+	}
+}
+
+func TestRequireUser(t *testing.T) {
+	for _, tt := range []struct {
+		user string
+		want int
+	}{
+		{"alice", http.StatusOK},
+		{anonymousUser, http.StatusForbidden},
+	} {
+		c := contextAs(tt.user)
+		err := RequireUser(func(c echo.Context) error { return c.NoContent(http.StatusOK) })(c)
+		got := c.Response().Status
+		var httpErr *echo.HTTPError
+		if errors.As(err, &httpErr) {
+			got = httpErr.Code
+		}
+		if got != tt.want {
+			t.Errorf("RequireUser as %q = %d, want %d", tt.user, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package web
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/db"
+	"github.com/dunamismax/go-modern-scaffold/internal/jobs"
+	"github.com/labstack/echo/v4"
+)
+
+// jobsPageSize is the number of jobs the admin jobs page lists.
+const jobsPageSize = 100
+
+// thumbnailJob makes the thumbnail of an image attachment.
+var thumbnailJob = jobs.Kind[thumbnailPayload]{Name: "thumbnail"}
+
+// thumbnailPayload is the payload of thumbnailJob.
+type thumbnailPayload struct {
+	AttachmentID int64 `json:"attachment_id"`
+}
+
+// RegisterJobs registers the handlers of the jobs the handlers enqueue.
+func (h *Handlers) RegisterJobs(r *jobs.Registry) {
+	jobs.Register(r, thumbnailJob, h.makeThumbnail)
+	jobs.Register(r, webhookJob, h.deliverWebhook)
+}
+
+// JobCount is the number of jobs with a status.
+type JobCount struct {
+	Status string
+	Count  int64
+}
+
+// RenderJobs renders the admin list of the latest jobs, of the status in the
+// status query parameter if there is one.
+func (h *Handlers) RenderJobs(c echo.Context) error {
+	ctx := c.Request().Context()
+	status := c.QueryParam("status")
+	if status != "" && !slices.Contains(jobs.Statuses, status) {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid job status")
+	}
+
+	rows, err := h.queries.CountJobsByStatus(ctx)
+	if err != nil {
+		return internalError(err, "Failed to count jobs")
+	}
+	counts := make([]JobCount, len(jobs.Statuses))
+	for i, s := range jobs.Statuses {
+		counts[i].Status = s
+		for _, row := range rows {
+			if row.Status == s {
+				counts[i].Count = row.Count
+			}
+		}
+	}
+
+	list, err := h.queries.GetJobs(ctx, db.GetJobsParams{Status: status, Limit: jobsPageSize})
+	if err != nil {
+		return internalError(err, "Failed to get jobs")
+	}
+	return renderComponent(c, JobsPage(list, counts, status))
+}
+
+// RetryJob queues a dead job to be run again and renders its row.
+func (h *Handlers) RetryJob(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid job ID")
+	}
+
+	job, err := h.queries.RequeueDeadJob(c.Request().Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return echo.NewHTTPError(http.StatusNotFound, "Dead job not found")
+	}
+	if err != nil {
+		return internalError(err, "Failed to retry job")
+	}
+	return renderComponent(c, JobRow(job))
+}
+
+// jobElementID returns the DOM id of a rendered job.
+func jobElementID(id int64) string {
+	return "job-" + strconv.FormatInt(id, 10)
+}
+
+// jobTarget returns a CSS selector for a rendered job.
+func jobTarget(id int64) string {
+	return "#" + jobElementID(id)
+}
+
+// jobStatusClass returns the badge class of a job status.
+func jobStatusClass(status string) string {
+	switch status {
+	case jobs.Running:
+		return "badge-info"
+	case jobs.Succeeded:
+		return "badge-success"
+	case jobs.Dead:
+		return "badge-error"
+	}
+	return "badge-ghost"
+}
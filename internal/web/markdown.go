@@ -0,0 +1,41 @@
+package web
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/db"
+	"github.com/dunamismax/go-modern-scaffold/internal/markdown"
+	"github.com/labstack/echo/v4"
+)
+
+// renderBody returns the body of msg rendered to HTML. Rendered bodies are
+// cached by their content, so a message is only parsed again after it is
+// edited. updated_at cannot tell revisions apart, as it only has a
+// resolution of one second.
+func (h *Handlers) renderBody(msg db.Message) (string, error) {
+	sum := sha256.Sum256([]byte(msg.Body))
+	key := "message-html:" + hex.EncodeToString(sum[:])
+	if cached, found := h.cache.Get(key); found {
+		if html, ok := cached.(string); ok {
+			return html, nil
+		}
+	}
+
+	html, err := h.markdown.Render(msg.Body)
+	if err != nil {
+		return "", err
+	}
+	h.cache.Set(key, html, int64(len(html)))
+	return html, nil
+}
+
+// HighlightCSS serves the stylesheet for highlighted code blocks.
+func HighlightCSS(c echo.Context) error {
+	css, err := markdown.HighlightCSS()
+	if err != nil {
+		return internalError(err, "Failed to generate stylesheet")
+	}
+	return c.Blob(http.StatusOK, "text/css; charset=utf-8", css)
+}
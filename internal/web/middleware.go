@@ -0,0 +1,80 @@
+package web
+
+import (
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/metrics"
+	"github.com/labstack/echo/v4"
+)
+
+// AccessLog returns middleware that logs each request through logger.
+// Requests that complete with a status below 400 are logged with probability
+// sampleRate; client and server errors are always logged.
+func AccessLog(logger *slog.Logger, sampleRate float64) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				// Let the error handler write the response so the logged
+				// status matches what the client receives.
+				c.Error(err)
+			}
+
+			req := c.Request()
+			res := c.Response()
+			status := res.Status
+
+			level := slog.LevelInfo
+			switch {
+			case status >= http.StatusInternalServerError:
+				level = slog.LevelError
+			case status >= http.StatusBadRequest:
+				level = slog.LevelWarn
+			case sampleRate < 1 && rand.Float64() >= sampleRate:
+				return nil
+			}
+
+			attrs := []slog.Attr{
+				slog.String("method", req.Method),
+				slog.String("route", c.Path()),
+				slog.String("uri", req.RequestURI),
+				slog.Int("status", status),
+				slog.Duration("latency", time.Since(start)),
+				slog.Int64("bytes_in", req.ContentLength),
+				slog.Int64("bytes_out", res.Size),
+				slog.String("request_id", res.Header().Get(echo.HeaderXRequestID)),
+				slog.String("remote_ip", c.RealIP()),
+			}
+			if err != nil {
+				attrs = append(attrs, slog.String("error", err.Error()))
+			}
+			logger.LogAttrs(req.Context(), level, "http request", attrs...)
+			return nil
+		}
+	}
+}
+
+// Metrics returns middleware that records the status and latency of each
+// request in rec. It must come before AccessLog, which has errors written by
+// the error handler, to see the status the client receives. Event streams
+// are counted but not timed, as they last as long as the client stays.
+func Metrics(rec *metrics.Recorder) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+			res := c.Response()
+			stream := strings.HasPrefix(res.Header().Get(echo.HeaderContentType), "text/event-stream")
+			rec.Observe(res.Status, time.Since(start), !stream)
+			return nil
+		}
+	}
+}
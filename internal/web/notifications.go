@@ -0,0 +1,296 @@
+package web
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/db"
+	"github.com/dunamismax/go-modern-scaffold/internal/form"
+	"github.com/labstack/echo/v4"
+)
+
+// Kinds of notification, stored in notifications.kind.
+const (
+	notifyMention  = "mention"
+	notifyReply    = "reply"
+	notifyReaction = "reaction"
+)
+
+// notificationLimit is the most notifications listed at once, newest first.
+const notificationLimit = 100
+
+// notificationsChanged is the HX-Trigger event that makes the notification
+// badge in the layout reload.
+const notificationsChanged = "notifications-changed"
+
+// RenderNotifications renders the notifications page of the current user,
+// with their notification preferences.
+func (h *Handlers) RenderNotifications(c echo.Context) error {
+	notifications, err := h.notifications(c)
+	if err != nil {
+		return err
+	}
+	prefs, err := notificationPreferences(c.Request().Context(), h.queries, currentUser(c))
+	if err != nil {
+		return internalError(err, "Failed to get notification preferences")
+	}
+	return renderComponent(c, NotificationsPage(notifications, preferencesInput(prefs), false))
+}
+
+// RenderNotificationBadge renders the unread notification count shown in the
+// layout, or nothing for anonymous users, who have no inbox.
+func (h *Handlers) RenderNotificationBadge(c echo.Context) error {
+	if currentUser(c) == anonymousUser {
+		return c.NoContent(http.StatusOK)
+	}
+	count, err := h.queries.CountUnreadNotifications(c.Request().Context(), currentUser(c))
+	if err != nil {
+		return internalError(err, "Failed to count notifications")
+	}
+	return renderComponent(c, NotificationBadge(count))
+}
+
+// MarkNotificationRead marks a notification of the current user as read and
+// renders the updated list.
+func (h *Handlers) MarkNotificationRead(c echo.Context) error {
+	if err := h.markNotificationRead(c); err != nil {
+		return err
+	}
+	return h.renderNotificationList(c)
+}
+
+// MarkAllNotificationsRead marks every notification of the current user as
+// read and renders the updated list.
+func (h *Handlers) MarkAllNotificationsRead(c echo.Context) error {
+	if err := h.queries.MarkAllNotificationsRead(c.Request().Context(), currentUser(c)); err != nil {
+		return internalError(err, "Failed to mark notifications read")
+	}
+	return h.renderNotificationList(c)
+}
+
+// UpdateNotificationPreferences saves which kinds of notification the current
+// user gets and renders the preferences form again.
+func (h *Handlers) UpdateNotificationPreferences(c echo.Context) error {
+	var input NotificationPreferencesInput
+	if _, err := h.setNotificationPreferences(c, &input); err != nil {
+		return err
+	}
+	return renderComponent(c, NotificationPreferencesForm(input, true))
+}
+
+// APINotifications returns the notifications of the current user as JSON,
+// newest first.
+func (h *Handlers) APINotifications(c echo.Context) error {
+	notifications, err := h.notifications(c)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, notifications)
+}
+
+// APIUnreadNotifications returns the number of unread notifications of the
+// current user.
+func (h *Handlers) APIUnreadNotifications(c echo.Context) error {
+	count, err := h.queries.CountUnreadNotifications(c.Request().Context(), currentUser(c))
+	if err != nil {
+		return internalError(err, "Failed to count notifications")
+	}
+	return c.JSON(http.StatusOK, map[string]int64{"unread": count})
+}
+
+// APIMarkNotificationRead marks a notification of the current user as read.
+func (h *Handlers) APIMarkNotificationRead(c echo.Context) error {
+	if err := h.markNotificationRead(c); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// APIMarkAllNotificationsRead marks every notification of the current user
+// as read.
+func (h *Handlers) APIMarkAllNotificationsRead(c echo.Context) error {
+	if err := h.queries.MarkAllNotificationsRead(c.Request().Context(), currentUser(c)); err != nil {
+		return internalError(err, "Failed to mark notifications read")
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// APINotificationPreferences returns the notification preferences of the
+// current user.
+func (h *Handlers) APINotificationPreferences(c echo.Context) error {
+	prefs, err := notificationPreferences(c.Request().Context(), h.queries, currentUser(c))
+	if err != nil {
+		return internalError(err, "Failed to get notification preferences")
+	}
+	return c.JSON(http.StatusOK, prefs)
+}
+
+// APIUpdateNotificationPreferences replaces the notification preferences of
+// the current user and returns them.
+func (h *Handlers) APIUpdateNotificationPreferences(c echo.Context) error {
+	var input NotificationPreferencesInput
+	prefs, err := h.setNotificationPreferences(c, &input)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, prefs)
+}
+
+// notifications returns the latest notifications of the current user.
+func (h *Handlers) notifications(c echo.Context) ([]db.GetNotificationsRow, error) {
+	notifications, err := h.queries.GetNotifications(c.Request().Context(), db.GetNotificationsParams{
+		Recipient: currentUser(c),
+		Limit:     notificationLimit,
+	})
+	if err != nil {
+		return nil, internalError(err, "Failed to get notifications")
+	}
+	return notifications, nil
+}
+
+// renderNotificationList renders the notification list and tells the badge
+// to reload.
+func (h *Handlers) renderNotificationList(c echo.Context) error {
+	notifications, err := h.notifications(c)
+	if err != nil {
+		return err
+	}
+	c.Response().Header().Set("HX-Trigger", notificationsChanged)
+	return renderComponent(c, NotificationList(notifications))
+}
+
+// markNotificationRead marks the notification named by the :id route
+// parameter as read. Notifications of other users are not found.
+func (h *Handlers) markNotificationRead(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid notification ID")
+	}
+
+	n, err := h.queries.MarkNotificationRead(c.Request().Context(), db.MarkNotificationReadParams{
+		ID:        id,
+		Recipient: currentUser(c),
+	})
+	if err != nil {
+		return internalError(err, "Failed to mark notification read")
+	}
+	if n == 0 {
+		return echo.NewHTTPError(http.StatusNotFound, "Notification not found")
+	}
+	return nil
+}
+
+// setNotificationPreferences binds input and saves it as the notification
+// preferences of the current user.
+func (h *Handlers) setNotificationPreferences(c echo.Context, input *NotificationPreferencesInput) (db.NotificationPreference, error) {
+	if err := form.Bind(c, input); err != nil {
+		return db.NotificationPreference{}, err
+	}
+	prefs, err := h.queries.SetNotificationPreferences(c.Request().Context(), db.SetNotificationPreferencesParams{
+		Username:  currentUser(c),
+		Mentions:  input.Mentions,
+		Replies:   input.Replies,
+		Reactions: input.Reactions,
+	})
+	if err != nil {
+		return db.NotificationPreference{}, internalError(err, "Failed to save notification preferences")
+	}
+	return prefs, nil
+}
+
+// notifyMessage notifies the users mentioned in a new message and, for a
+// reply, the author of the message it replies to. A mentioned author is only
+// told once.
+func (h *Handlers) notifyMessage(ctx context.Context, q db.Querier, msg db.Message) error {
+	mentioned := make(map[string]bool)
+	for _, user := range h.markdown.Mentions(msg.Body) {
+		mentioned[user] = true
+		if err := notify(ctx, q, user, notifyMention, msg.ID, msg.Author); err != nil {
+			return err
+		}
+	}
+	if msg.ParentID == nil {
+		return nil
+	}
+
+	root, err := q.GetMessage(ctx, *msg.ParentID)
+	if err != nil {
+		return err
+	}
+	if mentioned[root.Author] {
+		return nil
+	}
+	return notify(ctx, q, root.Author, notifyReply, msg.ID, msg.Author)
+}
+
+// notify tells recipient that actor caused a notification of kind on a
+// message, unless recipient turned that kind off. Users are not notified of
+// their own actions, and anonymous users are never notified.
+func notify(ctx context.Context, q db.Querier, recipient, kind string, messageID int64, actor string) error {
+	if recipient == actor || recipient == anonymousUser {
+		return nil
+	}
+
+	prefs, err := notificationPreferences(ctx, q, recipient)
+	if err != nil {
+		return err
+	}
+	if !wantsNotification(prefs, kind) {
+		return nil
+	}
+
+	return q.CreateNotification(ctx, db.CreateNotificationParams{
+		Recipient: recipient,
+		Kind:      kind,
+		MessageID: messageID,
+		Actor:     actor,
+	})
+}
+
+// notificationPreferences returns the notification preferences of user.
+// Users who never saved any get every kind of notification.
+func notificationPreferences(ctx context.Context, q db.Querier, user string) (db.NotificationPreference, error) {
+	prefs, err := q.GetNotificationPreferences(ctx, user)
+	if errors.Is(err, sql.ErrNoRows) {
+		return db.NotificationPreference{Username: user, Mentions: true, Replies: true, Reactions: true}, nil
+	}
+	return prefs, err
+}
+
+// wantsNotification reports whether prefs enable notifications of kind.
+func wantsNotification(prefs db.NotificationPreference, kind string) bool {
+	switch kind {
+	case notifyMention:
+		return prefs.Mentions
+	case notifyReply:
+		return prefs.Replies
+	case notifyReaction:
+		return prefs.Reactions
+	}
+	return false
+}
+
+// preferencesInput returns the form input showing prefs.
+func preferencesInput(prefs db.NotificationPreference) NotificationPreferencesInput {
+	return NotificationPreferencesInput{
+		Mentions:  prefs.Mentions,
+		Replies:   prefs.Replies,
+		Reactions: prefs.Reactions,
+	}
+}
+
+// notificationText returns the sentence describing a notification.
+func notificationText(n db.Notification) string {
+	switch n.Kind {
+	case notifyMention:
+		return n.Actor + " mentioned you"
+	case notifyReply:
+		return n.Actor + " replied to your message"
+	case notifyReaction:
+		return n.Actor + " reacted to your message"
+	}
+	return n.Actor + " notified you"
+}
@@ -0,0 +1,207 @@
+package web
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/db"
+	"github.com/dunamismax/go-modern-scaffold/internal/events"
+	"github.com/dunamismax/go-modern-scaffold/internal/form"
+	"github.com/labstack/echo/v4"
+)
+
+// reactionEmoji are the emoji offered in the reaction bar, in display order.
+// They must match the oneof rule on ReactionInput.Emoji.
+var reactionEmoji = []string{"👍", "❤️", "😂", "🎉", "👀", "🚀"}
+
+// ReactionInput is the data submitted by a reaction bar button.
+type ReactionInput struct {
+	Emoji string `form:"emoji" json:"emoji" label:"Reaction" validate:"required,oneof=👍 ❤️ 😂 🎉 👀 🚀"`
+}
+
+// RenderReactions renders the reaction bar of a message, e.g. after another
+// user reacted to it.
+func (h *Handlers) RenderReactions(c echo.Context) error {
+	msg, err := h.message(c)
+	if err != nil {
+		return err
+	}
+
+	reactions, err := h.reactions(c, msg.ID)
+	if err != nil {
+		return err
+	}
+	return renderComponent(c, ReactionBar(msg.ID, reactions))
+}
+
+// ToggleReaction adds the submitted reaction of the current user to a
+// message, or removes it if it is already there, and renders the updated
+// reaction bar.
+func (h *Handlers) ToggleReaction(c echo.Context) error {
+	msg, err := h.message(c)
+	if err != nil {
+		return err
+	}
+
+	var input ReactionInput
+	if err := form.Bind(c, &input); err != nil {
+		return err
+	}
+	if err := h.toggleReaction(c, msg, input.Emoji); err != nil {
+		return err
+	}
+
+	reactions, err := h.reactions(c, msg.ID)
+	if err != nil {
+		return err
+	}
+	return renderComponent(c, ReactionBar(msg.ID, reactions))
+}
+
+// APIReactions returns the reaction counts of a message as JSON.
+func (h *Handlers) APIReactions(c echo.Context) error {
+	msg, err := h.message(c)
+	if err != nil {
+		return err
+	}
+
+	reactions, err := h.reactions(c, msg.ID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, reactions)
+}
+
+// APIToggleReaction toggles a reaction of the current user on a message and
+// returns the updated reaction counts.
+func (h *Handlers) APIToggleReaction(c echo.Context) error {
+	msg, err := h.message(c)
+	if err != nil {
+		return err
+	}
+
+	var input ReactionInput
+	if err := form.Bind(c, &input); err != nil {
+		return err
+	}
+	if err := h.toggleReaction(c, msg, input.Emoji); err != nil {
+		return err
+	}
+
+	reactions, err := h.reactions(c, msg.ID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, reactions)
+}
+
+// toggleReaction removes the current user's emoji reaction from msg, or adds
+// it if there was none, and tells connected clients about it. Adding a
+// reaction notifies the author of msg. Anonymous requests may not react, as
+// they would all share the reactions of the anonymous user.
+func (h *Handlers) toggleReaction(c echo.Context, msg db.Message, emoji string) error {
+	ctx := c.Request().Context()
+	user := currentUser(c)
+	if user == anonymousUser {
+		return echo.NewHTTPError(http.StatusForbidden, "Sign in to react to messages")
+	}
+
+	var changed bool
+	err := h.queries.InTx(ctx, func(q db.Querier) error {
+		removed, err := q.RemoveReaction(ctx, db.RemoveReactionParams{
+			MessageID: msg.ID,
+			Username:  user,
+			Emoji:     emoji,
+		})
+		if err != nil || removed > 0 {
+			changed = removed > 0
+			return err
+		}
+		added, err := q.AddReaction(ctx, db.AddReactionParams{
+			MessageID: msg.ID,
+			Username:  user,
+			Emoji:     emoji,
+		})
+		if err != nil || added == 0 {
+			return err
+		}
+		changed = true
+		return notify(ctx, q, msg.Author, notifyReaction, msg.ID, user)
+	})
+	if err != nil {
+		return internalError(err, "Failed to toggle reaction")
+	}
+	if !changed {
+		return nil
+	}
+
+	h.broker.Publish(events.Event{
+		Type:      events.ReactionChanged,
+		MessageID: msg.ID,
+		ParentID:  msg.ParentID,
+		ChannelID: msg.ChannelID,
+	})
+	return nil
+}
+
+// reactions returns the reaction counts of a single message.
+func (h *Handlers) reactions(c echo.Context, id int64) ([]db.GetReactionCountsRow, error) {
+	views, err := h.withReactions(c, []MessageView{{Message: db.Message{ID: id}}})
+	if err != nil {
+		return nil, err
+	}
+	return views[0].Reactions, nil
+}
+
+// withReactions fills in the reactions of views with a query per
+// maxLookupIDs views.
+// Reaction counts change too often to be worth caching with the messages.
+func (h *Handlers) withReactions(c echo.Context, views []MessageView) ([]MessageView, error) {
+	if len(views) == 0 {
+		return views, nil
+	}
+
+	ids := make([]int64, len(views))
+	byID := make(map[int64]*MessageView, len(views))
+	for i := range views {
+		ids[i] = views[i].ID
+		byID[views[i].ID] = &views[i]
+		views[i].Reactions = []db.GetReactionCountsRow{}
+	}
+
+	for chunk := range slices.Chunk(ids, maxLookupIDs) {
+		counts, err := h.queries.GetReactionCounts(c.Request().Context(), db.GetReactionCountsParams{
+			Username:   currentUser(c),
+			MessageIds: chunk,
+		})
+		if err != nil {
+			return nil, internalError(err, "Failed to get reactions")
+		}
+		for _, count := range counts {
+			if view, ok := byID[count.MessageID]; ok {
+				view.Reactions = append(view.Reactions, count)
+			}
+		}
+	}
+	return views, nil
+}
+
+// reactionButtons returns one entry per offered emoji, in display order, with
+// its count taken from reactions.
+func reactionButtons(reactions []db.GetReactionCountsRow) []db.GetReactionCountsRow {
+	buttons := make([]db.GetReactionCountsRow, len(reactionEmoji))
+	for i, emoji := range reactionEmoji {
+		buttons[i].Emoji = emoji
+		for _, r := range reactions {
+			if r.Emoji == emoji {
+				buttons[i] = r
+			}
+		}
+	}
+	return buttons
+}
+
+// reactionsElementID returns the DOM id of the reaction bar of a message.
+func reactionsElementID(id int64) string {
+	return messageElementID(id) + "-reactions"
+}
@@ -0,0 +1,85 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/db"
+	"github.com/dunamismax/go-modern-scaffold/internal/db/dbtest"
+	"github.com/dunamismax/go-modern-scaffold/internal/events"
+	"github.com/labstack/echo/v4"
+)
+
+// contextAs returns a context for a request made by user.
+func contextAs(user string) echo.Context {
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/", nil), httptest.NewRecorder())
+	c.Set(userContextKey, user)
+	return c
+}
+
+func TestToggleReaction(t *testing.T) {
+	ctx := context.Background()
+	store := db.NewStore(dbtest.Open(t))
+	broker := events.NewBroker()
+	defer broker.Close()
+	h := NewHandlers(store, nil, broker, nil, nil)
+
+	msg, err := store.CreateMessage(ctx, db.CreateMessageParams{Body: "hi", Author: "bob", ChannelID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := func(wantReactions, wantNotifications int64) {
+		t.Helper()
+		counts, err := store.GetReactionCounts(ctx, db.GetReactionCountsParams{Username: "alice", MessageIds: []int64{msg.ID}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var reactions int64
+		for _, c := range counts {
+			reactions += c.Count
+		}
+		notifications, err := store.CountUnreadNotifications(ctx, "bob")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reactions != wantReactions || notifications != wantNotifications {
+			t.Errorf("%d reactions, %d notifications; want %d, %d", reactions, notifications, wantReactions, wantNotifications)
+		}
+	}
+
+	if err := h.toggleReaction(contextAs("alice"), msg, "👍"); err != nil {
+		t.Fatal(err)
+	}
+	check(1, 1)
+	if err := h.toggleReaction(contextAs("alice"), msg, "👍"); err != nil {
+		t.Fatal(err)
+	}
+	check(0, 1)
+
+	// An even number of concurrent toggles leaves no reaction, and notifies
+	// once per reaction added.
+	const toggles = 10
+	var wg sync.WaitGroup
+	for range toggles {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := h.toggleReaction(contextAs("alice"), msg, "🎉"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	check(0, 1+toggles/2)
+
+	err = h.toggleReaction(contextAs(anonymousUser), msg, "👍")
+	var httpErr *echo.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.Code != http.StatusForbidden {
+		t.Errorf("anonymous toggleReaction = %v, want 403", err)
+	}
+	check(0, 1+toggles/2)
+}
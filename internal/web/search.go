@@ -0,0 +1,74 @@
+package web
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// searchResultLimit caps the number of messages returned by a search.
+const searchResultLimit = 50
+
+// Markers that SearchMessages asks FTS5's snippet() to place around matched
+// terms. Control characters are used rather than HTML so the snippet can be
+// escaped like any other user content before the highlights are rendered.
+const (
+	snippetMatchStart = "\x02"
+	snippetMatchEnd   = "\x03"
+)
+
+// snippetPart is a run of snippet text that is either a matched term or
+// surrounding context.
+type snippetPart struct {
+	Text  string
+	Match bool
+}
+
+// ftsQuery turns free-form user input into an FTS5 query. Each word is quoted
+// so FTS5 operators and punctuation in the input are matched literally, and
+// treated as a prefix so results update while the user is still typing.
+// It returns an empty string when the input contains no searchable words.
+func ftsQuery(input string) string {
+	words := strings.FieldsFunc(input, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	terms := make([]string, 0, len(words))
+	for _, w := range words {
+		terms = append(terms, `"`+w+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
+
+// splitSnippet splits an FTS5 snippet into plain and highlighted parts.
+func splitSnippet(snippet string) []snippetPart {
+	var parts []snippetPart
+	for snippet != "" {
+		before, rest, found := strings.Cut(snippet, snippetMatchStart)
+		if before != "" {
+			parts = append(parts, snippetPart{Text: before})
+		}
+		if !found {
+			break
+		}
+		match, after, _ := strings.Cut(rest, snippetMatchEnd)
+		if match != "" {
+			parts = append(parts, snippetPart{Text: match, Match: true})
+		}
+		snippet = after
+	}
+	return parts
+}
+
+// snippetHTML renders an FTS5 snippet as escaped HTML with matched terms
+// wrapped in <mark> elements.
+func snippetHTML(snippet string) string {
+	var b strings.Builder
+	for _, part := range splitSnippet(snippet) {
+		if part.Match {
+			b.WriteString("<mark>" + html.EscapeString(part.Text) + "</mark>")
+		} else {
+			b.WriteString(html.EscapeString(part.Text))
+		}
+	}
+	return b.String()
+}
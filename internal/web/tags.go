@@ -0,0 +1,61 @@
+package web
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/markdown"
+	"github.com/labstack/echo/v4"
+)
+
+// tagCloudLimit is the number of tags shown in the tag cloud.
+const tagCloudLimit = 30
+
+// RenderTag renders the messages tagged with the :tag route parameter.
+func (h *Handlers) RenderTag(c echo.Context) error {
+	tag, err := url.PathUnescape(c.Param("tag"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid tag")
+	}
+	tag = markdown.NormalizeTag(tag)
+
+	messages, err := h.taggedMessages(c, tag)
+	if err != nil {
+		return err
+	}
+	tags, err := h.queries.GetTagCounts(c.Request().Context(), tagCloudLimit)
+	if err != nil {
+		return internalError(err, "Failed to get tags")
+	}
+
+	return renderComponent(c, TagPage(tag, messages, tags))
+}
+
+// APITags returns the most used tags with the number of messages using each,
+// most used first.
+func (h *Handlers) APITags(c echo.Context) error {
+	tags, err := h.queries.GetTagCounts(c.Request().Context(), tagCloudLimit)
+	if err != nil {
+		return internalError(err, "Failed to get tags")
+	}
+	return c.JSON(http.StatusOK, tags)
+}
+
+// taggedMessages returns the messages tagged with tag, newest first.
+func (h *Handlers) taggedMessages(c echo.Context, tag string) ([]MessageView, error) {
+	rows, err := h.queries.GetMessagesByTag(c.Request().Context(), tag)
+	if err != nil {
+		return nil, internalError(err, "Failed to get tagged messages")
+	}
+
+	views := make([]MessageView, len(rows))
+	for i, row := range rows {
+		views[i] = MessageView{Message: row.Message, ReplyCount: row.ReplyCount}
+	}
+	return h.fillViews(c, views)
+}
+
+// tagURL returns the URL of the page listing the messages tagged with tag.
+func tagURL(tag string) string {
+	return "/tags/" + url.PathEscape(tag)
+}
@@ -0,0 +1,150 @@
+package web
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/db"
+	"github.com/dunamismax/go-modern-scaffold/internal/events"
+	"github.com/dunamismax/go-modern-scaffold/internal/form"
+	"github.com/labstack/echo/v4"
+)
+
+// RenderThread renders the replies to a root message and a reply form, to
+// be expanded inline below the message.
+func (h *Handlers) RenderThread(c echo.Context) error {
+	root, err := h.threadRoot(c)
+	if err != nil {
+		return err
+	}
+
+	replies, err := h.replyViews(c, root)
+	if err != nil {
+		return err
+	}
+
+	return renderComponent(c, Thread(root, replies, MessageInput{}, nil))
+}
+
+// CreateReply posts a reply to a root message and renders the updated thread.
+func (h *Handlers) CreateReply(c echo.Context) error {
+	root, err := h.threadRoot(c)
+	if err != nil {
+		return err
+	}
+
+	var input MessageInput
+	if err := form.Bind(c, &input); err != nil {
+		var errs form.Errors
+		if errors.As(err, &errs) {
+			return renderInvalidForm(c, errs, replyFormTarget(root.ID), ReplyForm(root, input, errs))
+		}
+		return err
+	}
+
+	reply, err := h.createMessage(c, db.CreateMessageParams{
+		Body:      input.Body,
+		Author:    currentUser(c),
+		ParentID:  &root.ID,
+		ChannelID: root.ChannelID,
+	}, nil, "")
+	if err != nil {
+		return internalError(err, "Failed to create reply")
+	}
+	h.messageChanged(events.MessageCreated, reply)
+
+	replies, err := h.replyViews(c, root)
+	if err != nil {
+		return err
+	}
+
+	return renderComponent(c, Thread(root, replies, MessageInput{}, nil))
+}
+
+// APIThread returns a root message and its replies, oldest first, as JSON.
+func (h *Handlers) APIThread(c echo.Context) error {
+	root, err := h.threadRoot(c)
+	if err != nil {
+		return err
+	}
+
+	replies, err := h.queries.GetReplies(c.Request().Context(), root.ID)
+	if err != nil {
+		return internalError(err, "Failed to get replies")
+	}
+
+	return c.JSON(http.StatusOK, apiThread{Message: root, Replies: replies})
+}
+
+// APICreateReply posts a reply to a root message and returns the reply. It
+// honors the Idempotency-Key header like APICreateMessage.
+func (h *Handlers) APICreateReply(c echo.Context) error {
+	root, err := h.threadRoot(c)
+	if err != nil {
+		return err
+	}
+
+	var input MessageInput
+	if err := form.Bind(c, &input); err != nil {
+		return err
+	}
+
+	reply, created, err := h.createAPIMessage(c, db.CreateMessageParams{
+		Body:      input.Body,
+		Author:    currentUser(c),
+		ParentID:  &root.ID,
+		ChannelID: root.ChannelID,
+	}, nil, "Failed to create reply")
+	if err != nil {
+		return err
+	}
+	if !created {
+		return c.JSON(http.StatusOK, reply)
+	}
+
+	h.messageChanged(events.MessageCreated, reply)
+	return c.JSON(http.StatusCreated, reply)
+}
+
+// replyViews returns the replies to root, oldest first, with their reactions.
+func (h *Handlers) replyViews(c echo.Context, root db.Message) ([]MessageView, error) {
+	replies, err := h.queries.GetReplies(c.Request().Context(), root.ID)
+	if err != nil {
+		return nil, internalError(err, "Failed to get replies")
+	}
+	views := make([]MessageView, len(replies))
+	for i, reply := range replies {
+		views[i] = MessageView{Message: reply}
+	}
+	return h.fillViews(c, views)
+}
+
+// threadRoot loads the message named by the :id route parameter, or the root
+// it replies to. Threads are one level deep, so a reply to a reply joins the
+// root's thread.
+func (h *Handlers) threadRoot(c echo.Context) (db.Message, error) {
+	msg, err := h.message(c)
+	if err != nil || msg.ParentID == nil {
+		return msg, err
+	}
+
+	root, err := h.queries.GetMessage(c.Request().Context(), *msg.ParentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return db.Message{}, echo.NewHTTPError(http.StatusNotFound, "Thread not found")
+	}
+	if err != nil {
+		return db.Message{}, internalError(err, "Failed to get thread")
+	}
+	return root, nil
+}
+
+// threadElementID returns the DOM id of the container a thread expands into.
+func threadElementID(id int64) string {
+	return messageElementID(id) + "-thread"
+}
+
+// replyFormTarget returns a CSS selector for the reply form of a thread.
+func replyFormTarget(id int64) string {
+	return "#" + messageElementID(id) + "-reply"
+}
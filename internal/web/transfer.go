@@ -0,0 +1,87 @@
+package web
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/transfer"
+	"github.com/labstack/echo/v4"
+)
+
+// APIExportMessages streams the messages created in the range given by the
+// "from" and "to" query parameters as a download in the format named by the
+// "format" parameter: ndjson (the default), csv or json.
+func (h *Handlers) APIExportMessages(c echo.Context) error {
+	format, err := transfer.ParseFormat(c.QueryParam("format"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	var filter transfer.Filter
+	if from := c.QueryParam("from"); from != "" {
+		if filter.Since, err = transfer.ParseTime(from); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
+	if to := c.QueryParam("to"); to != "" {
+		if filter.Until, err = transfer.ParseTime(to); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, format.ContentType())
+	res.Header().Set(echo.HeaderContentDisposition, `attachment; filename="messages.`+string(format)+`"`)
+	res.WriteHeader(http.StatusOK)
+
+	// The status is sent, so a failure can only cut the download short.
+	n, err := transfer.Export(c.Request().Context(), h.queries, res, format, filter)
+	if err != nil {
+		slog.Error("failed to export messages", "error", err, "exported", n)
+		return nil
+	}
+	slog.Info("exported messages", "format", format, "count", n)
+	return nil
+}
+
+// APIImportMessages creates messages from the file in the request body and
+// returns a transfer.Report. The format is taken from the "format" query
+// parameter or else the Content-Type header. Invalid records are listed in
+// the report without stopping the import.
+func (h *Handlers) APIImportMessages(c echo.Context) error {
+	format, err := importFormat(c)
+	if err != nil {
+		return err
+	}
+
+	importer := &transfer.Importer{
+		Store:     h.queries,
+		Validator: c.Echo().Validator,
+		Tags:      h.markdown.Tags,
+	}
+	report, err := importer.Import(c.Request().Context(), c.Request().Body, format)
+	if report.Imported > 0 {
+		// Imported messages may land in any channel.
+		h.cache.Clear()
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Import stopped: "+err.Error()).SetInternal(err)
+	}
+
+	slog.Info("imported messages", "format", format, "count", report.Imported, "errors", len(report.Errors))
+	return c.JSON(http.StatusOK, report)
+}
+
+// importFormat returns the format of an import request body.
+func importFormat(c echo.Context) (transfer.Format, error) {
+	if name := c.QueryParam("format"); name != "" {
+		format, err := transfer.ParseFormat(name)
+		if err != nil {
+			return "", echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return format, nil
+	}
+	if format, ok := transfer.FormatOf(c.Request().Header.Get(echo.HeaderContentType)); ok {
+		return format, nil
+	}
+	return "", echo.NewHTTPError(http.StatusUnsupportedMediaType, "Set the format query parameter or a Content-Type of application/x-ndjson, text/csv or application/json")
+}
@@ -0,0 +1,110 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/buildinfo"
+	"github.com/dunamismax/go-modern-scaffold/internal/db"
+	"github.com/labstack/echo/v4"
+)
+
+// MessageView is a message together with the data rendered alongside it. It
+// is also the JSON representation of a message in API lists.
+type MessageView struct {
+	db.Message
+	// BodyHTML is the body rendered from Markdown to sanitized HTML.
+	BodyHTML    string                    `json:"body_html"`
+	Attachments []db.Attachment           `json:"attachments"`
+	ReplyCount  int64                     `json:"reply_count"`
+	Reactions   []db.GetReactionCountsRow `json:"reactions"`
+}
+
+const (
+	// messagePageSize is the number of root messages in a page of a channel's
+	// message list, unless an API client asks for another.
+	messagePageSize = 50
+	// maxMessagePageSize caps the "limit" parameter of the message list API.
+	maxMessagePageSize = 200
+	// maxLookupIDs is the most IDs looked up by one IN (...) query, well under
+	// the 999 variables a statement may have in older SQLite versions.
+	maxLookupIDs = 500
+)
+
+// messagePage selects a page of a channel's message list: the Limit newest
+// root messages with IDs below BeforeID, or the newest if BeforeID is 0.
+type messagePage struct {
+	BeforeID int64
+	Limit    int64
+}
+
+// firstPage is the page of the message list shown when a channel is opened.
+var firstPage = messagePage{Limit: messagePageSize}
+
+// parseMessagePage reads a page of the message list from the "before" and
+// "limit" query parameters, defaulting to the first page.
+func parseMessagePage(c echo.Context) (messagePage, error) {
+	page := firstPage
+	if before := c.QueryParam("before"); before != "" {
+		id, err := strconv.ParseInt(before, 10, 64)
+		if err != nil || id <= 0 {
+			return messagePage{}, echo.NewHTTPError(http.StatusBadRequest, "Invalid before message ID")
+		}
+		page.BeforeID = id
+	}
+	if limit := c.QueryParam("limit"); limit != "" {
+		n, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil || n <= 0 || n > maxMessagePageSize {
+			return messagePage{}, echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Sprintf("Limit must be between 1 and %d", maxMessagePageSize))
+		}
+		page.Limit = n
+	}
+	return page, nil
+}
+
+// messageList returns a page of the root messages of a channel with their
+// reply counts, newest first, filled in by fillViews.
+func (h *Handlers) messageList(c echo.Context, channelID int64, page messagePage) ([]MessageView, error) {
+	rows, err := h.messages(c.Request().Context(), channelID, page)
+	if err != nil {
+		return nil, internalError(err, "Failed to get messages")
+	}
+
+	views := make([]MessageView, len(rows))
+	for i, row := range rows {
+		views[i] = MessageView{Message: row.Message, ReplyCount: row.ReplyCount}
+	}
+	return h.fillViews(c, views)
+}
+
+// fillViews fills in the rendered bodies, attachments and reactions of views.
+func (h *Handlers) fillViews(c echo.Context, views []MessageView) ([]MessageView, error) {
+	for i := range views {
+		html, err := h.renderBody(views[i].Message)
+		if err != nil {
+			return nil, internalError(err, "Failed to render message")
+		}
+		views[i].BodyHTML = html
+	}
+
+	views, err := h.withAttachments(c, views)
+	if err != nil {
+		return nil, err
+	}
+	return h.withReactions(c, views)
+}
+
+// versionLabel describes the version of the server for the footer, such as
+// "v0.3.0 (3f2c1a9b0d4e, dirty)".
+func versionLabel(build buildinfo.Info) string {
+	if build.Revision == "" {
+		return build.Version
+	}
+	label := build.Version + " (" + build.ShortRevision()
+	if build.Dirty {
+		label += ", dirty"
+	}
+	return label + ")"
+}
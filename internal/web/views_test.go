@@ -0,0 +1,138 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/cache"
+	"github.com/dunamismax/go-modern-scaffold/internal/config"
+	"github.com/dunamismax/go-modern-scaffold/internal/db"
+	"github.com/dunamismax/go-modern-scaffold/internal/db/dbtest"
+	"github.com/labstack/echo/v4"
+)
+
+// createMessages creates n root messages in the general channel and returns
+// their IDs, oldest first.
+func createMessages(t *testing.T, store db.Store, n int) []int64 {
+	t.Helper()
+	ctx := context.Background()
+	ids := make([]int64, n)
+	err := store.InTx(ctx, func(q db.Querier) error {
+		for i := range ids {
+			msg, err := q.CreateMessage(ctx, db.CreateMessageParams{Body: "message " + strconv.Itoa(i), Author: "alice", ChannelID: 1})
+			if err != nil {
+				return err
+			}
+			ids[i] = msg.ID
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ids
+}
+
+// newCache returns a small cache for tests.
+func newCache(t *testing.T) *cache.Cache {
+	t.Helper()
+	c, err := cache.New(&config.Cache{NumCounters: 1000, MaxCost: 100, BufferItems: 64})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestMessageListPages(t *testing.T) {
+	store := db.NewStore(dbtest.Open(t))
+	h := NewHandlers(store, newCache(t), nil, nil, nil)
+
+	ids := createMessages(t, store, 2*messagePageSize+10)
+	parent := ids[0]
+	if _, err := store.CreateMessage(context.Background(), db.CreateMessageParams{Body: "reply", Author: "bob", ParentID: &parent, ChannelID: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Following the ID of the last message of each page lists every root
+	// message once, newest first.
+	var got []int64
+	page := firstPage
+	for {
+		views, err := h.messageList(contextAs("alice"), 1, page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(views) > messagePageSize {
+			t.Fatalf("page of %d messages, want at most %d", len(views), messagePageSize)
+		}
+		for _, v := range views {
+			got = append(got, v.ID)
+		}
+		if len(views) < messagePageSize {
+			break
+		}
+		page.BeforeID = views[len(views)-1].ID
+	}
+	if len(got) != len(ids) {
+		t.Fatalf("listed %d messages, want %d", len(got), len(ids))
+	}
+	for i, id := range got {
+		if want := ids[len(ids)-1-i]; id != want {
+			t.Fatalf("message %d = %d, want %d", i, id, want)
+		}
+	}
+}
+
+func TestParseMessagePage(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    messagePage
+		wantErr bool
+	}{
+		{"", firstPage, false},
+		{"before=42", messagePage{BeforeID: 42, Limit: messagePageSize}, false},
+		{"before=42&limit=10", messagePage{BeforeID: 42, Limit: 10}, false},
+		{"limit=200", messagePage{Limit: 200}, false},
+		{"before=0", messagePage{}, true},
+		{"before=x", messagePage{}, true},
+		{"limit=0", messagePage{}, true},
+		{"limit=201", messagePage{}, true},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil)
+		c := echo.New().NewContext(req, httptest.NewRecorder())
+		got, err := parseMessagePage(c)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseMessagePage(%q) = %+v, %v; want %+v, error %t", tt.query, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestFillViewsBatches(t *testing.T) {
+	ctx := context.Background()
+	store := db.NewStore(dbtest.Open(t))
+	h := NewHandlers(store, newCache(t), nil, nil, nil)
+
+	// More messages than one IN (...) lookup takes, so the last one is found
+	// by a later query.
+	ids := createMessages(t, store, 2*maxLookupIDs+1)
+	last := ids[len(ids)-1]
+	if _, err := store.AddReaction(ctx, db.AddReactionParams{MessageID: last, Username: "bob", Emoji: "👍"}); err != nil {
+		t.Fatal(err)
+	}
+
+	views := make([]MessageView, len(ids))
+	for i, id := range ids {
+		views[i].ID = id
+	}
+	views, err := h.fillViews(contextAs("alice"), views)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := views[len(views)-1].Reactions; len(got) != 1 || got[0].Count != 1 {
+		t.Errorf("reactions of message %d = %+v, want one 👍", last, got)
+	}
+}
@@ -0,0 +1,534 @@
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/db"
+	"github.com/dunamismax/go-modern-scaffold/internal/events"
+	"github.com/dunamismax/go-modern-scaffold/internal/form"
+	"github.com/dunamismax/go-modern-scaffold/internal/jobs"
+	"github.com/dunamismax/go-modern-scaffold/internal/webhook"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// webhookDeliveriesPageSize is the number of deliveries listed for a
+	// webhook.
+	webhookDeliveriesPageSize = 50
+	// webhookTimeout bounds a request to a webhook, including reading the
+	// start of its response.
+	webhookTimeout = 10 * time.Second
+)
+
+// Statuses of a webhook delivery, as of its latest attempt.
+const (
+	deliveryPending   = "pending"
+	deliverySucceeded = "succeeded"
+	deliveryFailed    = "failed"
+)
+
+// webhookEvents lists the event types webhooks can subscribe to. It must
+// match the oneof rule on WebhookInput.Events.
+var webhookEvents = []string{
+	events.MessageCreated,
+	events.MessageUpdated,
+	events.MessageDeleted,
+	events.MessageRestored,
+}
+
+// webhookClient sends the requests to webhooks.
+var webhookClient = &http.Client{Timeout: webhookTimeout}
+
+// webhookJob sends a webhook delivery. Failed deliveries are retried with
+// the backoff of the job pool, over about 20 minutes.
+var webhookJob = jobs.Kind[webhookPayload]{Name: "webhook", MaxAttempts: 8}
+
+// webhookPayload is the payload of webhookJob.
+type webhookPayload struct {
+	DeliveryID int64 `json:"delivery_id"`
+}
+
+// webhookEvent is the JSON body sent to webhooks: an event and the message
+// it is about, as it was right after the change.
+type webhookEvent struct {
+	Event   string     `json:"event"`
+	Time    time.Time  `json:"time"`
+	Message db.Message `json:"message"`
+}
+
+// WebhookView is the JSON representation of a webhook, with its events as a
+// list. The secret is only filled in when the webhook is created, and left
+// out otherwise.
+type WebhookView struct {
+	db.Webhook
+	Secret string   `json:"secret,omitempty"`
+	Events []string `json:"events"`
+}
+
+// WebhookDeliveryView is a webhook delivery together with the log of its
+// attempts, oldest first.
+type WebhookDeliveryView struct {
+	db.WebhookDelivery
+	Payload    json.RawMessage     `json:"payload"`
+	AttemptLog []db.WebhookAttempt `json:"attempt_log"`
+}
+
+// RenderWebhooks renders the list of webhooks with the form for adding one.
+func (h *Handlers) RenderWebhooks(c echo.Context) error {
+	hooks, err := h.webhooks(c)
+	if err != nil {
+		return err
+	}
+	return renderComponent(c, WebhooksPage(hooks, WebhookInput{}, nil))
+}
+
+// CreateWebhook adds a webhook and renders its secret, which is not shown
+// again.
+func (h *Handlers) CreateWebhook(c echo.Context) error {
+	var input WebhookInput
+	hook, err := h.createWebhook(c, &input)
+	if err != nil {
+		var errs form.Errors
+		if errors.As(err, &errs) {
+			return renderInvalidForm(c, errs, "#webhook-form", WebhookForm(nil, input, errs))
+		}
+		return err
+	}
+	return renderComponent(c, WebhookCreated(hook))
+}
+
+// RenderWebhook renders the form for changing a webhook along with its latest
+// deliveries.
+func (h *Handlers) RenderWebhook(c echo.Context) error {
+	hook, err := h.webhook(c)
+	if err != nil {
+		return err
+	}
+	deliveries, err := h.webhookDeliveries(c, hook.ID)
+	if err != nil {
+		return err
+	}
+	input := WebhookInput{URL: hook.Url, Events: webhookEventList(hook.Events)}
+	return renderComponent(c, WebhookPage(hook, input, nil, deliveries))
+}
+
+// UpdateWebhook saves the URL, secret and events of a webhook and redirects
+// to its page.
+func (h *Handlers) UpdateWebhook(c echo.Context) error {
+	var input WebhookInput
+	hook, err := h.updateWebhook(c, &input)
+	if err != nil {
+		var errs form.Errors
+		if errors.As(err, &errs) {
+			return renderInvalidForm(c, errs, "#webhook-form", WebhookForm(&hook, input, errs))
+		}
+		return err
+	}
+	return redirect(c, webhookURL(hook.ID))
+}
+
+// DeleteWebhook deletes a webhook and redirects to the list of webhooks.
+func (h *Handlers) DeleteWebhook(c echo.Context) error {
+	if err := h.deleteWebhook(c); err != nil {
+		return err
+	}
+	return redirect(c, "/admin/webhooks")
+}
+
+// Redeliver sends a webhook delivery again and renders it.
+func (h *Handlers) Redeliver(c echo.Context) error {
+	delivery, err := h.redeliver(c)
+	if err != nil {
+		return err
+	}
+	return renderComponent(c, WebhookDeliveryRow(delivery))
+}
+
+// APIListWebhooks returns all webhooks as JSON.
+func (h *Handlers) APIListWebhooks(c echo.Context) error {
+	hooks, err := h.webhooks(c)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, hooks)
+}
+
+// APIGetWebhook returns a single webhook as JSON.
+func (h *Handlers) APIGetWebhook(c echo.Context) error {
+	hook, err := h.webhook(c)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, webhookView(hook))
+}
+
+// APICreateWebhook adds a webhook and returns it, with its secret.
+func (h *Handlers) APICreateWebhook(c echo.Context) error {
+	var input WebhookInput
+	hook, err := h.createWebhook(c, &input)
+	if err != nil {
+		return err
+	}
+	view := webhookView(hook)
+	view.Secret = hook.Secret
+	return c.JSON(http.StatusCreated, view)
+}
+
+// APIUpdateWebhook changes the URL, secret and events of a webhook and
+// returns it.
+func (h *Handlers) APIUpdateWebhook(c echo.Context) error {
+	var input WebhookInput
+	hook, err := h.updateWebhook(c, &input)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, webhookView(hook))
+}
+
+// APIDeleteWebhook deletes a webhook along with its deliveries.
+func (h *Handlers) APIDeleteWebhook(c echo.Context) error {
+	if err := h.deleteWebhook(c); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// APIWebhookDeliveries returns the latest deliveries of a webhook, newest
+// first, with their attempts.
+func (h *Handlers) APIWebhookDeliveries(c echo.Context) error {
+	hook, err := h.webhook(c)
+	if err != nil {
+		return err
+	}
+	deliveries, err := h.webhookDeliveries(c, hook.ID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, deliveries)
+}
+
+// APIRedeliver sends a webhook delivery again and returns it.
+func (h *Handlers) APIRedeliver(c echo.Context) error {
+	delivery, err := h.redeliver(c)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusAccepted, delivery)
+}
+
+// webhooks returns all webhooks.
+func (h *Handlers) webhooks(c echo.Context) ([]WebhookView, error) {
+	rows, err := h.queries.GetWebhooks(c.Request().Context())
+	if err != nil {
+		return nil, internalError(err, "Failed to get webhooks")
+	}
+	hooks := make([]WebhookView, len(rows))
+	for i, row := range rows {
+		hooks[i] = webhookView(row)
+	}
+	return hooks, nil
+}
+
+// createWebhook binds input and adds a webhook from it, generating its
+// secret if none was given.
+func (h *Handlers) createWebhook(c echo.Context, input *WebhookInput) (db.Webhook, error) {
+	if err := form.Bind(c, input); err != nil {
+		return db.Webhook{}, err
+	}
+	secret := input.Secret
+	if secret == "" {
+		secret = rand.Text()
+	}
+
+	hook, err := h.queries.CreateWebhook(c.Request().Context(), db.CreateWebhookParams{
+		Url:       input.URL,
+		Secret:    secret,
+		Events:    strings.Join(input.Events, ","),
+		CreatedBy: currentUser(c),
+	})
+	if err != nil {
+		return db.Webhook{}, internalError(err, "Failed to create webhook")
+	}
+	return hook, nil
+}
+
+// updateWebhook binds input and saves it to the webhook with the ID in the
+// :id route parameter. Its secret is kept unless a new one was given.
+func (h *Handlers) updateWebhook(c echo.Context, input *WebhookInput) (db.Webhook, error) {
+	hook, err := h.webhook(c)
+	if err != nil {
+		return db.Webhook{}, err
+	}
+	if err := form.Bind(c, input); err != nil {
+		return hook, err
+	}
+	secret := input.Secret
+	if secret == "" {
+		secret = hook.Secret
+	}
+
+	hook, err = h.queries.UpdateWebhook(c.Request().Context(), db.UpdateWebhookParams{
+		ID:     hook.ID,
+		Url:    input.URL,
+		Secret: secret,
+		Events: strings.Join(input.Events, ","),
+	})
+	if err != nil {
+		return db.Webhook{}, internalError(err, "Failed to update webhook")
+	}
+	return hook, nil
+}
+
+// deleteWebhook deletes the webhook with the ID in the :id route parameter
+// along with its deliveries. Jobs still queued for them find nothing to send.
+func (h *Handlers) deleteWebhook(c echo.Context) error {
+	hook, err := h.webhook(c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	err = h.queries.InTx(ctx, func(q db.Querier) error {
+		if err := q.DeleteWebhookAttempts(ctx, hook.ID); err != nil {
+			return err
+		}
+		if err := q.DeleteWebhookDeliveries(ctx, hook.ID); err != nil {
+			return err
+		}
+		return q.DeleteWebhook(ctx, hook.ID)
+	})
+	if err != nil {
+		return internalError(err, "Failed to delete webhook")
+	}
+	return nil
+}
+
+// webhook loads the webhook with the ID in the :id route parameter.
+func (h *Handlers) webhook(c echo.Context) (db.Webhook, error) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return db.Webhook{}, echo.NewHTTPError(http.StatusBadRequest, "Invalid webhook ID")
+	}
+	hook, err := h.queries.GetWebhook(c.Request().Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return db.Webhook{}, echo.NewHTTPError(http.StatusNotFound, "Webhook not found")
+	}
+	if err != nil {
+		return db.Webhook{}, internalError(err, "Failed to get webhook")
+	}
+	return hook, nil
+}
+
+// webhookDeliveries returns the latest deliveries of a webhook, newest first,
+// with their attempts.
+func (h *Handlers) webhookDeliveries(c echo.Context, webhookID int64) ([]WebhookDeliveryView, error) {
+	ctx := c.Request().Context()
+	rows, err := h.queries.GetWebhookDeliveries(ctx, db.GetWebhookDeliveriesParams{
+		WebhookID: webhookID,
+		Limit:     webhookDeliveriesPageSize,
+	})
+	if err != nil {
+		return nil, internalError(err, "Failed to get webhook deliveries")
+	}
+	return h.withAttemptLogs(ctx, rows)
+}
+
+// redeliver queues the webhook delivery with the ID in the :delivery route
+// parameter, of the webhook with the ID in the :id route parameter, to be
+// sent again.
+func (h *Handlers) redeliver(c echo.Context) (WebhookDeliveryView, error) {
+	webhookID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return WebhookDeliveryView{}, echo.NewHTTPError(http.StatusBadRequest, "Invalid webhook ID")
+	}
+	id, err := strconv.ParseInt(c.Param("delivery"), 10, 64)
+	if err != nil {
+		return WebhookDeliveryView{}, echo.NewHTTPError(http.StatusBadRequest, "Invalid delivery ID")
+	}
+
+	ctx := c.Request().Context()
+	var delivery db.WebhookDelivery
+	err = h.queries.InTx(ctx, func(q db.Querier) error {
+		var err error
+		if delivery, err = q.ResetWebhookDelivery(ctx, db.ResetWebhookDeliveryParams{
+			ID:        id,
+			WebhookID: webhookID,
+		}); err != nil {
+			return err
+		}
+		_, err = webhookJob.Enqueue(ctx, q, webhookPayload{DeliveryID: delivery.ID})
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return WebhookDeliveryView{}, echo.NewHTTPError(http.StatusNotFound, "Delivery not found")
+	}
+	if err != nil {
+		return WebhookDeliveryView{}, internalError(err, "Failed to redeliver")
+	}
+
+	views, err := h.withAttemptLogs(ctx, []db.WebhookDelivery{delivery})
+	if err != nil {
+		return WebhookDeliveryView{}, err
+	}
+	return views[0], nil
+}
+
+// withAttemptLogs returns views of deliveries with their attempts.
+func (h *Handlers) withAttemptLogs(ctx context.Context, deliveries []db.WebhookDelivery) ([]WebhookDeliveryView, error) {
+	views := make([]WebhookDeliveryView, len(deliveries))
+	if len(deliveries) == 0 {
+		return views, nil
+	}
+
+	ids := make([]int64, len(deliveries))
+	byID := make(map[int64]*WebhookDeliveryView, len(deliveries))
+	for i, d := range deliveries {
+		ids[i] = d.ID
+		views[i] = WebhookDeliveryView{WebhookDelivery: d, Payload: json.RawMessage(d.Payload)}
+		byID[d.ID] = &views[i]
+	}
+	attempts, err := h.queries.GetWebhookAttempts(ctx, ids)
+	if err != nil {
+		return nil, internalError(err, "Failed to get webhook attempts")
+	}
+	for _, a := range attempts {
+		if view, ok := byID[a.DeliveryID]; ok {
+			view.AttemptLog = append(view.AttemptLog, a)
+		}
+	}
+	return views, nil
+}
+
+// queueWebhooks queues deliveries of an event about msg to the webhooks
+// subscribed to it. Queued with the Querier of the change's transaction, they
+// are only sent if the change is committed.
+func queueWebhooks(ctx context.Context, q db.Querier, event string, msg db.Message) error {
+	hooks, err := q.GetEventWebhooks(ctx, event)
+	if err != nil || len(hooks) == 0 {
+		return err
+	}
+	payload, err := json.Marshal(webhookEvent{Event: event, Time: time.Now().UTC(), Message: msg})
+	if err != nil {
+		return err
+	}
+	for _, hook := range hooks {
+		delivery, err := q.CreateWebhookDelivery(ctx, db.CreateWebhookDeliveryParams{
+			WebhookID: hook.ID,
+			Event:     event,
+			Payload:   string(payload),
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := webhookJob.Enqueue(ctx, q, webhookPayload{DeliveryID: delivery.ID}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deliverWebhook sends a webhook delivery and logs the attempt. It fails if
+// the webhook did not accept the delivery, so that the job is retried.
+func (h *Handlers) deliverWebhook(ctx context.Context, p webhookPayload) error {
+	row, err := h.queries.GetWebhookDelivery(ctx, p.DeliveryID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil // the webhook was deleted
+	}
+	if err != nil {
+		return err
+	}
+	delivery := row.WebhookDelivery
+
+	start := time.Now()
+	code, sendErr := webhook.Send(ctx, webhookClient, webhook.Notification{
+		URL:        row.Url,
+		Secret:     row.Secret,
+		Event:      delivery.Event,
+		DeliveryID: delivery.ID,
+		Payload:    []byte(delivery.Payload),
+	})
+	attempt := db.CreateWebhookAttemptParams{
+		DeliveryID: delivery.ID,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	result := db.SetWebhookDeliveryResultParams{ID: delivery.ID, Status: deliverySucceeded}
+	if code != 0 {
+		code := int64(code)
+		attempt.ResponseCode = &code
+		result.ResponseCode = &code
+	}
+	if sendErr != nil {
+		msg := sendErr.Error()
+		attempt.Error = &msg
+		result.Status = deliveryFailed
+	}
+
+	// The attempt is logged even if it was cut short by the server stopping.
+	ctx = context.WithoutCancel(ctx)
+	if err := h.queries.InTx(ctx, func(q db.Querier) error {
+		if err := q.CreateWebhookAttempt(ctx, attempt); err != nil {
+			return err
+		}
+		return q.SetWebhookDeliveryResult(ctx, result)
+	}); err != nil {
+		return err
+	}
+	return sendErr
+}
+
+// maskSecret hides all of secret but its last characters, enough to tell
+// secrets apart.
+func maskSecret(secret string) string {
+	const shown = 4
+	if len(secret) <= 2*shown {
+		return strings.Repeat("•", 8)
+	}
+	return strings.Repeat("•", 8) + secret[len(secret)-shown:]
+}
+
+// webhookView returns the JSON representation of hook, without its secret.
+func webhookView(hook db.Webhook) WebhookView {
+	return WebhookView{Webhook: hook, Events: webhookEventList(hook.Events)}
+}
+
+// webhookEventList splits the events column of a webhook into a list.
+func webhookEventList(column string) []string {
+	if column == "" {
+		return []string{}
+	}
+	return strings.Split(column, ",")
+}
+
+// webhookURL returns the URL of the admin page of a webhook.
+func webhookURL(id int64) string {
+	return "/admin/webhooks/" + strconv.FormatInt(id, 10)
+}
+
+// deliveryElementID returns the DOM id of a rendered webhook delivery.
+func deliveryElementID(id int64) string {
+	return "delivery-" + strconv.FormatInt(id, 10)
+}
+
+// deliveryTarget returns a CSS selector for a rendered webhook delivery.
+func deliveryTarget(id int64) string {
+	return "#" + deliveryElementID(id)
+}
+
+// deliveryStatusClass returns the badge class of a delivery status.
+func deliveryStatusClass(status string) string {
+	switch status {
+	case deliverySucceeded:
+		return "badge-success"
+	case deliveryFailed:
+		return "badge-error"
+	}
+	return "badge-ghost"
+}
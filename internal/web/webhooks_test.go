@@ -0,0 +1,166 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/db"
+	"github.com/dunamismax/go-modern-scaffold/internal/db/dbtest"
+	"github.com/dunamismax/go-modern-scaffold/internal/webhook"
+	"github.com/labstack/echo/v4"
+)
+
+func TestWebhookViewOmitsSecret(t *testing.T) {
+	hook := db.Webhook{ID: 1, Url: "https://example.com/hook", Secret: "s3cr3t-value", Events: "message.created"}
+
+	out, err := json.Marshal(webhookView(hook))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(out), "secret") {
+		t.Errorf("webhookView JSON = %s, want no secret", out)
+	}
+
+	view := webhookView(hook)
+	view.Secret = hook.Secret
+	out, err = json.Marshal(view)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["secret"] != hook.Secret {
+		t.Errorf("secret = %v, want %q when set", got["secret"], hook.Secret)
+	}
+}
+
+func TestMaskSecret(t *testing.T) {
+	tests := []struct {
+		secret string
+		want   string
+	}{
+		{"", "••••••••"},
+		{"short", "••••••••"},
+		{"12345678", "••••••••"},
+		{"4UJWFUQ3ZRLJSODFAJAF53CZT3", "••••••••CZT3"},
+	}
+	for _, tt := range tests {
+		if got := maskSecret(tt.secret); got != tt.want {
+			t.Errorf("maskSecret(%q) = %q, want %q", tt.secret, got, tt.want)
+		}
+	}
+}
+
+// queuedWebhookJobs returns the payloads of the webhook jobs waiting to run.
+func queuedWebhookJobs(t *testing.T, q db.Querier) []webhookPayload {
+	t.Helper()
+	jobs, err := q.GetJobs(context.Background(), db.GetJobsParams{Status: "queued", Limit: 100})
+	if err != nil {
+		t.Fatalf("GetJobs: %v", err)
+	}
+	var payloads []webhookPayload
+	for _, job := range jobs {
+		if job.Type != webhookJob.Name {
+			continue
+		}
+		var p webhookPayload
+		if err := json.Unmarshal([]byte(job.Payload), &p); err != nil {
+			t.Fatal(err)
+		}
+		payloads = append(payloads, p)
+	}
+	return payloads
+}
+
+func TestWebhookRetryAndRedeliver(t *testing.T) {
+	ctx := context.Background()
+	store := db.NewStore(dbtest.Open(t))
+	h := NewHandlers(store, nil, nil, nil, nil)
+
+	// The receiver fails the first request and accepts the others.
+	const secret = "s3cr3t"
+	var mu sync.Mutex
+	var deliveryIDs []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		deliveryIDs = append(deliveryIDs, r.Header.Get(webhook.DeliveryHeader))
+		if len(deliveryIDs) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	hook, err := store.CreateWebhook(ctx, db.CreateWebhookParams{Url: srv.URL, Secret: secret, CreatedBy: "root"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := store.CreateMessage(ctx, db.CreateMessageParams{Body: "hi", Author: "alice", ChannelID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := queueWebhooks(ctx, store, "message.created", msg); err != nil {
+		t.Fatal(err)
+	}
+	queued := queuedWebhookJobs(t, store)
+	if len(queued) != 1 {
+		t.Fatalf("queued %d webhook jobs, want 1", len(queued))
+	}
+	p := queued[0]
+
+	// A failed delivery returns an error, so that its job is retried.
+	if err := h.deliverWebhook(ctx, p); err == nil {
+		t.Fatal("deliverWebhook to a failing receiver returned no error")
+	}
+	if err := h.deliverWebhook(ctx, p); err != nil {
+		t.Fatalf("retried deliverWebhook: %v", err)
+	}
+
+	// Redelivering queues the same delivery again.
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	c := echo.New().NewContext(req, httptest.NewRecorder())
+	c.SetParamNames("id", "delivery")
+	c.SetParamValues(strconv.FormatInt(hook.ID, 10), strconv.FormatInt(p.DeliveryID, 10))
+	view, err := h.redeliver(c)
+	if err != nil {
+		t.Fatalf("redeliver: %v", err)
+	}
+	if view.ID != p.DeliveryID || view.Status != deliveryPending {
+		t.Errorf("redeliver = delivery %d, status %s; want %d, %s", view.ID, view.Status, p.DeliveryID, deliveryPending)
+	}
+	queued = queuedWebhookJobs(t, store)
+	if len(queued) != 2 || queued[0] != queued[1] {
+		t.Fatalf("queued webhook jobs %v, want the delivery twice", queued)
+	}
+	if err := h.deliverWebhook(ctx, queued[0]); err != nil {
+		t.Fatalf("redelivered deliverWebhook: %v", err)
+	}
+
+	want := strconv.FormatInt(p.DeliveryID, 10)
+	if len(deliveryIDs) != 3 {
+		t.Fatalf("receiver got %d requests, want 3", len(deliveryIDs))
+	}
+	for i, id := range deliveryIDs {
+		if id != want {
+			t.Errorf("request %d: %s = %q, want %q", i+1, webhook.DeliveryHeader, id, want)
+		}
+	}
+
+	views, err := h.webhookDeliveries(c, hook.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(views) != 1 || views[0].Status != deliverySucceeded || len(views[0].AttemptLog) != 3 {
+		t.Errorf("deliveries = %+v, want one succeeded delivery with 3 attempts", views)
+	}
+}
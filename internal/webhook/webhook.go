@@ -0,0 +1,112 @@
+// Package webhook sends signed notifications of events to the URLs subscribed
+// to them, and checks the signatures of notifications received.
+//
+// A notification is a POST of a JSON payload. Its TimestampHeader holds the
+// Unix time it was sent at, and its SignatureHeader "sha256=" followed by the
+// hex-encoded HMAC-SHA256 of the timestamp, a dot and the payload, keyed with
+// the secret of the subscription. Receivers check both with Verify, which
+// turns down notifications sent too long ago so that captured ones cannot be
+// replayed.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dunamismax/go-modern-scaffold/internal/buildinfo"
+)
+
+// Headers of a notification.
+const (
+	// EventHeader names the event notified of.
+	EventHeader = "X-Webhook-Event"
+	// DeliveryHeader identifies the delivery, which stays the same when a
+	// notification is sent again, so that receivers can skip duplicates.
+	DeliveryHeader = "X-Webhook-Delivery"
+	// TimestampHeader is the Unix time the notification was sent at.
+	TimestampHeader = "X-Webhook-Timestamp"
+	// SignatureHeader is the signature of the timestamp and payload.
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+// signaturePrefix names the algorithm of a signature.
+const signaturePrefix = "sha256="
+
+// Errors returned by Verify.
+var (
+	ErrInvalidSignature = errors.New("webhook: invalid signature")
+	ErrExpired          = errors.New("webhook: timestamp outside tolerance")
+)
+
+// Sign returns the signature of payload sent at t, keyed with secret.
+func Sign(secret string, t time.Time, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(t.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks that a notification with header and payload was signed with
+// secret, and sent no more than tolerance away from now.
+func Verify(secret string, header http.Header, payload []byte, tolerance time.Duration) error {
+	unix, err := strconv.ParseInt(header.Get(TimestampHeader), 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	t := time.Unix(unix, 0)
+	if d := time.Since(t); d > tolerance || d < -tolerance {
+		return ErrExpired
+	}
+	if !hmac.Equal([]byte(header.Get(SignatureHeader)), []byte(Sign(secret, t, payload))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Notification is an event to be sent to a subscribed URL.
+type Notification struct {
+	URL        string
+	Secret     string
+	Event      string
+	DeliveryID int64
+	Payload    []byte
+}
+
+// Send posts n with client, signed as of now. It returns the status code of
+// the response, or 0 if there was none, and an error unless the receiver
+// answered with a 2xx status.
+func Send(ctx context.Context, client *http.Client, n Notification) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(n.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-modern-scaffold-webhook/"+buildinfo.Get().Version)
+	req.Header.Set(EventHeader, n.Event)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(n.DeliveryID, 10))
+	now := time.Now()
+	req.Header.Set(TimestampHeader, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(SignatureHeader, Sign(n.Secret, now, n.Payload))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Read a little of the body so the connection can be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
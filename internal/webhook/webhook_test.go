@@ -0,0 +1,127 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// signedHeader returns the headers of payload signed with secret at t.
+func signedHeader(secret string, t time.Time, payload []byte) http.Header {
+	h := http.Header{}
+	h.Set(TimestampHeader, strconv.FormatInt(t.Unix(), 10))
+	h.Set(SignatureHeader, Sign(secret, t, payload))
+	return h
+}
+
+func TestVerify(t *testing.T) {
+	const secret = "s3cr3t"
+	payload := []byte(`{"event":"message.created"}`)
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		header http.Header
+		body   []byte
+		secret string
+		want   error
+	}{
+		{"round trip", signedHeader(secret, now, payload), payload, secret, nil},
+		{"within tolerance", signedHeader(secret, now.Add(-4*time.Minute), payload), payload, secret, nil},
+		{"tampered payload", signedHeader(secret, now, payload), []byte(`{"event":"message.deleted"}`), secret, ErrInvalidSignature},
+		{"wrong secret", signedHeader(secret, now, payload), payload, "other", ErrInvalidSignature},
+		{"expired", signedHeader(secret, now.Add(-10*time.Minute), payload), payload, secret, ErrExpired},
+		{"from the future", signedHeader(secret, now.Add(10*time.Minute), payload), payload, secret, ErrExpired},
+		{"no timestamp", http.Header{SignatureHeader: {Sign(secret, now, payload)}}, payload, secret, ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Verify(tt.secret, tt.header, tt.body, 5*time.Minute); !errors.Is(err, tt.want) {
+				t.Errorf("Verify() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyTamperedTimestamp(t *testing.T) {
+	const secret = "s3cr3t"
+	payload := []byte(`{}`)
+	header := signedHeader(secret, time.Now().Add(-time.Minute), payload)
+	// Moving the timestamp forward breaks the signature.
+	header.Set(TimestampHeader, strconv.FormatInt(time.Now().Unix(), 10))
+	if err := Verify(secret, header, payload, 5*time.Minute); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify() = %v, want %v", err, ErrInvalidSignature)
+	}
+}
+
+func TestSend(t *testing.T) {
+	const secret = "s3cr3t"
+	payload := []byte(`{"event":"message.created"}`)
+
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{"ok", http.StatusOK, false},
+		{"no content", http.StatusNoContent, false},
+		{"client error", http.StatusNotFound, true},
+		{"server error", http.StatusInternalServerError, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received *http.Request
+			var body []byte
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = r
+				body, _ = io.ReadAll(r.Body)
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			n := Notification{URL: srv.URL, Secret: secret, Event: "message.created", DeliveryID: 42, Payload: payload}
+			code, err := Send(context.Background(), srv.Client(), n)
+			if code != tt.status {
+				t.Errorf("Send() status = %d, want %d", code, tt.status)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Send() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			if received == nil {
+				t.Fatal("receiver got no request")
+			}
+			if received.Method != http.MethodPost {
+				t.Errorf("method = %s, want POST", received.Method)
+			}
+			if got := received.Header.Get(EventHeader); got != "message.created" {
+				t.Errorf("%s = %q, want message.created", EventHeader, got)
+			}
+			if got := received.Header.Get(DeliveryHeader); got != "42" {
+				t.Errorf("%s = %q, want 42", DeliveryHeader, got)
+			}
+			if err := Verify(secret, received.Header, body, time.Minute); err != nil {
+				t.Errorf("Verify() of the request sent = %v", err)
+			}
+		})
+	}
+}
+
+func TestSendConnectionError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	url := srv.URL
+	srv.Close()
+
+	code, err := Send(context.Background(), http.DefaultClient, Notification{URL: url, Payload: []byte(`{}`)})
+	if err == nil {
+		t.Error("Send() to a closed server returned no error")
+	}
+	if code != 0 {
+		t.Errorf("Send() status = %d, want 0", code)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/magefile/mage/mg"
 	"github.com/magefile/mage/sh"
@@ -36,9 +37,32 @@ var (
 	npmCmd    = "npm"
 	)
 
+const (
+	// buildTags compiles in mattn/go-sqlite3 with FTS5, which message search
+	// needs. Without it, only the pure-Go driver is built.
+	buildTags = "sqlite_fts5"
+
+	// pureGoTags selects the pure-Go SQLite driver so binaries build without CGO.
+	// modernc.org/sqlite always includes FTS5.
+	pureGoTags = "puresqlite"
+)
+
+// pureGoEnv returns env with CGO disabled.
+func pureGoEnv(env map[string]string) map[string]string {
+	out := map[string]string{"CGO_ENABLED": "0"}
+	for k, v := range env {
+		out[k] = v
+	}
+	return out
+}
+
+// buildinfoPkg is the package the version and build time are linked into.
+const buildinfoPkg = "github.com/dunamismax/go-modern-scaffold/internal/buildinfo"
+
 // ldflags returns the linker flags for building the binaries.
 func ldflags() string {
-	return fmt.Sprintf("-X main.version=%s", Version)
+	return fmt.Sprintf("-X %s.Version=%s -X %s.BuildTime=%s",
+		buildinfoPkg, Version, buildinfoPkg, time.Now().UTC().Format(time.RFC3339))
 }
 
 // -----------------------------------------------------------------------------
@@ -68,13 +92,13 @@ func (Build) All() error {
 // Server builds the main web server binary.
 func (Build) Server() error {
 	fmt.Println("Building server...")
-	return goBuild("-ldflags", ldflags(), "-o", "bin/server", "./cmd/server")
+	return goBuild("-tags", buildTags, "-ldflags", ldflags(), "-o", "bin/server", "./cmd/server")
 }
 
 // CLI builds the command-line interface binary.
 func (Build) CLI() error {
 	fmt.Println("Building CLI...")
-	return goBuild("-ldflags", ldflags(), "-o", "bin/cli", "./cmd/cli")
+	return goBuild("-tags", buildTags, "-ldflags", ldflags(), "-o", "bin/cli", "./cmd/cli")
 }
 
 // -----------------------------------------------------------------------------
@@ -123,10 +147,17 @@ func Format() error {
 
 type DB mg.Namespace
 
-// Migrate runs database migrations using Goose.
+// Migrate applies the pending migrations to the database in DB_URL with the
+// migrations embedded in the CLI.
 func (DB) Migrate() error {
 	fmt.Println("Running database migrations...")
-	return sh.Run("/Users/sawyer/go/bin/goose", "-dir", "db/migrations", "sqlite3", "./app.db", "up")
+	return goRun("-tags", buildTags, "./cmd/cli", "admin", "migrate", "up")
+}
+
+// Seed fills the database in DB_URL with sample data.
+func (DB) Seed() error {
+	fmt.Println("Seeding the database...")
+	return goRun("-tags", buildTags, "./cmd/cli", "admin", "seed")
 }
 
 // -----------------------------------------------------------------------------
@@ -147,16 +178,20 @@ func (Check) Lint() error {
 	return goVet("./...")
 }
 
-// Test runs all unit tests.
+// Test runs all unit tests against both SQLite drivers.
 func (Check) Test() error {
-	fmt.Println("Running tests...")
-	return goTest("-v", "-race", "-cover", "./...")
+	fmt.Println("Running tests (mattn/go-sqlite3)...")
+	if err := goTest("-v", "-race", "-cover", "-tags", buildTags, "./..."); err != nil {
+		return err
+	}
+	fmt.Println("Running tests (modernc.org/sqlite)...")
+	return sh.RunWith(pureGoEnv(nil), goCmd, "test", "-v", "-cover", "-tags", pureGoTags, "./...")
 }
 
 // Cover runs tests and displays coverage in the browser.
 func (Check) Cover() error {
 	fmt.Println("Running tests and displaying coverage...")
-	if err := goTest("-coverprofile=coverage.out", "./..."); err != nil {
+	if err := goTest("-coverprofile=coverage.out", "-tags", buildTags, "./..."); err != nil {
 		return err
 	}
 	return sh.Run(goCmd, "tool", "cover", "-html=coverage.out")
@@ -203,14 +238,15 @@ func (Release) Darwin() error {
 // release is a helper function to cross-compile binaries.
 func release(goos, goarch string) error {
 	fmt.Printf("Building release for %s/%s...\n", goos, goarch)
-	env := map[string]string{"GOOS": goos, "GOARCH": goarch}
+	// Cross-compiling cannot use CGO, so releases always use the pure-Go SQLite driver.
+	env := pureGoEnv(map[string]string{"GOOS": goos, "GOARCH": goarch})
 
 	// Build server
 	serverOut := filepath.Join("bin", fmt.Sprintf("server-%s-%s", goos, goarch))
 	if goos == "windows" {
 		serverOut += ".exe"
 	}
-	if err := sh.RunWith(env, goCmd, "build", "-ldflags", ldflags(), "-o", serverOut, "./cmd/server"); err != nil {
+	if err := sh.RunWith(env, goCmd, "build", "-tags", pureGoTags, "-ldflags", ldflags(), "-o", serverOut, "./cmd/server"); err != nil {
 		return err
 	}
 
@@ -219,7 +255,7 @@ func release(goos, goarch string) error {
 	if goos == "windows" {
 		cliOut += ".exe"
 	}
-	return sh.RunWith(env, goCmd, "build", "-ldflags", ldflags(), "-o", cliOut, "./cmd/cli")
+	return sh.RunWith(env, goCmd, "build", "-tags", pureGoTags, "-ldflags", ldflags(), "-o", cliOut, "./cmd/cli")
 }
 
 